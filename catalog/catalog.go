@@ -0,0 +1,231 @@
+// Package catalog describes the actions the Decider can choose between: where
+// each one runs, its kind and tier, what it costs and how much it consumes.
+// The Decider, the predictor proxy and the invoker tools share it so an action
+// name means the same thing everywhere; unknown names are errors.
+package catalog
+
+import (
+"encoding/json"
+"fmt"
+"os"
+"path/filepath"
+"sort"
+"strings"
+
+yaml "go.yaml.in/yaml/v2"
+)
+
+var (
+kinds = map[string]bool{"local": true, "edge": true, "cloud": true}
+tiers = map[string]bool{"low": true, "med": true, "high": true}
+)
+
+// Action is one catalog entry. Name is "<site>:<tier>", e.g. "edge1:med".
+type Action struct {
+Name      string  `json:"name" yaml:"name"`
+Site      string  `json:"site" yaml:"site"`
+Kind      string  `json:"kind" yaml:"kind"` // local | edge | cloud
+Tier      string  `json:"tier" yaml:"tier"` // low | med | high
+CostMs    float64 `json:"cost_ms" yaml:"cost_ms"`
+Intensity float64 `json:"intensity" yaml:"intensity"` // scalar resource use, for fairness and quotas
+CPU       float64 `json:"cpu" yaml:"cpu"`             // cores
+MemMB     float64 `json:"mem_mb" yaml:"mem_mb"`
+NetMbps   float64 `json:"net_mbps" yaml:"net_mbps"`
+Capacity  int     `json:"capacity" yaml:"capacity"` // concurrent executions the site can hold
+}
+
+// Catalog is an immutable set of actions.
+type Catalog struct {
+byName map[string]Action
+names  []string
+}
+
+// defaults derive cost and resources from kind and tier; a file may override any of them.
+var (
+kindCostMs    = map[string]float64{"local": 0, "edge": 15, "cloud": 40}
+tierCostMs    = map[string]float64{"low": 0, "med": 40, "high": 120}
+kindIntensity = map[string]float64{"local": 0, "edge": 1, "cloud": 2}
+tierIntensity = map[string]float64{"low": 1, "med": 2, "high": 3}
+tierCPU       = map[string]float64{"low": 0.5, "med": 1, "high": 2}
+tierMemMB     = map[string]float64{"low": 256, "med": 512, "high": 1024}
+kindCapacity  = map[string]int{"local": 1, "edge": 8, "cloud": 64}
+)
+
+// given marks the cost fields a catalog file set explicitly, so an explicit 0
+// is kept instead of being read as unset.
+type given struct{ costMs, intensity bool }
+
+func fillDefaults(a *Action, g given) {
+if a.CostMs == 0 && !g.costMs {
+a.CostMs = kindCostMs[a.Kind] + tierCostMs[a.Tier]
+}
+if a.Intensity == 0 && !g.intensity {
+a.Intensity = kindIntensity[a.Kind] + tierIntensity[a.Tier]
+}
+if a.CPU == 0 {
+a.CPU = tierCPU[a.Tier]
+}
+if a.MemMB == 0 {
+a.MemMB = tierMemMB[a.Tier]
+}
+if a.NetMbps == 0 && a.Kind != "local" {
+a.NetMbps = 10
+}
+if a.Capacity == 0 {
+a.Capacity = kindCapacity[a.Kind]
+}
+}
+
+// Default is the catalog used when no file is configured: local, edge1,
+// edge2 and cloud1, each at low/med/high.
+func Default() *Catalog {
+sites := []struct{ site, kind string }{{"local", "local"}, {"edge1", "edge"}, {"edge2", "edge"}, {"cloud1", "cloud"}}
+var list []Action
+for _, s := range sites {
+for _, t := range []string{"low", "med", "high"} {
+list = append(list, Action{Site: s.site, Kind: s.kind, Tier: t})
+}
+}
+c, err := New(list)
+if err != nil {
+panic(err)
+}
+return c
+}
+
+// New validates the actions and fills unset (zero) costs/resources from kind
+// and tier.
+func New(list []Action) (*Catalog, error) {
+return build(list, nil)
+}
+
+func build(list []Action, set []given) (*Catalog, error) {
+c := &Catalog{byName: make(map[string]Action, len(list))}
+for i, a := range list {
+if !kinds[a.Kind] {
+return nil, fmt.Errorf("action %q: unknown kind %q (want local|edge|cloud)", a.Name, a.Kind)
+}
+if !tiers[a.Tier] {
+return nil, fmt.Errorf("action %q: unknown tier %q (want low|med|high)", a.Name, a.Tier)
+}
+if a.Site == "" {
+return nil, fmt.Errorf("action %q: site is required", a.Name)
+}
+want := a.Site + ":" + a.Tier
+if a.Name == "" {
+a.Name = want
+} else if a.Name != want {
+return nil, fmt.Errorf("action %q: name must be %q (site:tier)", a.Name, want)
+}
+if a.CostMs < 0 || a.Intensity < 0 || a.CPU < 0 || a.MemMB < 0 || a.NetMbps < 0 || a.Capacity < 0 {
+return nil, fmt.Errorf("action %q: costs and resources must be non-negative", a.Name)
+}
+if _, dup := c.byName[a.Name]; dup {
+return nil, fmt.Errorf("action %q: duplicate", a.Name)
+}
+var g given
+if i < len(set) {
+g = set[i]
+}
+fillDefaults(&a, g)
+c.byName[a.Name] = a
+c.names = append(c.names, a.Name)
+}
+if len(c.names) == 0 {
+return nil, fmt.Errorf("catalog is empty")
+}
+sort.Strings(c.names)
+return c, nil
+}
+
+type file struct {
+Actions []Action `json:"actions" yaml:"actions"`
+}
+
+// Load reads a catalog from a YAML (.yaml/.yml) or JSON file. cost_ms and
+// intensity keep an explicit 0 from the file; only absent ones get defaults.
+func Load(path string) (*Catalog, error) {
+raw, err := os.ReadFile(path)
+if err != nil {
+return nil, err
+}
+var f file
+var keys struct {
+Actions []map[string]interface{} `json:"actions" yaml:"actions"`
+}
+switch strings.ToLower(filepath.Ext(path)) {
+case ".yaml", ".yml":
+err = yaml.UnmarshalStrict(raw, &f)
+if err == nil {
+err = yaml.Unmarshal(raw, &keys)
+}
+default:
+dec := json.NewDecoder(strings.NewReader(string(raw)))
+dec.DisallowUnknownFields()
+err = dec.Decode(&f)
+if err == nil {
+err = json.Unmarshal(raw, &keys)
+}
+}
+if err != nil {
+return nil, fmt.Errorf("%s: %v", path, err)
+}
+set := make([]given, len(keys.Actions))
+for i, m := range keys.Actions {
+_, set[i].costMs = m["cost_ms"]
+_, set[i].intensity = m["intensity"]
+}
+c, err := build(f.Actions, set)
+if err != nil {
+return nil, fmt.Errorf("%s: %v", path, err)
+}
+return c, nil
+}
+
+// FromEnv loads CSN_CATALOG when set and falls back to Default.
+func FromEnv() (*Catalog, error) {
+if p := strings.TrimSpace(os.Getenv("CSN_CATALOG")); p != "" {
+return Load(p)
+}
+return Default(), nil
+}
+
+// Lookup returns the action with this exact name.
+func (c *Catalog) Lookup(name string) (Action, bool) {
+a, ok := c.byName[name]
+return a, ok
+}
+
+// Get returns the action or the zero Action for unknown names; callers that
+// accept external input should Check first.
+func (c *Catalog) Get(name string) Action {
+return c.byName[name]
+}
+
+// Check returns an error naming every action that is not in the catalog.
+func (c *Catalog) Check(names []string) error {
+var unknown []string
+for _, n := range names {
+if _, ok := c.byName[n]; !ok {
+unknown = append(unknown, n)
+}
+}
+if len(unknown) > 0 {
+return fmt.Errorf("unknown actions: %s", strings.Join(unknown, ", "))
+}
+return nil
+}
+
+// Names lists all action names in sorted order.
+func (c *Catalog) Names() []string {
+return append([]string(nil), c.names...)
+}
+
+// Actions lists all actions in name order.
+func (c *Catalog) Actions() []Action {
+out := make([]Action, len(c.names))
+for i, n := range c.names {
+out[i] = c.byName[n]
+}
+return out
+}
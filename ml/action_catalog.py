@@ -0,0 +1,26 @@
+"""Action catalog shared with the Go services (catalog/catalog.go).
+
+Reads CSN_CATALOG or ops/actions.json; names that are not in the catalog are
+rejected rather than guessed from their prefix.
+"""
+import json, os
+from pathlib import Path
+
+def load(path: str | None = None) -> dict:
+    p = Path(path or os.environ.get("CSN_CATALOG") or "ops/actions.json")
+    if not p.exists():
+        return {}
+    if p.suffix in (".yaml", ".yml"):
+        import yaml
+        data = yaml.safe_load(p.read_text())
+    else:
+        data = json.loads(p.read_text())
+    return {a.get("name") or f'{a["site"]}:{a["tier"]}': a for a in data.get("actions", [])}
+
+ACTIONS = load()
+
+def kind_tier(name: str) -> tuple[str, str]:
+    a = ACTIONS.get(name)
+    if a is None:
+        raise KeyError(f"unknown action {name!r}")
+    return a["kind"], a["tier"]
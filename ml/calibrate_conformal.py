@@ -1,19 +1,12 @@
 import json, os, numpy as np
 from pathlib import Path
 import onnxruntime as ort
+from action_catalog import kind_tier
 
 rng = np.random.default_rng(7)
 
 def parse_action(a):
-    if not a: return "edge","med"
-    parts = a.split(":")
-    kind = parts[0]
-    tier = parts[1] if len(parts) > 1 else "med"
-    if kind.startswith("edge"):  kind = "edge"
-    if kind.startswith("cloud"): kind = "cloud"
-    if kind not in ("local","edge","cloud"): kind = "edge"
-    if tier not in ("low","med","high"): tier = "med"
-    return kind, tier
+    return kind_tier(a)  # raises KeyError for actions missing from the catalog
 
 def apply_action_adjustments(base_lat, base_en, features, action):
     # must match ml/serve_predictor.py (keep in sync!)
@@ -1,17 +1,37 @@
-from fastapi import FastAPI
+from fastapi import FastAPI, HTTPException
 from pydantic import BaseModel
-import numpy as np, json
+import numpy as np, json, os
 import onnxruntime as ort
 from pathlib import Path
+from ml.action_catalog import kind_tier
 
 app = FastAPI(title="CSN ONNX Predictor (conformal)")
 
-lat_sess = ort.InferenceSession("models/latency.onnx", providers=["CPUExecutionProvider"])
-en_sess  = ort.InferenceSession("models/energy.onnx",  providers=["CPUExecutionProvider"])
+# models/current is the version promoted by the registry (services/registry);
+# the flat files in models/ are the fallback for trees without versions
+MODELS_DIR = Path(os.environ.get("CSN_MODELS_DIR", "models"))
+MODEL_DIR = MODELS_DIR / "current" if (MODELS_DIR / "current" / "latency.onnx").exists() else MODELS_DIR
+print("[model] loading from", MODEL_DIR.resolve())
+
+lat_sess = ort.InferenceSession(str(MODEL_DIR / "latency.onnx"), providers=["CPUExecutionProvider"])
+en_sess  = ort.InferenceSession(str(MODEL_DIR / "energy.onnx"),  providers=["CPUExecutionProvider"])
+
+# version reported with every prediction (prediction caches key on it)
+def model_version() -> str:
+    if os.environ.get("CSN_MODEL_VERSION"):
+        return os.environ["CSN_MODEL_VERSION"]
+    cur = MODELS_DIR / "current"
+    manifest = cur / "manifest.json"
+    if manifest.exists():
+        return json.loads(manifest.read_text()).get("version", cur.resolve().name)
+    return cur.resolve().name if cur.exists() else "unversioned"
+
+MODEL_VERSION = model_version()
+print("[model] version:", MODEL_VERSION)
 
 # load conformal q-hat
 _qhat = {"edge:low":8.0,"edge:med":8.0,"edge:high":10.0,"local:med":8.0,"cloud:low":12.0}
-conf = Path("models/conformal.json")
+conf = MODEL_DIR / "conformal.json"
 if conf.exists():
     data = json.loads(conf.read_text())
     if "qhat" in data:
@@ -21,6 +41,8 @@ if conf.exists():
 class PredictIn(BaseModel):
     features: list[float]   # [bw, rtt, loss, device_cpu, edge_cpu, input_kb, slo_p95_ms]
     action: str | None = None
+    kind: str | None = None  # from the action catalog (set by the gRPC proxy)
+    tier: str | None = None
 
 class PredictOut(BaseModel):
     mu_latency_ms: float
@@ -28,26 +50,52 @@ class PredictOut(BaseModel):
     mu_energy_j: float
     var_energy: float
     p95_conformal_ms: float
+    model_version: str = MODEL_VERSION
+
+KINDS = ("local", "edge", "cloud")
+TIERS = ("low", "med", "high")
 
-def parse_action(a: str | None):
-    if not a: return "edge","med"
-    parts = a.split(":")
-    kind = parts[0]
-    tier = parts[1] if len(parts) > 1 else "med"
-    if kind.startswith("edge"): kind = "edge"
-    if kind.startswith("cloud"): kind = "cloud"
-    if kind not in ("local","edge","cloud"): kind = "edge"
-    if tier not in ("low","med","high"): tier = "med"
+def parse_action(inp: PredictIn):
+    # kind/tier come from the catalog via the proxy; bare names are looked up
+    # in the same catalog. Anything else is rejected.
+    if inp.kind or inp.tier:
+        kind, tier = inp.kind, inp.tier
+    elif not inp.action:
+        raise HTTPException(status_code=422, detail="action is required (name or kind/tier)")
+    else:
+        try:
+            kind, tier = kind_tier(inp.action)
+        except KeyError:
+            kind, tier = None, None
+    if kind not in KINDS or tier not in TIERS:
+        raise HTTPException(status_code=422, detail=f"unknown action {inp.action!r} (kind={kind!r}, tier={tier!r})")
     return kind, tier
 
+class PredictBatchIn(BaseModel):
+    rows: list[PredictIn]
+
+class PredictBatchOut(BaseModel):
+    predictions: list[PredictOut]   # same order as rows
+
 @app.post("/predict", response_model=PredictOut)
 def predict(inp: PredictIn):
     x = np.asarray(inp.features, dtype=np.float32).reshape(1, 7)
     lat = float(lat_sess.run(None, {"input": x})[0].ravel()[0])
     en  = float(en_sess.run(None,  {"input": x})[0].ravel()[0])
+    return adjust(lat, en, inp)
+
+@app.post("/predict_batch", response_model=PredictBatchOut)
+def predict_batch(inp: PredictBatchIn):
+    if not inp.rows:
+        return PredictBatchOut(predictions=[])
+    x = np.asarray([r.features for r in inp.rows], dtype=np.float32).reshape(-1, 7)
+    lats = lat_sess.run(None, {"input": x})[0].ravel()
+    ens  = en_sess.run(None,  {"input": x})[0].ravel()
+    return PredictBatchOut(predictions=[adjust(float(l), float(e), r) for l, e, r in zip(lats, ens, inp.rows)])
 
+def adjust(lat: float, en: float, inp: PredictIn) -> PredictOut:
     bw, rtt, loss, device_cpu, edge_cpu, size, slo = map(float, inp.features)
-    kind, tier = parse_action(inp.action)
+    kind, tier = parse_action(inp)
 
     # Tier/Kind effects (same as before)
     tier_lat_mult = {"low": 1.25, "med": 1.00, "high": 0.97}[tier]
@@ -0,0 +1,273 @@
+// Package modelreg manages the model versions under models/: each version is
+// a directory (e.g. models/v1) holding latency.onnx, energy.onnx,
+// conformal.json and optionally manifest.json, and models/current is a
+// relative symlink to the active one. Promotion and rollback replace the
+// symlink atomically and are recorded in models/.history.jsonl.
+package modelreg
+
+import (
+"bufio"
+"encoding/json"
+"errors"
+"fmt"
+"os"
+"path/filepath"
+"sort"
+"strings"
+"sync"
+"time"
+)
+
+// Required lists the files a version must contain to be promoted.
+var Required = []string{"latency.onnx", "energy.onnx", "conformal.json"}
+
+const (
+currentLink = "current"
+historyFile = ".history.jsonl"
+)
+
+// ErrNoPrevious is returned by Rollback when there is nothing to go back to.
+var ErrNoPrevious = errors.New("no previous version to roll back to")
+
+// ErrInvalidName wraps the error for an empty or non-plain version name.
+var ErrInvalidName = errors.New("invalid version name")
+
+// Version describes one model directory.
+type Version struct {
+Name     string          `json:"name"`
+Path     string          `json:"path"`
+Current  bool            `json:"current"`
+Valid    bool            `json:"valid"`
+Problems []string        `json:"problems,omitempty"`
+Manifest json.RawMessage `json:"manifest,omitempty"`
+Modified time.Time       `json:"modified"`
+}
+
+// Change is one promotion or rollback.
+type Change struct {
+TS     time.Time `json:"ts"`
+Op     string    `json:"op"` // promote | rollback
+From   string    `json:"from,omitempty"`
+To     string    `json:"to"`
+Reason string    `json:"reason,omitempty"`
+}
+
+// Registry operates on the models directory root. Changes are serialised
+// within one process; the symlink swap keeps concurrent readers consistent.
+type Registry struct {
+Root string
+mu   sync.Mutex
+}
+
+func New(root string) *Registry {
+return &Registry{Root: root}
+}
+
+// validName rejects anything that is not a plain directory name.
+func validName(name string) error {
+if name == "" || name == currentLink || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+return fmt.Errorf("%w %q", ErrInvalidName, name)
+}
+return nil
+}
+
+// Current returns the version models/current points to, "" if it does not
+// exist. Absolute targets (older rollover scripts) resolve to their base name.
+func (r *Registry) Current() (string, error) {
+target, err := os.Readlink(filepath.Join(r.Root, currentLink))
+if os.IsNotExist(err) {
+return "", nil
+}
+if err != nil {
+return "", err
+}
+return filepath.Base(filepath.Clean(target)), nil
+}
+
+// Validate reports why version name cannot be promoted; nil means it can.
+func (r *Registry) Validate(name string) error {
+if err := validName(name); err != nil {
+return err
+}
+problems, err := r.problems(filepath.Join(r.Root, name))
+if err != nil {
+return err
+}
+if len(problems) > 0 {
+return fmt.Errorf("version %s: %s", name, strings.Join(problems, "; "))
+}
+return nil
+}
+
+func (r *Registry) problems(dir string) ([]string, error) {
+st, err := os.Stat(dir)
+if err != nil {
+return nil, err
+}
+if !st.IsDir() {
+return nil, fmt.Errorf("%s is not a directory", dir)
+}
+var out []string
+for _, f := range Required {
+fi, err := os.Stat(filepath.Join(dir, f))
+switch {
+case err != nil:
+out = append(out, "missing "+f)
+case fi.Size() == 0:
+out = append(out, "empty "+f)
+}
+}
+if b, err := os.ReadFile(filepath.Join(dir, "conformal.json")); err == nil && !json.Valid(b) {
+out = append(out, "conformal.json is not valid JSON")
+}
+if b, err := os.ReadFile(filepath.Join(dir, "manifest.json")); err == nil && !json.Valid(b) {
+out = append(out, "manifest.json is not valid JSON")
+}
+return out, nil
+}
+
+// Get describes one version.
+func (r *Registry) Get(name string) (Version, error) {
+if err := validName(name); err != nil {
+return Version{}, err
+}
+cur, err := r.Current()
+if err != nil {
+return Version{}, err
+}
+return r.describe(name, cur)
+}
+
+func (r *Registry) describe(name, cur string) (Version, error) {
+dir := filepath.Join(r.Root, name)
+problems, err := r.problems(dir)
+if err != nil {
+return Version{}, err
+}
+st, _ := os.Stat(dir)
+v := Version{Name: name, Path: dir, Current: name == cur, Valid: len(problems) == 0, Problems: problems, Modified: st.ModTime()}
+if b, err := os.ReadFile(filepath.Join(dir, "manifest.json")); err == nil && json.Valid(b) {
+v.Manifest = json.RawMessage(b)
+}
+return v, nil
+}
+
+// List returns every version directory, oldest first.
+func (r *Registry) List() ([]Version, error) {
+entries, err := os.ReadDir(r.Root)
+if err != nil {
+return nil, err
+}
+cur, err := r.Current()
+if err != nil {
+return nil, err
+}
+var out []Version
+for _, e := range entries {
+if !e.IsDir() || validName(e.Name()) != nil {
+continue
+}
+v, err := r.describe(e.Name(), cur)
+if err != nil {
+return nil, err
+}
+out = append(out, v)
+}
+sort.Slice(out, func(i, j int) bool {
+if !out[i].Modified.Equal(out[j].Modified) {
+return out[i].Modified.Before(out[j].Modified)
+}
+return out[i].Name < out[j].Name
+})
+return out, nil
+}
+
+// Promote validates name and points models/current at it.
+func (r *Registry) Promote(name, reason string) (Change, error) {
+r.mu.Lock()
+defer r.mu.Unlock()
+return r.switchLocked("promote", name, reason)
+}
+
+// Rollback returns to the version that was current before the current one was
+// promoted; repeated rollbacks walk further back.
+func (r *Registry) Rollback(reason string) (Change, error) {
+r.mu.Lock()
+defer r.mu.Unlock()
+cur, err := r.Current()
+if err != nil {
+return Change{}, err
+}
+hist, err := r.History()
+if err != nil {
+return Change{}, err
+}
+for i := len(hist) - 1; i >= 0; i-- {
+if hist[i].Op == "promote" && hist[i].To == cur && hist[i].From != "" && hist[i].From != cur {
+return r.switchLocked("rollback", hist[i].From, reason)
+}
+}
+return Change{}, ErrNoPrevious
+}
+
+func (r *Registry) switchLocked(op, name, reason string) (Change, error) {
+if err := r.Validate(name); err != nil {
+return Change{}, err
+}
+cur, err := r.Current()
+if err != nil {
+return Change{}, err
+}
+// a relative target keeps the tree relocatable; rename over the old link
+// is atomic, so readers see either the old or the new version
+link := filepath.Join(r.Root, currentLink)
+tmp := fmt.Sprintf("%s.tmp-%d", link, os.Getpid())
+_ = os.Remove(tmp)
+if err := os.Symlink(name, tmp); err != nil {
+return Change{}, err
+}
+if err := os.Rename(tmp, link); err != nil {
+_ = os.Remove(tmp)
+return Change{}, err
+}
+c := Change{TS: time.Now().UTC(), Op: op, From: cur, To: name, Reason: reason}
+if err := r.appendHistory(c); err != nil {
+return c, fmt.Errorf("%s done, history not recorded: %w", op, err)
+}
+return c, nil
+}
+
+func (r *Registry) appendHistory(c Change) error {
+b, err := json.Marshal(c)
+if err != nil {
+return err
+}
+f, err := os.OpenFile(filepath.Join(r.Root, historyFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+if err != nil {
+return err
+}
+defer f.Close()
+_, err = f.Write(append(b, '\n'))
+return err
+}
+
+// History returns recorded promotions and rollbacks, oldest first.
+func (r *Registry) History() ([]Change, error) {
+f, err := os.Open(filepath.Join(r.Root, historyFile))
+if os.IsNotExist(err) {
+return nil, nil
+}
+if err != nil {
+return nil, err
+}
+defer f.Close()
+var out []Change
+sc := bufio.NewScanner(f)
+for sc.Scan() {
+var c Change
+if json.Unmarshal(sc.Bytes(), &c) == nil {
+out = append(out, c)
+}
+}
+return out, sc.Err()
+}
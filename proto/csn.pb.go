@@ -204,6 +204,7 @@ type PredictReply struct {
 	MuEnergyJ      float64 `protobuf:"fixed64,3,opt,name=mu_energy_j,json=muEnergyJ,proto3" json:"mu_energy_j,omitempty"`
 	VarEnergy      float64 `protobuf:"fixed64,4,opt,name=var_energy,json=varEnergy,proto3" json:"var_energy,omitempty"`
 	P95ConformalMs float64 `protobuf:"fixed64,5,opt,name=p95_conformal_ms,json=p95ConformalMs,proto3" json:"p95_conformal_ms,omitempty"`
+	ModelVersion   string  `protobuf:"bytes,6,opt,name=model_version,json=modelVersion,proto3" json:"model_version,omitempty"` // version of the model that produced it
 }
 
 func (x *PredictReply) Reset() {
@@ -273,32 +274,870 @@ func (x *PredictReply) GetP95ConformalMs() float64 {
 	return 0
 }
 
+func (x *PredictReply) GetModelVersion() string {
+	if x != nil {
+		return x.ModelVersion
+	}
+	return ""
+}
+
+// Scores every action for one context in a single call.
+type PredictBatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ctx     *Context `protobuf:"bytes,1,opt,name=ctx,proto3" json:"ctx,omitempty"`
+	Actions []string `protobuf:"bytes,2,rep,name=actions,proto3" json:"actions,omitempty"`
+}
+
+func (x *PredictBatchRequest) Reset() {
+	*x = PredictBatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PredictBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictBatchRequest) ProtoMessage() {}
+
+func (x *PredictBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictBatchRequest.ProtoReflect.Descriptor instead.
+func (*PredictBatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PredictBatchRequest) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
+}
+
+func (x *PredictBatchRequest) GetActions() []string {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+type PredictBatchReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Predictions []*PredictReply `protobuf:"bytes,1,rep,name=predictions,proto3" json:"predictions,omitempty"`
+}
+
+func (x *PredictBatchReply) Reset() {
+	*x = PredictBatchReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PredictBatchReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictBatchReply) ProtoMessage() {}
+
+func (x *PredictBatchReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictBatchReply.ProtoReflect.Descriptor instead.
+func (*PredictBatchReply) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PredictBatchReply) GetPredictions() []*PredictReply {
+	if x != nil {
+		return x.Predictions
+	}
+	return nil
+}
+
 type DecideRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Ctx             *Context `protobuf:"bytes,1,opt,name=ctx,proto3" json:"ctx,omitempty"`
-	FeasibleActions []string `protobuf:"bytes,2,rep,name=feasible_actions,json=feasibleActions,proto3" json:"feasible_actions,omitempty"`
+	Ctx             *Context `protobuf:"bytes,1,opt,name=ctx,proto3" json:"ctx,omitempty"`
+	FeasibleActions []string `protobuf:"bytes,2,rep,name=feasible_actions,json=feasibleActions,proto3" json:"feasible_actions,omitempty"`
+	Explain         bool     `protobuf:"varint,3,opt,name=explain,proto3" json:"explain,omitempty"` // fill DecideReply.breakdown
+}
+
+func (x *DecideRequest) Reset() {
+	*x = DecideRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DecideRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecideRequest) ProtoMessage() {}
+
+func (x *DecideRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecideRequest.ProtoReflect.Descriptor instead.
+func (*DecideRequest) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DecideRequest) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
+}
+
+func (x *DecideRequest) GetFeasibleActions() []string {
+	if x != nil {
+		return x.FeasibleActions
+	}
+	return nil
+}
+
+func (x *DecideRequest) GetExplain() bool {
+	if x != nil {
+		return x.Explain
+	}
+	return false
+}
+
+// Per-action terms of the utility U = -(latency + energy + slo + cost) + jitter.
+type ActionBreakdown struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Action          string  `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	LatencySampleMs float64 `protobuf:"fixed64,2,opt,name=latency_sample_ms,json=latencySampleMs,proto3" json:"latency_sample_ms,omitempty"`
+	EnergyTerm      float64 `protobuf:"fixed64,3,opt,name=energy_term,json=energyTerm,proto3" json:"energy_term,omitempty"` // lambda_energy * mu_energy_j
+	SloPenalty      float64 `protobuf:"fixed64,4,opt,name=slo_penalty,json=sloPenalty,proto3" json:"slo_penalty,omitempty"` // alpha_eff * max(0, p95 - slo)
+	AlphaEff        float64 `protobuf:"fixed64,5,opt,name=alpha_eff,json=alphaEff,proto3" json:"alpha_eff,omitempty"`
+	CostMs          float64 `protobuf:"fixed64,6,opt,name=cost_ms,json=costMs,proto3" json:"cost_ms,omitempty"`
+	FairnessPenalty float64 `protobuf:"fixed64,7,opt,name=fairness_penalty,json=fairnessPenalty,proto3" json:"fairness_penalty,omitempty"`
+	Jitter          float64 `protobuf:"fixed64,8,opt,name=jitter,proto3" json:"jitter,omitempty"`
+	Utility         float64 `protobuf:"fixed64,9,opt,name=utility,proto3" json:"utility,omitempty"`
+}
+
+func (x *ActionBreakdown) Reset() {
+	*x = ActionBreakdown{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ActionBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActionBreakdown) ProtoMessage() {}
+
+func (x *ActionBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActionBreakdown.ProtoReflect.Descriptor instead.
+func (*ActionBreakdown) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ActionBreakdown) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ActionBreakdown) GetLatencySampleMs() float64 {
+	if x != nil {
+		return x.LatencySampleMs
+	}
+	return 0
+}
+
+func (x *ActionBreakdown) GetEnergyTerm() float64 {
+	if x != nil {
+		return x.EnergyTerm
+	}
+	return 0
+}
+
+func (x *ActionBreakdown) GetSloPenalty() float64 {
+	if x != nil {
+		return x.SloPenalty
+	}
+	return 0
+}
+
+func (x *ActionBreakdown) GetAlphaEff() float64 {
+	if x != nil {
+		return x.AlphaEff
+	}
+	return 0
+}
+
+func (x *ActionBreakdown) GetCostMs() float64 {
+	if x != nil {
+		return x.CostMs
+	}
+	return 0
+}
+
+func (x *ActionBreakdown) GetFairnessPenalty() float64 {
+	if x != nil {
+		return x.FairnessPenalty
+	}
+	return 0
+}
+
+func (x *ActionBreakdown) GetJitter() float64 {
+	if x != nil {
+		return x.Jitter
+	}
+	return 0
+}
+
+func (x *ActionBreakdown) GetUtility() float64 {
+	if x != nil {
+		return x.Utility
+	}
+	return 0
+}
+
+type DecideReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChosenAction string             `protobuf:"bytes,1,opt,name=chosen_action,json=chosenAction,proto3" json:"chosen_action,omitempty"`
+	Explore      bool               `protobuf:"varint,2,opt,name=explore,proto3" json:"explore,omitempty"`                                 // chosen_action is not the policy's most likely choice
+	DecisionId   string             `protobuf:"bytes,3,opt,name=decision_id,json=decisionId,proto3" json:"decision_id,omitempty"`          // pass back in OutcomeRequest
+	Breakdown    []*ActionBreakdown `protobuf:"bytes,4,rep,name=breakdown,proto3" json:"breakdown,omitempty"`                              // only when DecideRequest.explain
+	Reason       string             `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`                                    // argmax | epsilon | fairness | quota | degraded
+	Denied       bool               `protobuf:"varint,6,opt,name=denied,proto3" json:"denied,omitempty"`                                   // tenant over quota; chosen_action is only the cheapest fallback
+	RetryAfterMs int64              `protobuf:"varint,7,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"` // when denied: time until the tenant can afford a decision
+	Degraded     bool               `protobuf:"varint,8,opt,name=degraded,proto3" json:"degraded,omitempty"`                               // no predictions were available; chosen by the rule-based heuristic
+	Arm          string             `protobuf:"bytes,9,opt,name=arm,proto3" json:"arm,omitempty"`                                          // experiment arm that served the decision, empty outside experiments
+	Propensity   float64            `protobuf:"fixed64,10,opt,name=propensity,proto3" json:"propensity,omitempty"`                         // probability the Decider selected chosen_action (1 when degraded, 0 when denied)
+}
+
+func (x *DecideReply) Reset() {
+	*x = DecideReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DecideReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecideReply) ProtoMessage() {}
+
+func (x *DecideReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecideReply.ProtoReflect.Descriptor instead.
+func (*DecideReply) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DecideReply) GetChosenAction() string {
+	if x != nil {
+		return x.ChosenAction
+	}
+	return ""
+}
+
+func (x *DecideReply) GetExplore() bool {
+	if x != nil {
+		return x.Explore
+	}
+	return false
+}
+
+func (x *DecideReply) GetDecisionId() string {
+	if x != nil {
+		return x.DecisionId
+	}
+	return ""
+}
+
+func (x *DecideReply) GetBreakdown() []*ActionBreakdown {
+	if x != nil {
+		return x.Breakdown
+	}
+	return nil
+}
+
+func (x *DecideReply) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *DecideReply) GetDenied() bool {
+	if x != nil {
+		return x.Denied
+	}
+	return false
+}
+
+func (x *DecideReply) GetRetryAfterMs() int64 {
+	if x != nil {
+		return x.RetryAfterMs
+	}
+	return 0
+}
+
+func (x *DecideReply) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+func (x *DecideReply) GetArm() string {
+	if x != nil {
+		return x.Arm
+	}
+	return ""
+}
+
+func (x *DecideReply) GetPropensity() float64 {
+	if x != nil {
+		return x.Propensity
+	}
+	return 0
+}
+
+// Observed result of executing a decision; drives the SLO/fairness loop.
+type OutcomeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DecisionId        string  `protobuf:"bytes,1,opt,name=decision_id,json=decisionId,proto3" json:"decision_id,omitempty"`
+	ObservedLatencyMs float64 `protobuf:"fixed64,2,opt,name=observed_latency_ms,json=observedLatencyMs,proto3" json:"observed_latency_ms,omitempty"`
+	ObservedEnergyJ   float64 `protobuf:"fixed64,3,opt,name=observed_energy_j,json=observedEnergyJ,proto3" json:"observed_energy_j,omitempty"`
+}
+
+func (x *OutcomeRequest) Reset() {
+	*x = OutcomeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OutcomeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutcomeRequest) ProtoMessage() {}
+
+func (x *OutcomeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutcomeRequest.ProtoReflect.Descriptor instead.
+func (*OutcomeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *OutcomeRequest) GetDecisionId() string {
+	if x != nil {
+		return x.DecisionId
+	}
+	return ""
+}
+
+func (x *OutcomeRequest) GetObservedLatencyMs() float64 {
+	if x != nil {
+		return x.ObservedLatencyMs
+	}
+	return 0
+}
+
+func (x *OutcomeRequest) GetObservedEnergyJ() float64 {
+	if x != nil {
+		return x.ObservedEnergyJ
+	}
+	return 0
+}
+
+type OutcomeReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Accepted bool `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+}
+
+func (x *OutcomeReply) Reset() {
+	*x = OutcomeReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OutcomeReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutcomeReply) ProtoMessage() {}
+
+func (x *OutcomeReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutcomeReply.ProtoReflect.Descriptor instead.
+func (*OutcomeReply) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *OutcomeReply) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+// One entry of the action catalog (catalog/catalog.go).
+type ActionSpec struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name      string  `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // "<site>:<tier>", e.g. edge1:med
+	Site      string  `protobuf:"bytes,2,opt,name=site,proto3" json:"site,omitempty"`
+	Kind      string  `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"` // local | edge | cloud
+	Tier      string  `protobuf:"bytes,4,opt,name=tier,proto3" json:"tier,omitempty"` // low | med | high
+	CostMs    float64 `protobuf:"fixed64,5,opt,name=cost_ms,json=costMs,proto3" json:"cost_ms,omitempty"`
+	Intensity float64 `protobuf:"fixed64,6,opt,name=intensity,proto3" json:"intensity,omitempty"` // scalar resource use (fairness, quotas)
+	Cpu       float64 `protobuf:"fixed64,7,opt,name=cpu,proto3" json:"cpu,omitempty"`             // cores
+	MemMb     float64 `protobuf:"fixed64,8,opt,name=mem_mb,json=memMb,proto3" json:"mem_mb,omitempty"`
+	NetMbps   float64 `protobuf:"fixed64,9,opt,name=net_mbps,json=netMbps,proto3" json:"net_mbps,omitempty"`
+	Capacity  int32   `protobuf:"varint,10,opt,name=capacity,proto3" json:"capacity,omitempty"`
+}
+
+func (x *ActionSpec) Reset() {
+	*x = ActionSpec{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ActionSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActionSpec) ProtoMessage() {}
+
+func (x *ActionSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActionSpec.ProtoReflect.Descriptor instead.
+func (*ActionSpec) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ActionSpec) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ActionSpec) GetSite() string {
+	if x != nil {
+		return x.Site
+	}
+	return ""
+}
+
+func (x *ActionSpec) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ActionSpec) GetTier() string {
+	if x != nil {
+		return x.Tier
+	}
+	return ""
+}
+
+func (x *ActionSpec) GetCostMs() float64 {
+	if x != nil {
+		return x.CostMs
+	}
+	return 0
+}
+
+func (x *ActionSpec) GetIntensity() float64 {
+	if x != nil {
+		return x.Intensity
+	}
+	return 0
+}
+
+func (x *ActionSpec) GetCpu() float64 {
+	if x != nil {
+		return x.Cpu
+	}
+	return 0
+}
+
+func (x *ActionSpec) GetMemMb() float64 {
+	if x != nil {
+		return x.MemMb
+	}
+	return 0
+}
+
+func (x *ActionSpec) GetNetMbps() float64 {
+	if x != nil {
+		return x.NetMbps
+	}
+	return 0
+}
+
+func (x *ActionSpec) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+type ListActionsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListActionsRequest) Reset() {
+	*x = ListActionsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListActionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListActionsRequest) ProtoMessage() {}
+
+func (x *ListActionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListActionsRequest.ProtoReflect.Descriptor instead.
+func (*ListActionsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{11}
+}
+
+type ListActionsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Actions []*ActionSpec `protobuf:"bytes,1,rep,name=actions,proto3" json:"actions,omitempty"`
+}
+
+func (x *ListActionsReply) Reset() {
+	*x = ListActionsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListActionsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListActionsReply) ProtoMessage() {}
+
+func (x *ListActionsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListActionsReply.ProtoReflect.Descriptor instead.
+func (*ListActionsReply) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListActionsReply) GetActions() []*ActionSpec {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+// One model version directory (modelreg/modelreg.go).
+type ModelVersion struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name         string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`        // directory under models/, e.g. v1
+	Current      bool     `protobuf:"varint,2,opt,name=current,proto3" json:"current,omitempty"` // models/current points here
+	Valid        bool     `protobuf:"varint,3,opt,name=valid,proto3" json:"valid,omitempty"`     // has latency.onnx, energy.onnx and conformal.json
+	Problems     []string `protobuf:"bytes,4,rep,name=problems,proto3" json:"problems,omitempty"`
+	ManifestJson string   `protobuf:"bytes,5,opt,name=manifest_json,json=manifestJson,proto3" json:"manifest_json,omitempty"` // manifest.json verbatim, empty if absent
+}
+
+func (x *ModelVersion) Reset() {
+	*x = ModelVersion{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ModelVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelVersion) ProtoMessage() {}
+
+func (x *ModelVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelVersion.ProtoReflect.Descriptor instead.
+func (*ModelVersion) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ModelVersion) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ModelVersion) GetCurrent() bool {
+	if x != nil {
+		return x.Current
+	}
+	return false
+}
+
+func (x *ModelVersion) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ModelVersion) GetProblems() []string {
+	if x != nil {
+		return x.Problems
+	}
+	return nil
+}
+
+func (x *ModelVersion) GetManifestJson() string {
+	if x != nil {
+		return x.ManifestJson
+	}
+	return ""
+}
+
+type ListModelsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListModelsRequest) Reset() {
+	*x = ListModelsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListModelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModelsRequest) ProtoMessage() {}
+
+func (x *ListModelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModelsRequest.ProtoReflect.Descriptor instead.
+func (*ListModelsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{14}
+}
+
+type ListModelsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Versions []*ModelVersion `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	Current  string          `protobuf:"bytes,2,opt,name=current,proto3" json:"current,omitempty"`
 }
 
-func (x *DecideRequest) Reset() {
-	*x = DecideRequest{}
+func (x *ListModelsReply) Reset() {
+	*x = ListModelsReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_csn_proto_msgTypes[3]
+		mi := &file_proto_csn_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *DecideRequest) String() string {
+func (x *ListModelsReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DecideRequest) ProtoMessage() {}
+func (*ListModelsReply) ProtoMessage() {}
 
-func (x *DecideRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_csn_proto_msgTypes[3]
+func (x *ListModelsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -309,51 +1148,51 @@ func (x *DecideRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DecideRequest.ProtoReflect.Descriptor instead.
-func (*DecideRequest) Descriptor() ([]byte, []int) {
-	return file_proto_csn_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use ListModelsReply.ProtoReflect.Descriptor instead.
+func (*ListModelsReply) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *DecideRequest) GetCtx() *Context {
+func (x *ListModelsReply) GetVersions() []*ModelVersion {
 	if x != nil {
-		return x.Ctx
+		return x.Versions
 	}
 	return nil
 }
 
-func (x *DecideRequest) GetFeasibleActions() []string {
+func (x *ListModelsReply) GetCurrent() string {
 	if x != nil {
-		return x.FeasibleActions
+		return x.Current
 	}
-	return nil
+	return ""
 }
 
-type DecideReply struct {
+type PromoteModelRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ChosenAction string `protobuf:"bytes,1,opt,name=chosen_action,json=chosenAction,proto3" json:"chosen_action,omitempty"`
-	Explore      bool   `protobuf:"varint,2,opt,name=explore,proto3" json:"explore,omitempty"`
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Reason  string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
 }
 
-func (x *DecideReply) Reset() {
-	*x = DecideReply{}
+func (x *PromoteModelRequest) Reset() {
+	*x = PromoteModelRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_csn_proto_msgTypes[4]
+		mi := &file_proto_csn_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *DecideReply) String() string {
+func (x *PromoteModelRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DecideReply) ProtoMessage() {}
+func (*PromoteModelRequest) ProtoMessage() {}
 
-func (x *DecideReply) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_csn_proto_msgTypes[4]
+func (x *PromoteModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -364,23 +1203,125 @@ func (x *DecideReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DecideReply.ProtoReflect.Descriptor instead.
-func (*DecideReply) Descriptor() ([]byte, []int) {
-	return file_proto_csn_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use PromoteModelRequest.ProtoReflect.Descriptor instead.
+func (*PromoteModelRequest) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *DecideReply) GetChosenAction() string {
+func (x *PromoteModelRequest) GetVersion() string {
 	if x != nil {
-		return x.ChosenAction
+		return x.Version
 	}
 	return ""
 }
 
-func (x *DecideReply) GetExplore() bool {
+func (x *PromoteModelRequest) GetReason() string {
 	if x != nil {
-		return x.Explore
+		return x.Reason
 	}
-	return false
+	return ""
+}
+
+type RollbackModelRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reason string `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *RollbackModelRequest) Reset() {
+	*x = RollbackModelRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RollbackModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollbackModelRequest) ProtoMessage() {}
+
+func (x *RollbackModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollbackModelRequest.ProtoReflect.Descriptor instead.
+func (*RollbackModelRequest) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RollbackModelRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ModelChangeReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Previous string `protobuf:"bytes,1,opt,name=previous,proto3" json:"previous,omitempty"`
+	Current  string `protobuf:"bytes,2,opt,name=current,proto3" json:"current,omitempty"`
+}
+
+func (x *ModelChangeReply) Reset() {
+	*x = ModelChangeReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_csn_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ModelChangeReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelChangeReply) ProtoMessage() {}
+
+func (x *ModelChangeReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_csn_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelChangeReply.ProtoReflect.Descriptor instead.
+func (*ModelChangeReply) Descriptor() ([]byte, []int) {
+	return file_proto_csn_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ModelChangeReply) GetPrevious() string {
+	if x != nil {
+		return x.Previous
+	}
+	return ""
+}
+
+func (x *ModelChangeReply) GetCurrent() string {
+	if x != nil {
+		return x.Current
+	}
+	return ""
 }
 
 var File_proto_csn_proto protoreflect.FileDescriptor
@@ -409,7 +1350,7 @@ var file_proto_csn_proto_rawDesc = []byte{
 	0x12, 0x1e, 0x0a, 0x03, 0x63, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
 	0x63, 0x73, 0x6e, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x52, 0x03, 0x63, 0x74, 0x78,
 	0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xbc, 0x01, 0x0a, 0x0c, 0x50, 0x72, 0x65,
+	0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xe1, 0x01, 0x0a, 0x0c, 0x50, 0x72, 0x65,
 	0x64, 0x69, 0x63, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x22, 0x0a, 0x0d, 0x6d, 0x75, 0x5f,
 	0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01,
 	0x52, 0x0b, 0x6d, 0x75, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x73, 0x12, 0x1f, 0x0a,
@@ -421,25 +1362,159 @@ var file_proto_csn_proto_rawDesc = []byte{
 	0x28, 0x01, 0x52, 0x09, 0x76, 0x61, 0x72, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x12, 0x28, 0x0a,
 	0x10, 0x70, 0x39, 0x35, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x5f, 0x6d,
 	0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0e, 0x70, 0x39, 0x35, 0x43, 0x6f, 0x6e, 0x66,
-	0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x4d, 0x73, 0x22, 0x5a, 0x0a, 0x0d, 0x44, 0x65, 0x63, 0x69, 0x64,
+	0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x4d, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+	0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x4f, 0x0a, 0x13,
+	0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x03, 0x63, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0c, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x52, 0x03,
+	0x63, 0x74, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x48, 0x0a,
+	0x11, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x12, 0x33, 0x0a, 0x0b, 0x70, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x50, 0x72,
+	0x65, 0x64, 0x69, 0x63, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x52, 0x0b, 0x70, 0x72, 0x65, 0x64,
+	0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x74, 0x0a, 0x0d, 0x44, 0x65, 0x63, 0x69, 0x64,
 	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x03, 0x63, 0x74, 0x78, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x43, 0x6f, 0x6e, 0x74,
 	0x65, 0x78, 0x74, 0x52, 0x03, 0x63, 0x74, 0x78, 0x12, 0x29, 0x0a, 0x10, 0x66, 0x65, 0x61, 0x73,
 	0x69, 0x62, 0x6c, 0x65, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03,
 	0x28, 0x09, 0x52, 0x0f, 0x66, 0x65, 0x61, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x41, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x22, 0x4c, 0x0a, 0x0b, 0x44, 0x65, 0x63, 0x69, 0x64, 0x65, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x68, 0x6f, 0x73, 0x65, 0x6e, 0x5f, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x68, 0x6f, 0x73, 0x65,
-	0x6e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x6c, 0x6f,
-	0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x78, 0x70, 0x6c, 0x6f, 0x72,
-	0x65, 0x32, 0x3e, 0x0a, 0x09, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x31,
-	0x0a, 0x07, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x12, 0x13, 0x2e, 0x63, 0x73, 0x6e, 0x2e,
-	0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11,
-	0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x32, 0x39, 0x0a, 0x07, 0x44, 0x65, 0x63, 0x69, 0x64, 0x65, 0x72, 0x12, 0x2e, 0x0a, 0x06,
-	0x44, 0x65, 0x63, 0x69, 0x64, 0x65, 0x12, 0x12, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x44, 0x65, 0x63,
-	0x69, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x63, 0x73, 0x6e,
-	0x2e, 0x44, 0x65, 0x63, 0x69, 0x64, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x42, 0x22, 0x5a, 0x20,
+	0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x6c, 0x61, 0x69, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x78, 0x70, 0x6c, 0x61, 0x69, 0x6e, 0x22, 0xaa, 0x02,
+	0x0a, 0x0f, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x72, 0x65, 0x61, 0x6b, 0x64, 0x6f, 0x77,
+	0x6e, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x11, 0x6c, 0x61, 0x74,
+	0x65, 0x6e, 0x63, 0x79, 0x5f, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x6d, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x53, 0x61, 0x6d,
+	0x70, 0x6c, 0x65, 0x4d, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x5f,
+	0x74, 0x65, 0x72, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x65, 0x6e, 0x65, 0x72,
+	0x67, 0x79, 0x54, 0x65, 0x72, 0x6d, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x6c, 0x6f, 0x5f, 0x70, 0x65,
+	0x6e, 0x61, 0x6c, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x73, 0x6c, 0x6f,
+	0x50, 0x65, 0x6e, 0x61, 0x6c, 0x74, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x61, 0x6c, 0x70, 0x68, 0x61,
+	0x5f, 0x65, 0x66, 0x66, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x61, 0x6c, 0x70, 0x68,
+	0x61, 0x45, 0x66, 0x66, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x6d, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x63, 0x6f, 0x73, 0x74, 0x4d, 0x73, 0x12, 0x29, 0x0a,
+	0x10, 0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73, 0x5f, 0x70, 0x65, 0x6e, 0x61, 0x6c, 0x74,
+	0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73,
+	0x73, 0x50, 0x65, 0x6e, 0x61, 0x6c, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x6a, 0x69, 0x74, 0x74,
+	0x65, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x6a, 0x69, 0x74, 0x74, 0x65, 0x72,
+	0x12, 0x18, 0x0a, 0x07, 0x75, 0x74, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x07, 0x75, 0x74, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x22, 0xc5, 0x02, 0x0a, 0x0b, 0x44,
+	0x65, 0x63, 0x69, 0x64, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x68,
+	0x6f, 0x73, 0x65, 0x6e, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x63, 0x68, 0x6f, 0x73, 0x65, 0x6e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x6c, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x07, 0x65, 0x78, 0x70, 0x6c, 0x6f, 0x72, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x63,
+	0x69, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x32, 0x0a, 0x09, 0x62, 0x72,
+	0x65, 0x61, 0x6b, 0x64, 0x6f, 0x77, 0x6e, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x63, 0x73, 0x6e, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x72, 0x65, 0x61, 0x6b, 0x64,
+	0x6f, 0x77, 0x6e, 0x52, 0x09, 0x62, 0x72, 0x65, 0x61, 0x6b, 0x64, 0x6f, 0x77, 0x6e, 0x12, 0x16,
+	0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x6e, 0x69, 0x65, 0x64,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x64, 0x65, 0x6e, 0x69, 0x65, 0x64, 0x12, 0x24,
+	0x0a, 0x0e, 0x72, 0x65, 0x74, 0x72, 0x79, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x6d, 0x73,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x72, 0x65, 0x74, 0x72, 0x79, 0x41, 0x66, 0x74,
+	0x65, 0x72, 0x4d, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x67, 0x72, 0x61, 0x64, 0x65, 0x64,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x65, 0x67, 0x72, 0x61, 0x64, 0x65, 0x64,
+	0x12, 0x10, 0x0a, 0x03, 0x61, 0x72, 0x6d, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61,
+	0x72, 0x6d, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x70, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x79,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x70, 0x65, 0x6e, 0x73, 0x69,
+	0x74, 0x79, 0x22, 0x8d, 0x01, 0x0a, 0x0e, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64, 0x65, 0x63, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x13, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x64, 0x5f, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x11, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x4c, 0x61, 0x74,
+	0x65, 0x6e, 0x63, 0x79, 0x4d, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x64, 0x5f, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x5f, 0x6a, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x0f, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x45, 0x6e, 0x65, 0x72, 0x67,
+	0x79, 0x4a, 0x22, 0x2a, 0x0a, 0x0c, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x22, 0xf3,
+	0x01, 0x0a, 0x0a, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x73, 0x69, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x65,
+	0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x69, 0x65, 0x72, 0x12, 0x17, 0x0a,
+	0x07, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06,
+	0x63, 0x6f, 0x73, 0x74, 0x4d, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x73,
+	0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x6e,
+	0x73, 0x69, 0x74, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x70, 0x75, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x03, 0x63, 0x70, 0x75, 0x12, 0x15, 0x0a, 0x06, 0x6d, 0x65, 0x6d, 0x5f, 0x6d, 0x62,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x6d, 0x65, 0x6d, 0x4d, 0x62, 0x12, 0x19, 0x0a,
+	0x08, 0x6e, 0x65, 0x74, 0x5f, 0x6d, 0x62, 0x70, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x07, 0x6e, 0x65, 0x74, 0x4d, 0x62, 0x70, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61,
+	0x63, 0x69, 0x74, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61,
+	0x63, 0x69, 0x74, 0x79, 0x22, 0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3d, 0x0a, 0x10, 0x4c, 0x69,
+	0x73, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x29,
+	0x0a, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0f, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63,
+	0x52, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x93, 0x01, 0x0a, 0x0c, 0x4d, 0x6f,
+	0x64, 0x65, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x07, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x08, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x61,
+	0x6e, 0x69, 0x66, 0x65, 0x73, 0x74, 0x5f, 0x6a, 0x73, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x6d, 0x61, 0x6e, 0x69, 0x66, 0x65, 0x73, 0x74, 0x4a, 0x73, 0x6f, 0x6e, 0x22,
+	0x13, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0x5a, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x6f, 0x64, 0x65,
+	0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2d, 0x0a, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x63, 0x73, 0x6e, 0x2e,
+	0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74,
+	0x22, 0x47, 0x0a, 0x13, 0x50, 0x72, 0x6f, 0x6d, 0x6f, 0x74, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x6c,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x2e, 0x0a, 0x14, 0x52, 0x6f, 0x6c,
+	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x48, 0x0a, 0x10, 0x4d, 0x6f, 0x64,
+	0x65, 0x6c, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1a, 0x0a,
+	0x08, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x75, 0x72, 0x72,
+	0x65, 0x6e, 0x74, 0x32, 0x80, 0x01, 0x0a, 0x09, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x6f,
+	0x72, 0x12, 0x31, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x12, 0x13, 0x2e, 0x63,
+	0x73, 0x6e, 0x2e, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x11, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x40, 0x0a, 0x0c, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x12, 0x18, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x50, 0x72, 0x65, 0x64, 0x69,
+	0x63, 0x74, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16,
+	0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x50, 0x72, 0x65, 0x64, 0x69, 0x63, 0x74, 0x42, 0x61, 0x74, 0x63,
+	0x68, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x32, 0xb1, 0x01, 0x0a, 0x07, 0x44, 0x65, 0x63, 0x69, 0x64,
+	0x65, 0x72, 0x12, 0x2e, 0x0a, 0x06, 0x44, 0x65, 0x63, 0x69, 0x64, 0x65, 0x12, 0x12, 0x2e, 0x63,
+	0x73, 0x6e, 0x2e, 0x44, 0x65, 0x63, 0x69, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x10, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x44, 0x65, 0x63, 0x69, 0x64, 0x65, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x12, 0x37, 0x0a, 0x0d, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x4f, 0x75, 0x74, 0x63,
+	0x6f, 0x6d, 0x65, 0x12, 0x13, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x4f,
+	0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x3d, 0x0a, 0x0b, 0x4c,
+	0x69, 0x73, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x17, 0x2e, 0x63, 0x73, 0x6e,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x32, 0xcf, 0x01, 0x0a, 0x0d, 0x4d,
+	0x6f, 0x64, 0x65, 0x6c, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x12, 0x3a, 0x0a, 0x0a,
+	0x4c, 0x69, 0x73, 0x74, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x73, 0x12, 0x16, 0x2e, 0x63, 0x73, 0x6e,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x14, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x6f, 0x64,
+	0x65, 0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x3f, 0x0a, 0x0c, 0x50, 0x72, 0x6f, 0x6d,
+	0x6f, 0x74, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x18, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x50,
+	0x72, 0x6f, 0x6d, 0x6f, 0x74, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x15, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x43, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x41, 0x0a, 0x0d, 0x52, 0x6f, 0x6c,
+	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x19, 0x2e, 0x63, 0x73, 0x6e,
+	0x2e, 0x52, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x63, 0x73, 0x6e, 0x2e, 0x4d, 0x6f, 0x64, 0x65,
+	0x6c, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x42, 0x22, 0x5a, 0x20,
 	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x75, 0x6c, 0x61, 0x74,
 	0x2f, 0x63, 0x73, 0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x63, 0x73, 0x6e, 0x70, 0x62,
 	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
@@ -457,26 +1532,57 @@ func file_proto_csn_proto_rawDescGZIP() []byte {
 	return file_proto_csn_proto_rawDescData
 }
 
-var file_proto_csn_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_proto_csn_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_proto_csn_proto_goTypes = []interface{}{
-	(*Context)(nil),        // 0: csn.Context
-	(*PredictRequest)(nil), // 1: csn.PredictRequest
-	(*PredictReply)(nil),   // 2: csn.PredictReply
-	(*DecideRequest)(nil),  // 3: csn.DecideRequest
-	(*DecideReply)(nil),    // 4: csn.DecideReply
+	(*Context)(nil),              // 0: csn.Context
+	(*PredictRequest)(nil),       // 1: csn.PredictRequest
+	(*PredictReply)(nil),         // 2: csn.PredictReply
+	(*PredictBatchRequest)(nil),  // 3: csn.PredictBatchRequest
+	(*PredictBatchReply)(nil),    // 4: csn.PredictBatchReply
+	(*DecideRequest)(nil),        // 5: csn.DecideRequest
+	(*ActionBreakdown)(nil),      // 6: csn.ActionBreakdown
+	(*DecideReply)(nil),          // 7: csn.DecideReply
+	(*OutcomeRequest)(nil),       // 8: csn.OutcomeRequest
+	(*OutcomeReply)(nil),         // 9: csn.OutcomeReply
+	(*ActionSpec)(nil),           // 10: csn.ActionSpec
+	(*ListActionsRequest)(nil),   // 11: csn.ListActionsRequest
+	(*ListActionsReply)(nil),     // 12: csn.ListActionsReply
+	(*ModelVersion)(nil),         // 13: csn.ModelVersion
+	(*ListModelsRequest)(nil),    // 14: csn.ListModelsRequest
+	(*ListModelsReply)(nil),      // 15: csn.ListModelsReply
+	(*PromoteModelRequest)(nil),  // 16: csn.PromoteModelRequest
+	(*RollbackModelRequest)(nil), // 17: csn.RollbackModelRequest
+	(*ModelChangeReply)(nil),     // 18: csn.ModelChangeReply
 }
 var file_proto_csn_proto_depIdxs = []int32{
-	0, // 0: csn.PredictRequest.ctx:type_name -> csn.Context
-	0, // 1: csn.DecideRequest.ctx:type_name -> csn.Context
-	1, // 2: csn.Predictor.Predict:input_type -> csn.PredictRequest
-	3, // 3: csn.Decider.Decide:input_type -> csn.DecideRequest
-	2, // 4: csn.Predictor.Predict:output_type -> csn.PredictReply
-	4, // 5: csn.Decider.Decide:output_type -> csn.DecideReply
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: csn.PredictRequest.ctx:type_name -> csn.Context
+	0,  // 1: csn.PredictBatchRequest.ctx:type_name -> csn.Context
+	2,  // 2: csn.PredictBatchReply.predictions:type_name -> csn.PredictReply
+	0,  // 3: csn.DecideRequest.ctx:type_name -> csn.Context
+	6,  // 4: csn.DecideReply.breakdown:type_name -> csn.ActionBreakdown
+	10, // 5: csn.ListActionsReply.actions:type_name -> csn.ActionSpec
+	13, // 6: csn.ListModelsReply.versions:type_name -> csn.ModelVersion
+	1,  // 7: csn.Predictor.Predict:input_type -> csn.PredictRequest
+	3,  // 8: csn.Predictor.PredictBatch:input_type -> csn.PredictBatchRequest
+	5,  // 9: csn.Decider.Decide:input_type -> csn.DecideRequest
+	8,  // 10: csn.Decider.ReportOutcome:input_type -> csn.OutcomeRequest
+	11, // 11: csn.Decider.ListActions:input_type -> csn.ListActionsRequest
+	14, // 12: csn.ModelRegistry.ListModels:input_type -> csn.ListModelsRequest
+	16, // 13: csn.ModelRegistry.PromoteModel:input_type -> csn.PromoteModelRequest
+	17, // 14: csn.ModelRegistry.RollbackModel:input_type -> csn.RollbackModelRequest
+	2,  // 15: csn.Predictor.Predict:output_type -> csn.PredictReply
+	4,  // 16: csn.Predictor.PredictBatch:output_type -> csn.PredictBatchReply
+	7,  // 17: csn.Decider.Decide:output_type -> csn.DecideReply
+	9,  // 18: csn.Decider.ReportOutcome:output_type -> csn.OutcomeReply
+	12, // 19: csn.Decider.ListActions:output_type -> csn.ListActionsReply
+	15, // 20: csn.ModelRegistry.ListModels:output_type -> csn.ListModelsReply
+	18, // 21: csn.ModelRegistry.PromoteModel:output_type -> csn.ModelChangeReply
+	18, // 22: csn.ModelRegistry.RollbackModel:output_type -> csn.ModelChangeReply
+	15, // [15:23] is the sub-list for method output_type
+	7,  // [7:15] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_proto_csn_proto_init() }
@@ -522,7 +1628,7 @@ func file_proto_csn_proto_init() {
 			}
 		}
 		file_proto_csn_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DecideRequest); i {
+			switch v := v.(*PredictBatchRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -534,6 +1640,42 @@ func file_proto_csn_proto_init() {
 			}
 		}
 		file_proto_csn_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PredictBatchReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DecideRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ActionBreakdown); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*DecideReply); i {
 			case 0:
 				return &v.state
@@ -545,6 +1687,138 @@ func file_proto_csn_proto_init() {
 				return nil
 			}
 		}
+		file_proto_csn_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OutcomeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OutcomeReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ActionSpec); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListActionsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListActionsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ModelVersion); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListModelsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListModelsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PromoteModelRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RollbackModelRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_csn_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ModelChangeReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -552,9 +1826,9 @@ func file_proto_csn_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_csn_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   19,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   3,
 		},
 		GoTypes:           file_proto_csn_proto_goTypes,
 		DependencyIndexes: file_proto_csn_proto_depIdxs,
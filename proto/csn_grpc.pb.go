@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Predictor_Predict_FullMethodName = "/csn.Predictor/Predict"
+	Predictor_Predict_FullMethodName      = "/csn.Predictor/Predict"
+	Predictor_PredictBatch_FullMethodName = "/csn.Predictor/PredictBatch"
 )
 
 // PredictorClient is the client API for Predictor service.
@@ -27,6 +28,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PredictorClient interface {
 	Predict(ctx context.Context, in *PredictRequest, opts ...grpc.CallOption) (*PredictReply, error)
+	PredictBatch(ctx context.Context, in *PredictBatchRequest, opts ...grpc.CallOption) (*PredictBatchReply, error)
 }
 
 type predictorClient struct {
@@ -46,11 +48,21 @@ func (c *predictorClient) Predict(ctx context.Context, in *PredictRequest, opts
 	return out, nil
 }
 
+func (c *predictorClient) PredictBatch(ctx context.Context, in *PredictBatchRequest, opts ...grpc.CallOption) (*PredictBatchReply, error) {
+	out := new(PredictBatchReply)
+	err := c.cc.Invoke(ctx, Predictor_PredictBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PredictorServer is the server API for Predictor service.
 // All implementations must embed UnimplementedPredictorServer
 // for forward compatibility
 type PredictorServer interface {
 	Predict(context.Context, *PredictRequest) (*PredictReply, error)
+	PredictBatch(context.Context, *PredictBatchRequest) (*PredictBatchReply, error)
 	mustEmbedUnimplementedPredictorServer()
 }
 
@@ -61,6 +73,9 @@ type UnimplementedPredictorServer struct {
 func (UnimplementedPredictorServer) Predict(context.Context, *PredictRequest) (*PredictReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Predict not implemented")
 }
+func (UnimplementedPredictorServer) PredictBatch(context.Context, *PredictBatchRequest) (*PredictBatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PredictBatch not implemented")
+}
 func (UnimplementedPredictorServer) mustEmbedUnimplementedPredictorServer() {}
 
 // UnsafePredictorServer may be embedded to opt out of forward compatibility for this service.
@@ -92,6 +107,24 @@ func _Predictor_Predict_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Predictor_PredictBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PredictBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PredictorServer).PredictBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Predictor_PredictBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PredictorServer).PredictBatch(ctx, req.(*PredictBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Predictor_ServiceDesc is the grpc.ServiceDesc for Predictor service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -103,13 +136,19 @@ var Predictor_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Predict",
 			Handler:    _Predictor_Predict_Handler,
 		},
+		{
+			MethodName: "PredictBatch",
+			Handler:    _Predictor_PredictBatch_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/csn.proto",
 }
 
 const (
-	Decider_Decide_FullMethodName = "/csn.Decider/Decide"
+	Decider_Decide_FullMethodName        = "/csn.Decider/Decide"
+	Decider_ReportOutcome_FullMethodName = "/csn.Decider/ReportOutcome"
+	Decider_ListActions_FullMethodName   = "/csn.Decider/ListActions"
 )
 
 // DeciderClient is the client API for Decider service.
@@ -117,6 +156,8 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type DeciderClient interface {
 	Decide(ctx context.Context, in *DecideRequest, opts ...grpc.CallOption) (*DecideReply, error)
+	ReportOutcome(ctx context.Context, in *OutcomeRequest, opts ...grpc.CallOption) (*OutcomeReply, error)
+	ListActions(ctx context.Context, in *ListActionsRequest, opts ...grpc.CallOption) (*ListActionsReply, error)
 }
 
 type deciderClient struct {
@@ -136,11 +177,31 @@ func (c *deciderClient) Decide(ctx context.Context, in *DecideRequest, opts ...g
 	return out, nil
 }
 
+func (c *deciderClient) ReportOutcome(ctx context.Context, in *OutcomeRequest, opts ...grpc.CallOption) (*OutcomeReply, error) {
+	out := new(OutcomeReply)
+	err := c.cc.Invoke(ctx, Decider_ReportOutcome_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deciderClient) ListActions(ctx context.Context, in *ListActionsRequest, opts ...grpc.CallOption) (*ListActionsReply, error) {
+	out := new(ListActionsReply)
+	err := c.cc.Invoke(ctx, Decider_ListActions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DeciderServer is the server API for Decider service.
 // All implementations must embed UnimplementedDeciderServer
 // for forward compatibility
 type DeciderServer interface {
 	Decide(context.Context, *DecideRequest) (*DecideReply, error)
+	ReportOutcome(context.Context, *OutcomeRequest) (*OutcomeReply, error)
+	ListActions(context.Context, *ListActionsRequest) (*ListActionsReply, error)
 	mustEmbedUnimplementedDeciderServer()
 }
 
@@ -151,6 +212,12 @@ type UnimplementedDeciderServer struct {
 func (UnimplementedDeciderServer) Decide(context.Context, *DecideRequest) (*DecideReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Decide not implemented")
 }
+func (UnimplementedDeciderServer) ReportOutcome(context.Context, *OutcomeRequest) (*OutcomeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportOutcome not implemented")
+}
+func (UnimplementedDeciderServer) ListActions(context.Context, *ListActionsRequest) (*ListActionsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListActions not implemented")
+}
 func (UnimplementedDeciderServer) mustEmbedUnimplementedDeciderServer() {}
 
 // UnsafeDeciderServer may be embedded to opt out of forward compatibility for this service.
@@ -182,6 +249,42 @@ func _Decider_Decide_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Decider_ReportOutcome_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OutcomeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeciderServer).ReportOutcome(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Decider_ReportOutcome_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeciderServer).ReportOutcome(ctx, req.(*OutcomeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Decider_ListActions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListActionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeciderServer).ListActions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Decider_ListActions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeciderServer).ListActions(ctx, req.(*ListActionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Decider_ServiceDesc is the grpc.ServiceDesc for Decider service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -193,6 +296,178 @@ var Decider_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Decide",
 			Handler:    _Decider_Decide_Handler,
 		},
+		{
+			MethodName: "ReportOutcome",
+			Handler:    _Decider_ReportOutcome_Handler,
+		},
+		{
+			MethodName: "ListActions",
+			Handler:    _Decider_ListActions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/csn.proto",
+}
+
+const (
+	ModelRegistry_ListModels_FullMethodName    = "/csn.ModelRegistry/ListModels"
+	ModelRegistry_PromoteModel_FullMethodName  = "/csn.ModelRegistry/PromoteModel"
+	ModelRegistry_RollbackModel_FullMethodName = "/csn.ModelRegistry/RollbackModel"
+)
+
+// ModelRegistryClient is the client API for ModelRegistry service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ModelRegistryClient interface {
+	ListModels(ctx context.Context, in *ListModelsRequest, opts ...grpc.CallOption) (*ListModelsReply, error)
+	PromoteModel(ctx context.Context, in *PromoteModelRequest, opts ...grpc.CallOption) (*ModelChangeReply, error)
+	RollbackModel(ctx context.Context, in *RollbackModelRequest, opts ...grpc.CallOption) (*ModelChangeReply, error)
+}
+
+type modelRegistryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewModelRegistryClient(cc grpc.ClientConnInterface) ModelRegistryClient {
+	return &modelRegistryClient{cc}
+}
+
+func (c *modelRegistryClient) ListModels(ctx context.Context, in *ListModelsRequest, opts ...grpc.CallOption) (*ListModelsReply, error) {
+	out := new(ListModelsReply)
+	err := c.cc.Invoke(ctx, ModelRegistry_ListModels_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *modelRegistryClient) PromoteModel(ctx context.Context, in *PromoteModelRequest, opts ...grpc.CallOption) (*ModelChangeReply, error) {
+	out := new(ModelChangeReply)
+	err := c.cc.Invoke(ctx, ModelRegistry_PromoteModel_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *modelRegistryClient) RollbackModel(ctx context.Context, in *RollbackModelRequest, opts ...grpc.CallOption) (*ModelChangeReply, error) {
+	out := new(ModelChangeReply)
+	err := c.cc.Invoke(ctx, ModelRegistry_RollbackModel_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ModelRegistryServer is the server API for ModelRegistry service.
+// All implementations must embed UnimplementedModelRegistryServer
+// for forward compatibility
+type ModelRegistryServer interface {
+	ListModels(context.Context, *ListModelsRequest) (*ListModelsReply, error)
+	PromoteModel(context.Context, *PromoteModelRequest) (*ModelChangeReply, error)
+	RollbackModel(context.Context, *RollbackModelRequest) (*ModelChangeReply, error)
+	mustEmbedUnimplementedModelRegistryServer()
+}
+
+// UnimplementedModelRegistryServer must be embedded to have forward compatible implementations.
+type UnimplementedModelRegistryServer struct {
+}
+
+func (UnimplementedModelRegistryServer) ListModels(context.Context, *ListModelsRequest) (*ListModelsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListModels not implemented")
+}
+func (UnimplementedModelRegistryServer) PromoteModel(context.Context, *PromoteModelRequest) (*ModelChangeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PromoteModel not implemented")
+}
+func (UnimplementedModelRegistryServer) RollbackModel(context.Context, *RollbackModelRequest) (*ModelChangeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackModel not implemented")
+}
+func (UnimplementedModelRegistryServer) mustEmbedUnimplementedModelRegistryServer() {}
+
+// UnsafeModelRegistryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ModelRegistryServer will
+// result in compilation errors.
+type UnsafeModelRegistryServer interface {
+	mustEmbedUnimplementedModelRegistryServer()
+}
+
+func RegisterModelRegistryServer(s grpc.ServiceRegistrar, srv ModelRegistryServer) {
+	s.RegisterService(&ModelRegistry_ServiceDesc, srv)
+}
+
+func _ModelRegistry_ListModels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListModelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelRegistryServer).ListModels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelRegistry_ListModels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelRegistryServer).ListModels(ctx, req.(*ListModelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ModelRegistry_PromoteModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PromoteModelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelRegistryServer).PromoteModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelRegistry_PromoteModel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelRegistryServer).PromoteModel(ctx, req.(*PromoteModelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ModelRegistry_RollbackModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackModelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelRegistryServer).RollbackModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelRegistry_RollbackModel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelRegistryServer).RollbackModel(ctx, req.(*RollbackModelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ModelRegistry_ServiceDesc is the grpc.ServiceDesc for ModelRegistry service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ModelRegistry_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "csn.ModelRegistry",
+	HandlerType: (*ModelRegistryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListModels",
+			Handler:    _ModelRegistry_ListModels_Handler,
+		},
+		{
+			MethodName: "PromoteModel",
+			Handler:    _ModelRegistry_PromoteModel_Handler,
+		},
+		{
+			MethodName: "RollbackModel",
+			Handler:    _ModelRegistry_RollbackModel_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/csn.proto",
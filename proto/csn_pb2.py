@@ -24,7 +24,7 @@ _sym_db = _symbol_database.Default()
 
 
 
-DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0fproto/csn.proto\x12\x03\x63sn\"\xbc\x01\n\x07\x43ontext\x12\x11\n\ttenant_id\x18\x01 \x01(\t\x12\x0e\n\x06\x61pp_id\x18\x02 \x01(\t\x12\x0f\n\x07\x62w_mbps\x18\x03 \x01(\x01\x12\x0e\n\x06rtt_ms\x18\x04 \x01(\x01\x12\x0c\n\x04loss\x18\x05 \x01(\x01\x12\x12\n\ndevice_cpu\x18\x06 \x01(\x01\x12\x13\n\x0b\x62\x61ttery_soc\x18\x07 \x01(\x01\x12\x10\n\x08\x65\x64ge_cpu\x18\x08 \x01(\x01\x12\x10\n\x08input_kb\x18\t \x01(\x01\x12\x12\n\nslo_p95_ms\x18\n \x01(\x01\";\n\x0ePredictRequest\x12\x19\n\x03\x63tx\x18\x01 \x01(\x0b\x32\x0c.csn.Context\x12\x0e\n\x06\x61\x63tion\x18\x02 \x01(\t\"}\n\x0cPredictReply\x12\x15\n\rmu_latency_ms\x18\x01 \x01(\x01\x12\x13\n\x0bvar_latency\x18\x02 \x01(\x01\x12\x13\n\x0bmu_energy_j\x18\x03 \x01(\x01\x12\x12\n\nvar_energy\x18\x04 \x01(\x01\x12\x18\n\x10p95_conformal_ms\x18\x05 \x01(\x01\"D\n\rDecideRequest\x12\x19\n\x03\x63tx\x18\x01 \x01(\x0b\x32\x0c.csn.Context\x12\x18\n\x10\x66\x65\x61sible_actions\x18\x02 \x03(\t\"5\n\x0b\x44\x65\x63ideReply\x12\x15\n\rchosen_action\x18\x01 \x01(\t\x12\x0f\n\x07\x65xplore\x18\x02 \x01(\x08\x32>\n\tPredictor\x12\x31\n\x07Predict\x12\x13.csn.PredictRequest\x1a\x11.csn.PredictReply29\n\x07\x44\x65\x63ider\x12.\n\x06\x44\x65\x63ide\x12\x12.csn.DecideRequest\x1a\x10.csn.DecideReplyB\"Z github.com/mulat/csn/proto;csnpbb\x06proto3')
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0fproto/csn.proto\x12\x03\x63sn\"\xbc\x01\n\x07\x43ontext\x12\x11\n\ttenant_id\x18\x01 \x01(\t\x12\x0e\n\x06\x61pp_id\x18\x02 \x01(\t\x12\x0f\n\x07\x62w_mbps\x18\x03 \x01(\x01\x12\x0e\n\x06rtt_ms\x18\x04 \x01(\x01\x12\x0c\n\x04loss\x18\x05 \x01(\x01\x12\x12\n\ndevice_cpu\x18\x06 \x01(\x01\x12\x13\n\x0b\x62\x61ttery_soc\x18\x07 \x01(\x01\x12\x10\n\x08\x65\x64ge_cpu\x18\x08 \x01(\x01\x12\x10\n\x08input_kb\x18\t \x01(\x01\x12\x12\n\nslo_p95_ms\x18\n \x01(\x01\";\n\x0ePredictRequest\x12\x19\n\x03\x63tx\x18\x01 \x01(\x0b\x32\x0c.csn.Context\x12\x0e\n\x06\x61\x63tion\x18\x02 \x01(\t\"\x94\x01\n\x0cPredictReply\x12\x15\n\rmu_latency_ms\x18\x01 \x01(\x01\x12\x13\n\x0bvar_latency\x18\x02 \x01(\x01\x12\x13\n\x0bmu_energy_j\x18\x03 \x01(\x01\x12\x12\n\nvar_energy\x18\x04 \x01(\x01\x12\x18\n\x10p95_conformal_ms\x18\x05 \x01(\x01\x12\x15\n\rmodel_version\x18\x06 \x01(\t\"A\n\x13PredictBatchRequest\x12\x19\n\x03\x63tx\x18\x01 \x01(\x0b\x32\x0c.csn.Context\x12\x0f\n\x07\x61\x63tions\x18\x02 \x03(\t\";\n\x11PredictBatchReply\x12&\n\x0bpredictions\x18\x01 \x03(\x0b\x32\x11.csn.PredictReply\"U\n\rDecideRequest\x12\x19\n\x03\x63tx\x18\x01 \x01(\x0b\x32\x0c.csn.Context\x12\x18\n\x10\x66\x65\x61sible_actions\x18\x02 \x03(\t\x12\x0f\n\x07\x65xplain\x18\x03 \x01(\x08\"\xc5\x01\n\x0f\x41\x63tionBreakdown\x12\x0e\n\x06\x61\x63tion\x18\x01 \x01(\t\x12\x19\n\x11latency_sample_ms\x18\x02 \x01(\x01\x12\x13\n\x0b\x65nergy_term\x18\x03 \x01(\x01\x12\x13\n\x0bslo_penalty\x18\x04 \x01(\x01\x12\x11\n\talpha_eff\x18\x05 \x01(\x01\x12\x0f\n\x07\x63ost_ms\x18\x06 \x01(\x01\x12\x18\n\x10\x66\x61irness_penalty\x18\x07 \x01(\x01\x12\x0e\n\x06jitter\x18\x08 \x01(\x01\x12\x0f\n\x07utility\x18\t \x01(\x01\"\xde\x01\n\x0b\x44\x65\x63ideReply\x12\x15\n\rchosen_action\x18\x01 \x01(\t\x12\x0f\n\x07\x65xplore\x18\x02 \x01(\x08\x12\x13\n\x0b\x64\x65\x63ision_id\x18\x03 \x01(\t\x12\'\n\tbreakdown\x18\x04 \x03(\x0b\x32\x14.csn.ActionBreakdown\x12\x0e\n\x06reason\x18\x05 \x01(\t\x12\x0e\n\x06\x64\x65nied\x18\x06 \x01(\x08\x12\x16\n\x0eretry_after_ms\x18\x07 \x01(\x03\x12\x10\n\x08\x64\x65graded\x18\x08 \x01(\x08\x12\x0b\n\x03\x61rm\x18\t \x01(\t\x12\x12\n\npropensity\x18\n \x01(\x01\"]\n\x0eOutcomeRequest\x12\x13\n\x0b\x64\x65\x63ision_id\x18\x01 \x01(\t\x12\x1b\n\x13observed_latency_ms\x18\x02 \x01(\x01\x12\x19\n\x11observed_energy_j\x18\x03 \x01(\x01\" \n\x0cOutcomeReply\x12\x10\n\x08\x61\x63\x63\x65pted\x18\x01 \x01(\x08\"\xa9\x01\n\nActionSpec\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x0c\n\x04site\x18\x02 \x01(\t\x12\x0c\n\x04kind\x18\x03 \x01(\t\x12\x0c\n\x04tier\x18\x04 \x01(\t\x12\x0f\n\x07\x63ost_ms\x18\x05 \x01(\x01\x12\x11\n\tintensity\x18\x06 \x01(\x01\x12\x0b\n\x03\x63pu\x18\x07 \x01(\x01\x12\x0e\n\x06mem_mb\x18\x08 \x01(\x01\x12\x10\n\x08net_mbps\x18\t \x01(\x01\x12\x10\n\x08\x63\x61pacity\x18\n \x01(\x05\"\x14\n\x12ListActionsRequest\"4\n\x10ListActionsReply\x12 \n\x07\x61\x63tions\x18\x01 \x03(\x0b\x32\x0f.csn.ActionSpec\"e\n\x0cModelVersion\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x0f\n\x07\x63urrent\x18\x02 \x01(\x08\x12\r\n\x05valid\x18\x03 \x01(\x08\x12\x10\n\x08problems\x18\x04 \x03(\t\x12\x15\n\rmanifest_json\x18\x05 \x01(\t\"\x13\n\x11ListModelsRequest\"G\n\x0fListModelsReply\x12#\n\x08versions\x18\x01 \x03(\x0b\x32\x11.csn.ModelVersion\x12\x0f\n\x07\x63urrent\x18\x02 \x01(\t\"6\n\x13PromoteModelRequest\x12\x0f\n\x07version\x18\x01 \x01(\t\x12\x0e\n\x06reason\x18\x02 \x01(\t\"&\n\x14RollbackModelRequest\x12\x0e\n\x06reason\x18\x01 \x01(\t\"5\n\x10ModelChangeReply\x12\x10\n\x08previous\x18\x01 \x01(\t\x12\x0f\n\x07\x63urrent\x18\x02 \x01(\t2\x80\x01\n\tPredictor\x12\x31\n\x07Predict\x12\x13.csn.PredictRequest\x1a\x11.csn.PredictReply\x12@\n\x0cPredictBatch\x12\x18.csn.PredictBatchRequest\x1a\x16.csn.PredictBatchReply2\xb1\x01\n\x07\x44\x65\x63ider\x12.\n\x06\x44\x65\x63ide\x12\x12.csn.DecideRequest\x1a\x10.csn.DecideReply\x12\x37\n\rReportOutcome\x12\x13.csn.OutcomeRequest\x1a\x11.csn.OutcomeReply\x12=\n\x0bListActions\x12\x17.csn.ListActionsRequest\x1a\x15.csn.ListActionsReply2\xcf\x01\n\rModelRegistry\x12:\n\nListModels\x12\x16.csn.ListModelsRequest\x1a\x14.csn.ListModelsReply\x12?\n\x0cPromoteModel\x12\x18.csn.PromoteModelRequest\x1a\x15.csn.ModelChangeReply\x12\x41\n\rRollbackModel\x12\x19.csn.RollbackModelRequest\x1a\x15.csn.ModelChangeReplyB\"Z github.com/mulat/csn/proto;csnpbb\x06proto3')
 
 _globals = globals()
 _builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
@@ -36,14 +36,44 @@ if not _descriptor._USE_C_DESCRIPTORS:
   _globals['_CONTEXT']._serialized_end=213
   _globals['_PREDICTREQUEST']._serialized_start=215
   _globals['_PREDICTREQUEST']._serialized_end=274
-  _globals['_PREDICTREPLY']._serialized_start=276
-  _globals['_PREDICTREPLY']._serialized_end=401
-  _globals['_DECIDEREQUEST']._serialized_start=403
-  _globals['_DECIDEREQUEST']._serialized_end=471
-  _globals['_DECIDEREPLY']._serialized_start=473
-  _globals['_DECIDEREPLY']._serialized_end=526
-  _globals['_PREDICTOR']._serialized_start=528
-  _globals['_PREDICTOR']._serialized_end=590
-  _globals['_DECIDER']._serialized_start=592
-  _globals['_DECIDER']._serialized_end=649
+  _globals['_PREDICTREPLY']._serialized_start=277
+  _globals['_PREDICTREPLY']._serialized_end=425
+  _globals['_PREDICTBATCHREQUEST']._serialized_start=427
+  _globals['_PREDICTBATCHREQUEST']._serialized_end=492
+  _globals['_PREDICTBATCHREPLY']._serialized_start=494
+  _globals['_PREDICTBATCHREPLY']._serialized_end=553
+  _globals['_DECIDEREQUEST']._serialized_start=555
+  _globals['_DECIDEREQUEST']._serialized_end=640
+  _globals['_ACTIONBREAKDOWN']._serialized_start=643
+  _globals['_ACTIONBREAKDOWN']._serialized_end=840
+  _globals['_DECIDEREPLY']._serialized_start=843
+  _globals['_DECIDEREPLY']._serialized_end=1065
+  _globals['_OUTCOMEREQUEST']._serialized_start=1067
+  _globals['_OUTCOMEREQUEST']._serialized_end=1160
+  _globals['_OUTCOMEREPLY']._serialized_start=1162
+  _globals['_OUTCOMEREPLY']._serialized_end=1194
+  _globals['_ACTIONSPEC']._serialized_start=1197
+  _globals['_ACTIONSPEC']._serialized_end=1366
+  _globals['_LISTACTIONSREQUEST']._serialized_start=1368
+  _globals['_LISTACTIONSREQUEST']._serialized_end=1388
+  _globals['_LISTACTIONSREPLY']._serialized_start=1390
+  _globals['_LISTACTIONSREPLY']._serialized_end=1442
+  _globals['_MODELVERSION']._serialized_start=1444
+  _globals['_MODELVERSION']._serialized_end=1545
+  _globals['_LISTMODELSREQUEST']._serialized_start=1547
+  _globals['_LISTMODELSREQUEST']._serialized_end=1566
+  _globals['_LISTMODELSREPLY']._serialized_start=1568
+  _globals['_LISTMODELSREPLY']._serialized_end=1639
+  _globals['_PROMOTEMODELREQUEST']._serialized_start=1641
+  _globals['_PROMOTEMODELREQUEST']._serialized_end=1695
+  _globals['_ROLLBACKMODELREQUEST']._serialized_start=1697
+  _globals['_ROLLBACKMODELREQUEST']._serialized_end=1735
+  _globals['_MODELCHANGEREPLY']._serialized_start=1737
+  _globals['_MODELCHANGEREPLY']._serialized_end=1790
+  _globals['_PREDICTOR']._serialized_start=1793
+  _globals['_PREDICTOR']._serialized_end=1921
+  _globals['_DECIDER']._serialized_start=1924
+  _globals['_DECIDER']._serialized_end=2101
+  _globals['_MODELREGISTRY']._serialized_start=2104
+  _globals['_MODELREGISTRY']._serialized_end=2311
 # @@protoc_insertion_point(module_scope)
@@ -39,6 +39,11 @@ class PredictorStub(object):
                 request_serializer=proto_dot_csn__pb2.PredictRequest.SerializeToString,
                 response_deserializer=proto_dot_csn__pb2.PredictReply.FromString,
                 _registered_method=True)
+        self.PredictBatch = channel.unary_unary(
+                '/csn.Predictor/PredictBatch',
+                request_serializer=proto_dot_csn__pb2.PredictBatchRequest.SerializeToString,
+                response_deserializer=proto_dot_csn__pb2.PredictBatchReply.FromString,
+                _registered_method=True)
 
 
 class PredictorServicer(object):
@@ -50,6 +55,12 @@ class PredictorServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def PredictBatch(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_PredictorServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -58,6 +69,11 @@ def add_PredictorServicer_to_server(servicer, server):
                     request_deserializer=proto_dot_csn__pb2.PredictRequest.FromString,
                     response_serializer=proto_dot_csn__pb2.PredictReply.SerializeToString,
             ),
+            'PredictBatch': grpc.unary_unary_rpc_method_handler(
+                    servicer.PredictBatch,
+                    request_deserializer=proto_dot_csn__pb2.PredictBatchRequest.FromString,
+                    response_serializer=proto_dot_csn__pb2.PredictBatchReply.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'csn.Predictor', rpc_method_handlers)
@@ -96,6 +112,33 @@ class Predictor(object):
             metadata,
             _registered_method=True)
 
+    @staticmethod
+    def PredictBatch(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/csn.Predictor/PredictBatch',
+            proto_dot_csn__pb2.PredictBatchRequest.SerializeToString,
+            proto_dot_csn__pb2.PredictBatchReply.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
 
 class DeciderStub(object):
     """Missing associated documentation comment in .proto file."""
@@ -111,6 +154,16 @@ class DeciderStub(object):
                 request_serializer=proto_dot_csn__pb2.DecideRequest.SerializeToString,
                 response_deserializer=proto_dot_csn__pb2.DecideReply.FromString,
                 _registered_method=True)
+        self.ReportOutcome = channel.unary_unary(
+                '/csn.Decider/ReportOutcome',
+                request_serializer=proto_dot_csn__pb2.OutcomeRequest.SerializeToString,
+                response_deserializer=proto_dot_csn__pb2.OutcomeReply.FromString,
+                _registered_method=True)
+        self.ListActions = channel.unary_unary(
+                '/csn.Decider/ListActions',
+                request_serializer=proto_dot_csn__pb2.ListActionsRequest.SerializeToString,
+                response_deserializer=proto_dot_csn__pb2.ListActionsReply.FromString,
+                _registered_method=True)
 
 
 class DeciderServicer(object):
@@ -122,6 +175,18 @@ class DeciderServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def ReportOutcome(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def ListActions(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_DeciderServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -130,6 +195,16 @@ def add_DeciderServicer_to_server(servicer, server):
                     request_deserializer=proto_dot_csn__pb2.DecideRequest.FromString,
                     response_serializer=proto_dot_csn__pb2.DecideReply.SerializeToString,
             ),
+            'ReportOutcome': grpc.unary_unary_rpc_method_handler(
+                    servicer.ReportOutcome,
+                    request_deserializer=proto_dot_csn__pb2.OutcomeRequest.FromString,
+                    response_serializer=proto_dot_csn__pb2.OutcomeReply.SerializeToString,
+            ),
+            'ListActions': grpc.unary_unary_rpc_method_handler(
+                    servicer.ListActions,
+                    request_deserializer=proto_dot_csn__pb2.ListActionsRequest.FromString,
+                    response_serializer=proto_dot_csn__pb2.ListActionsReply.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'csn.Decider', rpc_method_handlers)
@@ -167,3 +242,215 @@ class Decider(object):
             timeout,
             metadata,
             _registered_method=True)
+
+    @staticmethod
+    def ReportOutcome(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/csn.Decider/ReportOutcome',
+            proto_dot_csn__pb2.OutcomeRequest.SerializeToString,
+            proto_dot_csn__pb2.OutcomeReply.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def ListActions(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/csn.Decider/ListActions',
+            proto_dot_csn__pb2.ListActionsRequest.SerializeToString,
+            proto_dot_csn__pb2.ListActionsReply.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+
+class ModelRegistryStub(object):
+    """Missing associated documentation comment in .proto file."""
+
+    def __init__(self, channel):
+        """Constructor.
+
+        Args:
+            channel: A grpc.Channel.
+        """
+        self.ListModels = channel.unary_unary(
+                '/csn.ModelRegistry/ListModels',
+                request_serializer=proto_dot_csn__pb2.ListModelsRequest.SerializeToString,
+                response_deserializer=proto_dot_csn__pb2.ListModelsReply.FromString,
+                _registered_method=True)
+        self.PromoteModel = channel.unary_unary(
+                '/csn.ModelRegistry/PromoteModel',
+                request_serializer=proto_dot_csn__pb2.PromoteModelRequest.SerializeToString,
+                response_deserializer=proto_dot_csn__pb2.ModelChangeReply.FromString,
+                _registered_method=True)
+        self.RollbackModel = channel.unary_unary(
+                '/csn.ModelRegistry/RollbackModel',
+                request_serializer=proto_dot_csn__pb2.RollbackModelRequest.SerializeToString,
+                response_deserializer=proto_dot_csn__pb2.ModelChangeReply.FromString,
+                _registered_method=True)
+
+
+class ModelRegistryServicer(object):
+    """Missing associated documentation comment in .proto file."""
+
+    def ListModels(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def PromoteModel(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def RollbackModel(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+
+def add_ModelRegistryServicer_to_server(servicer, server):
+    rpc_method_handlers = {
+            'ListModels': grpc.unary_unary_rpc_method_handler(
+                    servicer.ListModels,
+                    request_deserializer=proto_dot_csn__pb2.ListModelsRequest.FromString,
+                    response_serializer=proto_dot_csn__pb2.ListModelsReply.SerializeToString,
+            ),
+            'PromoteModel': grpc.unary_unary_rpc_method_handler(
+                    servicer.PromoteModel,
+                    request_deserializer=proto_dot_csn__pb2.PromoteModelRequest.FromString,
+                    response_serializer=proto_dot_csn__pb2.ModelChangeReply.SerializeToString,
+            ),
+            'RollbackModel': grpc.unary_unary_rpc_method_handler(
+                    servicer.RollbackModel,
+                    request_deserializer=proto_dot_csn__pb2.RollbackModelRequest.FromString,
+                    response_serializer=proto_dot_csn__pb2.ModelChangeReply.SerializeToString,
+            ),
+    }
+    generic_handler = grpc.method_handlers_generic_handler(
+            'csn.ModelRegistry', rpc_method_handlers)
+    server.add_generic_rpc_handlers((generic_handler,))
+    server.add_registered_method_handlers('csn.ModelRegistry', rpc_method_handlers)
+
+
+ # This class is part of an EXPERIMENTAL API.
+class ModelRegistry(object):
+    """Missing associated documentation comment in .proto file."""
+
+    @staticmethod
+    def ListModels(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/csn.ModelRegistry/ListModels',
+            proto_dot_csn__pb2.ListModelsRequest.SerializeToString,
+            proto_dot_csn__pb2.ListModelsReply.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def PromoteModel(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/csn.ModelRegistry/PromoteModel',
+            proto_dot_csn__pb2.PromoteModelRequest.SerializeToString,
+            proto_dot_csn__pb2.ModelChangeReply.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def RollbackModel(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/csn.ModelRegistry/RollbackModel',
+            proto_dot_csn__pb2.RollbackModelRequest.SerializeToString,
+            proto_dot_csn__pb2.ModelChangeReply.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
@@ -11,13 +11,14 @@ GAMMA_MAX=40.0
 GAMMA_STEP=1.0
 TARGET=0.10
 INTERVAL=3
+CLASS="${CLASS:-default}"   # SLO class to tune
 
 get_metric () {
   curl -s "$DECIDER/metrics" | awk -v k="$1" '$1==k {print $2}' | tail -n1
 }
 
 get_mu () {
-  curl -s "$DECIDER/lagrange/get" | python3 -c 'import sys,json; print(json.load(sys.stdin)["mu_slo"])'
+  curl -s "$DECIDER/lagrange/get" | python3 -c 'import sys,json; print(json.load(sys.stdin)["mu_slo"][sys.argv[1]])' "$CLASS"
 }
 
 get_gamma () {
@@ -26,7 +27,7 @@ get_gamma () {
 
 set_lagrange () {
   local mu="$1" gamma="$2"
-  curl -s -X POST "$DECIDER/lagrange/set?mu_slo=${mu}&gamma_fair_ms=${gamma}" >/dev/null
+  curl -s -X POST "$DECIDER/lagrange/set?class=${CLASS}&mu_slo=${mu}&gamma_fair_ms=${gamma}" >/dev/null
 }
 
 clamp () {
@@ -39,13 +40,13 @@ print(v)
 PY
 }
 
-echo "[ls-autotune] starting... target violation=$TARGET"
+echo "[ls-autotune] starting... class=$CLASS target violation=$TARGET"
 
 MU="$(get_mu 2>/dev/null || echo 0)"
 GAMMA="$(get_gamma 2>/dev/null || echo 10)"
 
 while true; do
-  VIOL="$(get_metric csn_viol_rate)"
+  VIOL="$(get_metric "csn_viol_rate{class=\"$CLASS\"}")"
   [ -z "$VIOL" ] && VIOL=0
 
   # adjust mu_slo
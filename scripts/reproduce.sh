@@ -14,7 +14,7 @@ is_up() { ss -ltn 2>/dev/null | awk '{print $4}' | grep -q ":$1$"; }
 echo "[reproduce] ensuring services are up…"
 if ! is_up 7001; then ./bin/predictor & echo $! > "$RUN_DIR/predictor.pid"; sleep 0.3; fi
 if ! is_up 8000; then uvicorn ml.serve_predictor:app --host 127.0.0.1 --port 8000 & echo $! > "$RUN_DIR/model.pid"; sleep 0.4; fi
-if ! is_up 9103; then uvicorn services.operator.api:app --host 0.0.0.0 --port 9103 & echo $! > "$RUN_DIR/operator.pid"; sleep 0.4; fi
+if ! is_up 9103; then ./bin/operator & echo $! > "$RUN_DIR/operator.pid"; sleep 0.4; fi
 if ! is_up 7002; then ./bin/decider & echo $! > "$RUN_DIR/decider.pid"; sleep 0.5; fi
 
 echo "[reproduce] services:"
@@ -24,7 +24,7 @@ sudo lsof -i -P -n | egrep ':8000|:7001|:7002|:9102|:9103' | grep LISTEN | tee "
 edges_up() { curl -s "$OP/metrics" | awk '$1=="csn_edges_up"{print $2}'; }
 metric_pair() {
   curl -s "$DEC_METRICS" | awk '
-    $1=="csn_viol_rate"{v=$2}
+    $1=="csn_viol_rate{class=\"default\"}"{v=$2}
     $1=="csn_explore_epsilon"{e=$2}
     END{printf("viol_rate=%s, epsilon=%s\n", v, e)}'
 }
@@ -41,13 +41,13 @@ curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e2"
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e3"}' >/dev/null || true
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e4"}' >/dev/null || true
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e5"}' >/dev/null || true
-curl -s -X POST "$OP/edge"  -H 'Content-Type: application/json' -d '{"name":"e2"}' >/dev/null
-curl -s -X POST "$OP/edge"  -H 'Content-Type: application/json' -d '{"name":"e3"}' >/dev/null
+curl -s -X POST "$OP/edge"  -H 'Content-Type: application/json' -d '{"name":"e2","site":"edge1"}' >/dev/null
+curl -s -X POST "$OP/edge"  -H 'Content-Type: application/json' -d '{"name":"e3","site":"edge2"}' >/dev/null
 
 # run phases
 phase "BASELINE (2 edges)"        "phase1.out"
-curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e4"}' >/dev/null
-curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e5"}' >/dev/null
+curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e4","site":"edge1"}' >/dev/null
+curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e5","site":"edge2"}' >/dev/null
 phase "SCALE UP (4 edges)"        "phase2.out"
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e2"}' >/dev/null
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e3"}' >/dev/null
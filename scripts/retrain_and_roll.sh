@@ -24,8 +24,8 @@ cat > "$NEW_DIR/manifest.json" <<JSON
 }
 JSON
 
-# 3) atomically switch active pointer
-ln -sfn "$(pwd)/$NEW_DIR" models/current
+# 3) validate and atomically switch models/current (relative symlink)
+./bin/registry -root models promote "v${STAMP}" -reason "${1-adhoc}"
 
 echo "[csn] rolled models/current -> $NEW_DIR"
 ls -l models | sed -n '1,10p'
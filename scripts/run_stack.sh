@@ -2,8 +2,8 @@
 set -euo pipefail
 cd "$(dirname "$0")/.."
 
-pkill -f "uvicorn .*services.operator.api" 2>/dev/null || true
-pkill -f "uvicorn .*services.sensing.api"  2>/dev/null || true
+pkill -f "/bin/operator$" 2>/dev/null || true
+pkill -f "/bin/sensing$" 2>/dev/null || true
 pkill -f "/bin/predictor$" 2>/dev/null || true
 pkill -f "/bin/decider$" 2>/dev/null || true
 docker rm -f csn-prom 2>/dev/null || true
@@ -11,9 +11,8 @@ sleep 0.3
 
 ./scripts/go_next.sh
 
-source .venv/bin/activate
-nohup uvicorn services.sensing.api:app --host 0.0.0.0 --port 9104 > /tmp/csn-sensing.log 2>&1 &
-deactivate
+# sensing: ingest on :9105 (CSN_SENSE_URL), metrics on :9104
+nohup ./bin/sensing > /tmp/csn-sensing.log 2>&1 &
 
 mkdir -p ops
 cat > ops/prometheus.yml <<'YAML'
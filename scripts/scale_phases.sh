@@ -17,22 +17,23 @@ curl -sf "$OP/metrics" >/dev/null || { echo "Operator not up on 9103"; exit 1; }
 # reset to 2 edges (drain all, then add two deterministic)
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e2"}' >/dev/null || true
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e3"}' >/dev/null || true
-curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"edge4"}' >/dev/null || true
-curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e2"}' >/dev/null
-curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e3"}' >/dev/null
+curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e4"}' >/dev/null || true
+curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e5"}' >/dev/null || true
+curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e2","site":"edge1"}' >/dev/null
+curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e3","site":"edge2"}' >/dev/null
 
 phase "BASELINE (2 edges)"
 
 # scale up to 4
-curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e4"}' >/dev/null
-curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e5"}' >/dev/null
+curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e4","site":"edge1"}' >/dev/null
+curl -s -X POST "$OP/edge" -H 'Content-Type: application/json' -d '{"name":"e5","site":"edge2"}' >/dev/null
 phase "SCALE UP (4 edges)"
 
-# scale down to 1
+# scale down to 1 (e5 on edge2); edge1 has no edge left and reports down
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e2"}' >/dev/null
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e3"}' >/dev/null
 curl -s -X POST "$OP/drain" -H 'Content-Type: application/json' -d '{"name":"e4"}' >/dev/null
 phase "SCALE DOWN (1 edge)"
 
 echo -e "\n=== Done. Recent telemetry rows ==="
-tail -n 6 experiments/telemetry.csv 2>/dev/null || echo "no telemetry file yet"
+tail -n 6 experiments/telemetry_live.csv 2>/dev/null || echo "no telemetry file yet"
@@ -18,7 +18,7 @@ fi
 # operator :9103
 if need ":9103"; then
   echo "[up] operator :9103"
-  uvicorn services.operator.api:app --host 0.0.0.0 --port 9103 &>/tmp/csn_operator.log &
+  ./bin/operator &>/tmp/csn_operator.log &
 fi
 
 # decider :7002 / :9102
@@ -0,0 +1,45 @@
+package main
+
+import (
+"context"
+
+"github.com/mulat/csn/catalog"
+
+pb "github.com/mulat/csn/proto"
+)
+
+// global action catalog (catalog.path, or the built-in default); set once in main
+var actionCatalog = catalog.Default()
+
+func actionSpec(a catalog.Action) *pb.ActionSpec {
+return &pb.ActionSpec{
+Name:      a.Name,
+Site:      a.Site,
+Kind:      a.Kind,
+Tier:      a.Tier,
+CostMs:    a.CostMs,
+Intensity: a.Intensity,
+Cpu:       a.CPU,
+MemMb:     a.MemMB,
+NetMbps:   a.NetMbps,
+Capacity:  int32(a.Capacity),
+}
+}
+
+// ListActions serves the catalog so clients use the Decider's view of each action.
+func (s *deciderServer) ListActions(ctx context.Context, req *pb.ListActionsRequest) (*pb.ListActionsReply, error) {
+acts := actionCatalog.Actions()
+reply := &pb.ListActionsReply{Actions: make([]*pb.ActionSpec, len(acts))}
+for i, a := range acts {
+reply.Actions[i] = actionSpec(a)
+}
+return reply, nil
+}
+
+// loadCatalog reads path, or returns the built-in catalog when path is empty.
+func loadCatalog(path string) (*catalog.Catalog, error) {
+if path == "" {
+return catalog.Default(), nil
+}
+return catalog.Load(path)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+"encoding/json"
+"fmt"
+"log"
+"os"
+"path/filepath"
+"strings"
+"sync"
+"time"
+
+"github.com/prometheus/client_golang/prometheus"
+
+pb "github.com/mulat/csn/proto"
+)
+
+var mAuditErrors = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_audit_write_errors_total",
+Help: "Decision audit records that could not be written",
+})
+
+func init() {
+prometheus.MustRegister(mAuditErrors)
+}
+
+type auditContext struct {
+TenantID   string  `json:"tenant_id"`
+AppID      string  `json:"app_id"`
+BwMbps     float64 `json:"bw_mbps"`
+RttMs      float64 `json:"rtt_ms"`
+Loss       float64 `json:"loss"`
+DeviceCPU  float64 `json:"device_cpu"`
+BatterySoc float64 `json:"battery_soc"`
+EdgeCPU    float64 `json:"edge_cpu"`
+InputKb    float64 `json:"input_kb"`
+SloP95Ms   float64 `json:"slo_p95_ms"`
+}
+
+func newAuditContext(c *pb.Context) auditContext {
+return auditContext{
+TenantID:   c.GetTenantId(),
+AppID:      c.GetAppId(),
+BwMbps:     c.GetBwMbps(),
+RttMs:      c.GetRttMs(),
+Loss:       c.GetLoss(),
+DeviceCPU:  c.GetDeviceCpu(),
+BatterySoc: c.GetBatterySoc(),
+EdgeCPU:    c.GetEdgeCpu(),
+InputKb:    c.GetInputKb(),
+SloP95Ms:   c.GetSloP95Ms(),
+}
+}
+
+// auditCandidate is one scored action. mu_latency_ms/mu_energy_j are the
+// predictor's (capacity-adjusted) estimate; bandit_lat_ms/bandit_en_j are what
+// the policy scored after the bandit correction, when the bandit is on.
+type auditCandidate struct {
+Action          string   `json:"action"`
+MuLatencyMs     float64  `json:"mu_latency_ms"`
+P95ConformalMs  float64  `json:"p95_conformal_ms"`
+P95EffMs        float64  `json:"p95_eff_ms"`
+MuEnergyJ       float64  `json:"mu_energy_j"`
+BanditLatMs     *float64 `json:"bandit_lat_ms,omitempty"`
+BanditEnJ       *float64 `json:"bandit_en_j,omitempty"`
+CostMs          float64  `json:"cost_ms"`
+CapacityFactor  float64  `json:"capacity_factor"`
+FairnessPenalty float64  `json:"fairness_penalty"`
+Utility         float64  `json:"utility"`
+Propensity      float64  `json:"propensity"`
+}
+
+// auditRecord is one JSONL line; Kind is "decision", "outcome" or "retrain".
+type auditRecord struct {
+Kind       string `json:"kind"`
+DecisionID string `json:"decision_id"`
+TS         string `json:"ts"`
+
+// decision
+Context         *auditContext    `json:"context,omitempty"`
+FeasibleActions []string         `json:"feasible_actions,omitempty"`
+Policy          string           `json:"policy,omitempty"`
+Arm             string           `json:"arm,omitempty"`
+Candidates      []auditCandidate `json:"candidates,omitempty"`
+MuSLO           *float64         `json:"mu_slo,omitempty"`
+SLOClass        string           `json:"slo_class,omitempty"`
+Epsilon         *float64         `json:"epsilon,omitempty"`
+CapacityFactor  *float64         `json:"capacity_factor,omitempty"`
+Argmax          string           `json:"argmax,omitempty"`
+Chosen          string           `json:"chosen,omitempty"`
+Propensity      *float64         `json:"propensity,omitempty"`
+PropensityMethod string        `json:"propensity_method,omitempty"` // exact | monte_carlo
+Explore         bool             `json:"explore,omitempty"`
+Reason          string           `json:"reason,omitempty"`
+EpsilonChanged  bool             `json:"epsilon_changed,omitempty"`
+FairnessChanged bool             `json:"fairness_changed,omitempty"`
+BreakerOpen     bool             `json:"breaker_open,omitempty"`
+SitesDown       []string         `json:"sites_down,omitempty"`
+Degraded        bool             `json:"degraded,omitempty"`
+Shadow          []shadowResult   `json:"shadow,omitempty"`
+
+// outcome
+Source            string   `json:"source,omitempty"` // reported | timeout
+ObservedLatencyMs *float64 `json:"observed_latency_ms,omitempty"`
+ObservedEnergyJ   *float64 `json:"observed_energy_j,omitempty"`
+
+// retrain
+Retrain *retrainTrigger `json:"retrain,omitempty"`
+}
+
+// auditLog appends JSONL records to path, rotating to path.1..path.keep once
+// the file exceeds maxBytes. A nil *auditLog discards everything.
+type auditLog struct {
+path     string
+maxBytes int64
+keep     int
+clock    clock // record timestamps
+
+mu   sync.Mutex
+f    *os.File
+size int64
+}
+
+func newAuditLog(path string, maxBytes int64, keep int) (*auditLog, error) {
+if dir := filepath.Dir(path); dir != "" {
+if err := os.MkdirAll(dir, 0o755); err != nil {
+return nil, err
+}
+}
+a := &auditLog{path: path, maxBytes: maxBytes, keep: keep}
+if err := a.openLocked(); err != nil {
+return nil, err
+}
+return a, nil
+}
+
+func (a *auditLog) openLocked() error {
+f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+if err != nil {
+return err
+}
+st, err := f.Stat()
+if err != nil {
+f.Close()
+return err
+}
+a.f, a.size = f, st.Size()
+return nil
+}
+
+func (a *auditLog) rotateLocked() error {
+if a.f != nil {
+a.f.Close()
+a.f = nil
+}
+for i := a.keep - 1; i >= 1; i-- {
+_ = os.Rename(fmt.Sprintf("%s.%d", a.path, i), fmt.Sprintf("%s.%d", a.path, i+1))
+}
+if a.keep > 0 {
+_ = os.Rename(a.path, a.path+".1")
+} else {
+_ = os.Remove(a.path)
+}
+return a.openLocked()
+}
+
+func (a *auditLog) write(rec *auditRecord) {
+if a == nil {
+return
+}
+if rec.TS == "" {
+rec.TS = clockNow(a.clock).UTC().Format(time.RFC3339Nano)
+}
+buf, err := json.Marshal(rec)
+if err != nil {
+mAuditErrors.Inc()
+return
+}
+buf = append(buf, '\n')
+a.mu.Lock()
+defer a.mu.Unlock()
+if a.f == nil || (a.maxBytes > 0 && a.size+int64(len(buf)) > a.maxBytes) {
+if err := a.rotateLocked(); err != nil {
+mAuditErrors.Inc()
+log.Printf("audit rotate: %v", err)
+return
+}
+}
+n, err := a.f.Write(buf)
+a.size += int64(n)
+if err != nil {
+mAuditErrors.Inc()
+}
+}
+
+// decisionTrace records how Decide arrived at its choice.
+type decisionTrace struct {
+argmax      string
+chosen      string
+epsChanged  bool
+fairChanged bool
+breakerOpen bool
+sitesDown   []string // feasible actions dropped because their site is down
+degraded    bool
+muSLO       float64
+sloClass    string
+epsilon     float64
+capFactor   float64
+shadows     []shadowResult
+arm         string // experiment arm, "" outside experiments
+propensity  float64
+propMethod  string // propensityExact or propensityMonteCarlo
+explore     bool
+}
+
+// reason names the step that determined the final action.
+func (tr decisionTrace) reason() string {
+switch {
+case tr.degraded:
+return "degraded"
+case tr.fairChanged:
+return "fairness"
+case tr.epsChanged:
+return "epsilon"
+}
+return "argmax"
+}
+
+func (s *deciderServer) auditDecision(id string, req *pb.DecideRequest, pol Policy, cands []candidate, probs []float64, tr decisionTrace) {
+if s.audit == nil {
+return
+}
+tenant := req.Ctx.GetTenantId()
+if tenant == "" {
+tenant = "default"
+}
+ac := newAuditContext(req.Ctx)
+rec := &auditRecord{
+Kind:            "decision",
+DecisionID:      id,
+Context:         &ac,
+FeasibleActions: req.FeasibleActions,
+Policy:          pol.Name(),
+Arm:             tr.arm,
+Candidates:      make([]auditCandidate, 0, len(cands)),
+MuSLO:           &tr.muSLO,
+SLOClass:        tr.sloClass,
+Epsilon:         &tr.epsilon,
+CapacityFactor:  &tr.capFactor,
+Argmax:          tr.argmax,
+Chosen:          tr.chosen,
+Propensity:      &tr.propensity,
+PropensityMethod: tr.propMethod,
+Explore:         tr.explore,
+Reason:          tr.reason(),
+EpsilonChanged:  tr.epsChanged,
+FairnessChanged: tr.fairChanged,
+BreakerOpen:     tr.breakerOpen,
+SitesDown:       tr.sitesDown,
+Degraded:        tr.degraded,
+Shadow:          tr.shadows,
+}
+for i, c := range cands {
+ac := auditCandidate{
+Action:          c.action,
+MuLatencyMs:     c.predLat,
+P95ConformalMs:  c.p95Conf,
+P95EffMs:        c.p95eff,
+MuEnergyJ:       c.predEn,
+CostMs:          c.costMs,
+CapacityFactor:  c.capFactor,
+FairnessPenalty: s.fairnessPenalty(tenant, c.action),
+Utility:         c.u,
+Propensity:      probs[i],
+}
+if c.sampled {
+lat, en := c.muLat, c.muEn
+ac.BanditLatMs, ac.BanditEnJ = &lat, &en
+}
+rec.Candidates = append(rec.Candidates, ac)
+}
+s.audit.write(rec)
+}
+
+// auditLogFromConfig opens audit.path ("off" disables) with audit.max_mb/keep rotation.
+func auditLogFromConfig(c *deciderConfig, clk clock) *auditLog {
+path := strings.TrimSpace(c.AuditPath)
+if path == "off" || path == "" {
+return nil
+}
+a, err := newAuditLog(path, int64(c.AuditMaxMB)<<20, c.AuditKeep)
+if err != nil {
+log.Printf("audit log disabled: %v", err)
+return nil
+}
+a.clock = clk
+return a
+}
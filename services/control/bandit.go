@@ -0,0 +1,268 @@
+package main
+
+import (
+"encoding/json"
+"math"
+"net/http"
+"sync"
+
+"github.com/prometheus/client_golang/prometheus"
+
+pb "github.com/mulat/csn/proto"
+)
+
+var (
+mBanditUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_bandit_updates_total",
+Help: "Residual observations folded into the contextual bandit, per action",
+}, []string{"action"})
+mBanditResidual = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_bandit_last_residual_ms",
+Help: "Last observed latency residual (observed - predicted), per action",
+}, []string{"action"})
+)
+
+func init() {
+prometheus.MustRegister(mBanditUpdates, mBanditResidual)
+}
+
+// banditDim is the length of the feature vector built by banditFeatures.
+const banditDim = 9
+
+// banditFeatures maps a context to a roughly unit-scaled vector (with bias term).
+func banditFeatures(c *pb.Context) []float64 {
+return []float64{
+1,
+c.GetBwMbps() / 100,
+c.GetRttMs() / 100,
+c.GetLoss() * 100,
+c.GetDeviceCpu(),
+c.GetBatterySoc(),
+c.GetEdgeCpu(),
+c.GetInputKb() / 1024,
+c.GetSloP95Ms() / 100,
+}
+}
+
+// armModel is a ridge regression of the predictor's latency and energy
+// residuals on the context, kept as A^-1 (Sherman-Morrison updates).
+type armModel struct {
+n    int
+ainv [][]float64
+bLat []float64
+bEn  []float64
+}
+
+func newArmModel(lambda float64) *armModel {
+m := &armModel{ainv: make([][]float64, banditDim), bLat: make([]float64, banditDim), bEn: make([]float64, banditDim)}
+for i := range m.ainv {
+m.ainv[i] = make([]float64, banditDim)
+m.ainv[i][i] = 1 / lambda
+}
+return m
+}
+
+func matVec(m [][]float64, x []float64) []float64 {
+out := make([]float64, len(m))
+for i := range m {
+for j, v := range x {
+out[i] += m[i][j] * v
+}
+}
+return out
+}
+
+func dot(a, b []float64) float64 {
+s := 0.0
+for i := range a {
+s += a[i] * b[i]
+}
+return s
+}
+
+func (m *armModel) update(x []float64, rLat, rEn float64) {
+ax := matVec(m.ainv, x)
+den := 1 + dot(x, ax)
+for i := range m.ainv {
+for j := range m.ainv[i] {
+m.ainv[i][j] -= ax[i] * ax[j] / den
+}
+}
+for i, v := range x {
+m.bLat[i] += rLat * v
+m.bEn[i] += rEn * v
+}
+m.n++
+}
+
+func (m *armModel) theta() (lat, en []float64) {
+return matVec(m.ainv, m.bLat), matVec(m.ainv, m.bEn)
+}
+
+// cholesky returns lower-triangular L with L*L^T = a, or nil if a is not PD.
+func cholesky(a [][]float64) [][]float64 {
+n := len(a)
+l := make([][]float64, n)
+for i := range l {
+l[i] = make([]float64, n)
+}
+for i := 0; i < n; i++ {
+for j := 0; j <= i; j++ {
+sum := a[i][j]
+for k := 0; k < j; k++ {
+sum -= l[i][k] * l[j][k]
+}
+if i == j {
+if sum <= 0 {
+return nil
+}
+l[i][i] = math.Sqrt(sum)
+} else {
+l[i][j] = sum / l[j][j]
+}
+}
+}
+return l
+}
+
+// contextualBandit learns, per action, how far the predictor is off in a given
+// context and folds that into the candidates before the policy scores them.
+//
+//	linucb: latency = mu + theta^T x - alpha*sqrt(x^T A^-1 x)  (optimism)
+//	lints:  latency = mu + theta~^T x, theta~ ~ N(theta, v^2 A^-1)
+//
+// Energy always uses the posterior mean.
+type contextualBandit struct {
+mode   string // off | linucb | lints
+alpha  float64
+v      float64
+lambda float64
+
+rng    *lockedRand // Thompson draws
+
+mu   sync.Mutex
+arms map[string]*armModel
+}
+
+func newContextualBandit(mode string, alpha, v float64, rng *lockedRand) *contextualBandit {
+return &contextualBandit{mode: mode, alpha: alpha, v: v, lambda: 1.0, rng: rng, arms: make(map[string]*armModel)}
+}
+
+func (b *contextualBandit) enabled() bool {
+if b == nil {
+return false
+}
+b.mu.Lock()
+defer b.mu.Unlock()
+return b.mode != "off"
+}
+
+// configure swaps mode and exploration widths in place; learned arms are kept.
+func (b *contextualBandit) configure(mode string, alpha, v float64) {
+b.mu.Lock()
+b.mode, b.alpha, b.v = mode, alpha, v
+b.mu.Unlock()
+}
+
+func (b *contextualBandit) armLocked(a string) *armModel {
+m, ok := b.arms[a]
+if !ok {
+m = newArmModel(b.lambda)
+b.arms[a] = m
+}
+return m
+}
+
+// adjust shifts the candidate's latency/energy/p95 by the learned residual.
+func (b *contextualBandit) adjust(c *candidate, x []float64) {
+b.mu.Lock()
+defer b.mu.Unlock()
+m := b.armLocked(c.action)
+tLat, tEn := m.theta()
+meanLat := dot(tLat, x)
+latShift := meanLat
+switch b.mode {
+case "linucb":
+width := math.Sqrt(math.Max(0, dot(x, matVec(m.ainv, x))))
+latShift -= b.alpha * width
+case "lints":
+if l := cholesky(m.ainv); l != nil {
+z := make([]float64, banditDim)
+for i := range z {
+z[i] = b.rng.NormFloat64()
+}
+latShift += b.v * dot(x, matVec(l, z))
+// the draw is N(0, v²·x'A⁻¹x); selectionPropensity re-draws it from these
+c.tsBase = c.muLat + meanLat
+c.tsStd = b.v * math.Sqrt(math.Max(0, dot(x, matVec(m.ainv, x))))
+}
+}
+c.muLat = math.Max(0, c.muLat+latShift)
+c.muEn = math.Max(0, c.muEn+dot(tEn, x))
+c.p95eff += meanLat
+c.sampled = true
+}
+
+func (b *contextualBandit) observe(action string, x []float64, rLat, rEn float64) {
+b.mu.Lock()
+b.armLocked(action).update(x, rLat, rEn)
+b.mu.Unlock()
+mBanditUpdates.WithLabelValues(action).Inc()
+mBanditResidual.WithLabelValues(action).Set(rLat)
+}
+
+func (b *contextualBandit) reset(action string) {
+b.mu.Lock()
+defer b.mu.Unlock()
+if action == "" {
+b.arms = make(map[string]*armModel)
+return
+}
+delete(b.arms, action)
+}
+
+type banditArmState struct {
+N        int       `json:"n"`
+ThetaLat []float64 `json:"theta_lat"`
+ThetaEn  []float64 `json:"theta_en"`
+}
+
+type banditState struct {
+Mode   string                    `json:"mode"`
+Alpha  float64                   `json:"alpha"`
+V      float64                   `json:"v"`
+Lambda float64                   `json:"lambda"`
+Arms   map[string]banditArmState `json:"arms"`
+}
+
+func (b *contextualBandit) snapshot() banditState {
+b.mu.Lock()
+defer b.mu.Unlock()
+st := banditState{Mode: b.mode, Alpha: b.alpha, V: b.v, Lambda: b.lambda, Arms: make(map[string]banditArmState)}
+for a, m := range b.arms {
+tl, te := m.theta()
+st.Arms[a] = banditArmState{N: m.n, ThetaLat: tl, ThetaEn: te}
+}
+return st
+}
+
+// registerBanditHandlers exposes /bandit/get and /bandit/reset[?action=edge1:med].
+func registerBanditHandlers(b *contextualBandit) {
+http.HandleFunc("/bandit/get", func(w http.ResponseWriter, r *http.Request) {
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(b.snapshot())
+})
+http.HandleFunc("/bandit/reset", func(w http.ResponseWriter, r *http.Request) {
+if r.Method != http.MethodPost {
+http.Error(w, "POST only", http.StatusMethodNotAllowed)
+return
+}
+b.reset(r.URL.Query().Get("action"))
+w.WriteHeader(http.StatusNoContent)
+})
+}
+
+// banditFromConfig builds the learner from bandit.mode/alpha/v.
+func banditFromConfig(c *deciderConfig, rng *lockedRand) *contextualBandit {
+return newContextualBandit(c.BanditMode, c.BanditAlpha, c.BanditV, rng)
+}
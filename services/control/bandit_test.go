@@ -0,0 +1,145 @@
+package main
+
+import (
+"math"
+"math/rand"
+"testing"
+)
+
+// invert returns a^-1 by Gauss-Jordan elimination with partial pivoting.
+func invert(t *testing.T, a [][]float64) [][]float64 {
+t.Helper()
+n := len(a)
+m := make([][]float64, n)
+for i := range a {
+m[i] = make([]float64, 2*n)
+copy(m[i], a[i])
+m[i][n+i] = 1
+}
+for c := 0; c < n; c++ {
+p := c
+for r := c + 1; r < n; r++ {
+if math.Abs(m[r][c]) > math.Abs(m[p][c]) {
+p = r
+}
+}
+if m[p][c] == 0 {
+t.Fatalf("singular matrix")
+}
+m[c], m[p] = m[p], m[c]
+d := m[c][c]
+for j := range m[c] {
+m[c][j] /= d
+}
+for r := 0; r < n; r++ {
+if r == c || m[r][c] == 0 {
+continue
+}
+f := m[r][c]
+for j := range m[r] {
+m[r][j] -= f * m[c][j]
+}
+}
+}
+out := make([][]float64, n)
+for i := range m {
+out[i] = m[i][n:]
+}
+return out
+}
+
+func TestArmModelShermanMorrison(t *testing.T) {
+cases := []struct {
+name   string
+lambda float64
+n      int
+seed   int64
+}{
+{"one update", 1, 1, 1},
+{"few updates", 1, 5, 2},
+{"more updates than dims", 1, 50, 3},
+{"strong prior", 10, 20, 4},
+{"weak prior", 0.1, 20, 5},
+}
+for _, tc := range cases {
+t.Run(tc.name, func(t *testing.T) {
+rng := rand.New(rand.NewSource(tc.seed))
+m := newArmModel(tc.lambda)
+a := make([][]float64, banditDim)
+for i := range a {
+a[i] = make([]float64, banditDim)
+a[i][i] = tc.lambda
+}
+bLat := make([]float64, banditDim)
+for k := 0; k < tc.n; k++ {
+x := make([]float64, banditDim)
+x[0] = 1
+for i := 1; i < banditDim; i++ {
+x[i] = rng.Float64()
+}
+r := rng.NormFloat64() * 10
+m.update(x, r, r/100)
+for i := range a {
+for j := range a[i] {
+a[i][j] += x[i] * x[j]
+}
+bLat[i] += r * x[i]
+}
+}
+want := invert(t, a)
+for i := range want {
+for j := range want[i] {
+if d := math.Abs(m.ainv[i][j] - want[i][j]); d > 1e-9 {
+t.Fatalf("ainv[%d][%d] = %g, direct inverse %g", i, j, m.ainv[i][j], want[i][j])
+}
+}
+}
+tLat, _ := m.theta()
+wantTheta := matVec(want, bLat)
+for i := range wantTheta {
+if d := math.Abs(tLat[i] - wantTheta[i]); d > 1e-6 {
+t.Fatalf("theta[%d] = %g, want %g", i, tLat[i], wantTheta[i])
+}
+}
+if m.n != tc.n {
+t.Fatalf("n = %d, want %d", m.n, tc.n)
+}
+})
+}
+}
+
+func TestCholesky(t *testing.T) {
+cases := []struct {
+name string
+a    [][]float64
+pd   bool
+}{
+{"identity", [][]float64{{1, 0}, {0, 1}}, true},
+{"2x2", [][]float64{{4, 2}, {2, 3}}, true},
+{"3x3", [][]float64{{25, 15, -5}, {15, 18, 0}, {-5, 0, 11}}, true},
+{"singular", [][]float64{{1, 1}, {1, 1}}, false},
+{"indefinite", [][]float64{{1, 2}, {2, 1}}, false},
+}
+for _, tc := range cases {
+t.Run(tc.name, func(t *testing.T) {
+l := cholesky(tc.a)
+if (l != nil) != tc.pd {
+t.Fatalf("cholesky returned %v, positive definite %v", l, tc.pd)
+}
+if l == nil {
+return
+}
+for i := range tc.a {
+for j := range tc.a {
+s := 0.0
+for k := range l {
+s += l[i][k] * l[j][k]
+}
+if math.Abs(s-tc.a[i][j]) > 1e-9 {
+t.Fatalf("(L L^T)[%d][%d] = %g, want %g", i, j, s, tc.a[i][j])
+}
+}
+}
+})
+}
+}
@@ -1,34 +1,239 @@
 package main
 
 import (
-"sync/atomic"
+"encoding/json"
+"net/http"
+"sort"
+"strings"
+"sync"
 "time"
+
+"github.com/prometheus/client_golang/prometheus"
+)
+
+type breakerState int
+
+const (
+breakerClosed breakerState = iota
+breakerHalfOpen
+breakerOpen
 )
 
+func (st breakerState) String() string {
+switch st {
+case breakerHalfOpen:
+return "half_open"
+case breakerOpen:
+return "open"
+}
+return "closed"
+}
+
+var (
+mBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_breaker_state",
+Help: "Circuit breaker state per key (0=closed, 1=half_open, 2=open)",
+}, []string{"key"})
+mBreakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_breaker_transitions_total",
+Help: "Circuit breaker state transitions per key",
+}, []string{"key", "from", "to"})
+)
+
+func init() {
+prometheus.MustRegister(mBreakerState, mBreakerTransitions)
+}
+
+// breakerLimits are shared by every breaker in a set so reloads apply at once.
+type breakerLimits struct {
+maxFails int           // consecutive failures that trip closed -> open
+cooldown time.Duration // open time before probing
+probes   int           // concurrent half-open probes, and successes needed to close
+}
+
+// circuitBreaker is closed -> open after maxFails consecutive failures, then
+// half-open after the cooldown, where up to `probes` calls go through; that
+// many successes close it again and any failure reopens it.
 type circuitBreaker struct {
-failBudget int32
-maxFails   int32
-openUntil  int64 // unix nano until which breaker is open
-cooldown   time.Duration
+key   string
+clock clock
+
+mu        sync.Mutex
+state     breakerState
+fails     int
+openUntil time.Time
+inFlight  int // half-open probes not yet reported
+probeOK   int // half-open successes so far
+}
+
+func (b *circuitBreaker) setLocked(to breakerState) {
+if b.state == to {
+return
+}
+mBreakerTransitions.WithLabelValues(b.key, b.state.String(), to.String()).Inc()
+mBreakerState.WithLabelValues(b.key).Set(float64(to))
+b.state = to
+b.fails, b.inFlight, b.probeOK = 0, 0, 0
+}
+
+// allow reports whether a call may go out; in half-open it hands out a probe permit.
+func (b *circuitBreaker) allow(l breakerLimits) bool {
+b.mu.Lock()
+defer b.mu.Unlock()
+if b.state == breakerOpen {
+if clockNow(b.clock).Before(b.openUntil) {
+return false
+}
+b.setLocked(breakerHalfOpen)
+}
+if b.state == breakerHalfOpen {
+if b.inFlight >= l.probes {
+return false
+}
+b.inFlight++
+}
+return true
+}
+
+func (b *circuitBreaker) onSuccess(l breakerLimits) {
+b.mu.Lock()
+defer b.mu.Unlock()
+switch b.state {
+case breakerClosed:
+b.fails = 0
+case breakerHalfOpen:
+if b.inFlight > 0 {
+b.inFlight--
+}
+b.probeOK++
+if b.probeOK >= l.probes {
+b.setLocked(breakerClosed)
+}
+}
+}
+
+// onFailure records a failed call and reports whether the breaker is open now.
+func (b *circuitBreaker) onFailure(l breakerLimits) bool {
+b.mu.Lock()
+defer b.mu.Unlock()
+switch b.state {
+case breakerClosed:
+b.fails++
+if b.fails >= l.maxFails {
+b.tripLocked(l)
+}
+case breakerHalfOpen:
+b.tripLocked(l)
+}
+return b.state == breakerOpen
+}
+
+func (b *circuitBreaker) tripLocked(l breakerLimits) {
+b.setLocked(breakerOpen)
+b.openUntil = clockNow(b.clock).Add(l.cooldown)
+}
+
+// breakerSet holds one breaker per predictor endpoint, or per endpoint and
+// action when perAction is set.
+type breakerSet struct {
+perAction bool
+clock     clock
+
+mu     sync.Mutex
+limits breakerLimits
+byKey  map[string]*circuitBreaker
+}
+
+func newBreakerSet(l breakerLimits, perAction bool, clk clock) *breakerSet {
+return &breakerSet{perAction: perAction, clock: clk, limits: l, byKey: make(map[string]*circuitBreaker)}
 }
 
-func newBreaker(maxFails int, cooldown time.Duration) *circuitBreaker {
-return &circuitBreaker{maxFails: int32(maxFails), cooldown: cooldown}
+func (bs *breakerSet) setLimits(l breakerLimits) {
+bs.mu.Lock()
+bs.limits = l
+bs.mu.Unlock()
 }
 
-func (b *circuitBreaker) allow() bool {
-until := time.Unix(0, atomic.LoadInt64(&b.openUntil))
-return time.Now().After(until)
+func (bs *breakerSet) currentLimits() breakerLimits {
+bs.mu.Lock()
+defer bs.mu.Unlock()
+return bs.limits
 }
 
-func (b *circuitBreaker) onSuccess() {
-atomic.StoreInt32(&b.failBudget, 0)
+// get returns the breaker for endpoint (and action) with the current limits.
+func (bs *breakerSet) get(endpoint, action string) (*circuitBreaker, breakerLimits) {
+key := endpoint
+if bs.perAction && action != "" {
+key = endpoint + "|" + action
+}
+bs.mu.Lock()
+defer bs.mu.Unlock()
+b, ok := bs.byKey[key]
+if !ok {
+b = &circuitBreaker{key: key, clock: bs.clock}
+bs.byKey[key] = b
+mBreakerState.WithLabelValues(key).Set(float64(breakerClosed))
+}
+return b, bs.limits
+}
+
+type breakerView struct {
+Key       string     `json:"key"`
+State     string     `json:"state"`
+Failures  int        `json:"consecutive_failures"`
+OpenUntil *time.Time `json:"open_until,omitempty"`
+InFlight  int        `json:"probes_in_flight"`
+ProbeOK   int        `json:"probe_successes"`
+}
+
+func (bs *breakerSet) snapshot() []breakerView {
+bs.mu.Lock()
+bks := make([]*circuitBreaker, 0, len(bs.byKey))
+for _, b := range bs.byKey {
+bks = append(bks, b)
+}
+bs.mu.Unlock()
+out := make([]breakerView, 0, len(bks))
+for _, b := range bks {
+b.mu.Lock()
+v := breakerView{Key: b.key, State: b.state.String(), Failures: b.fails, InFlight: b.inFlight, ProbeOK: b.probeOK}
+if b.state == breakerOpen {
+t := b.openUntil
+v.OpenUntil = &t
+}
+b.mu.Unlock()
+out = append(out, v)
+}
+sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+return out
+}
+
+// reset closes the breakers whose key starts with prefix ("" = all).
+func (bs *breakerSet) reset(prefix string) {
+bs.mu.Lock()
+defer bs.mu.Unlock()
+for k, b := range bs.byKey {
+if strings.HasPrefix(k, prefix) {
+b.mu.Lock()
+b.setLocked(breakerClosed)
+b.fails = 0
+b.mu.Unlock()
+}
+}
 }
 
-func (b *circuitBreaker) onFailure() {
-f := atomic.AddInt32(&b.failBudget, 1)
-if f >= b.maxFails {
-atomic.StoreInt32(&b.failBudget, 0)
-atomic.StoreInt64(&b.openUntil, time.Now().Add(b.cooldown).UnixNano())
+// registerBreakerHandlers exposes GET /breaker and POST /breaker/reset[?key=127.0.0.1:7001].
+func registerBreakerHandlers(bs *breakerSet) {
+http.HandleFunc("/breaker", func(w http.ResponseWriter, r *http.Request) {
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(bs.snapshot())
+})
+http.HandleFunc("/breaker/reset", func(w http.ResponseWriter, r *http.Request) {
+if r.Method != http.MethodPost {
+http.Error(w, "POST only", http.StatusMethodNotAllowed)
+return
 }
+bs.reset(r.URL.Query().Get("key"))
+w.WriteHeader(http.StatusNoContent)
+})
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+"testing"
+"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+l := breakerLimits{maxFails: 3, cooldown: time.Second, probes: 2}
+// steps: allow (want the permit result), ok, fail, wait (past the cooldown),
+// each followed by the expected state
+type step struct {
+op    string
+allow bool
+state breakerState
+}
+cases := []struct {
+name  string
+steps []step
+}{
+{"failures below the limit stay closed", []step{
+{op: "fail", state: breakerClosed},
+{op: "fail", state: breakerClosed},
+{op: "allow", allow: true, state: breakerClosed},
+}},
+{"a success resets the failure count", []step{
+{op: "fail", state: breakerClosed},
+{op: "fail", state: breakerClosed},
+{op: "ok", state: breakerClosed},
+{op: "fail", state: breakerClosed},
+{op: "fail", state: breakerClosed},
+}},
+{"maxFails consecutive failures open it", []step{
+{op: "fail", state: breakerClosed},
+{op: "fail", state: breakerClosed},
+{op: "fail", state: breakerOpen},
+{op: "allow", allow: false, state: breakerOpen},
+}},
+{"cooldown leads to half-open with limited probes", []step{
+{op: "fail"}, {op: "fail"}, {op: "fail", state: breakerOpen},
+{op: "wait", state: breakerOpen},
+{op: "allow", allow: true, state: breakerHalfOpen},
+{op: "allow", allow: true, state: breakerHalfOpen},
+{op: "allow", allow: false, state: breakerHalfOpen},
+}},
+{"enough probe successes close it", []step{
+{op: "fail"}, {op: "fail"}, {op: "fail", state: breakerOpen},
+{op: "wait", state: breakerOpen},
+{op: "allow", allow: true, state: breakerHalfOpen},
+{op: "ok", state: breakerHalfOpen},
+{op: "allow", allow: true, state: breakerHalfOpen},
+{op: "ok", state: breakerClosed},
+{op: "allow", allow: true, state: breakerClosed},
+}},
+{"a probe failure reopens it", []step{
+{op: "fail"}, {op: "fail"}, {op: "fail", state: breakerOpen},
+{op: "wait", state: breakerOpen},
+{op: "allow", allow: true, state: breakerHalfOpen},
+{op: "ok", state: breakerHalfOpen},
+{op: "allow", allow: true, state: breakerHalfOpen},
+{op: "fail", state: breakerOpen},
+{op: "allow", allow: false, state: breakerOpen},
+}},
+}
+for _, tc := range cases {
+t.Run(tc.name, func(t *testing.T) {
+clk := newManualClock(time.Unix(0, 0), time.Millisecond)
+b := &circuitBreaker{key: "test|" + tc.name, clock: clk}
+for i, s := range tc.steps {
+switch s.op {
+case "allow":
+if got := b.allow(l); got != s.allow {
+t.Fatalf("step %d: allow = %v, want %v", i, got, s.allow)
+}
+case "ok":
+b.onSuccess(l)
+case "fail":
+if open := b.onFailure(l); open != (s.state == breakerOpen) {
+t.Fatalf("step %d: onFailure reported open=%v", i, open)
+}
+case "wait":
+clk.advance(clk.Now().Add(l.cooldown))
+}
+if b.state != s.state {
+t.Fatalf("step %d (%s): state %v, want %v", i, s.op, b.state, s.state)
+}
+}
+})
+}
+}
+
+func TestBreakerSetKeys(t *testing.T) {
+cases := []struct {
+perAction bool
+same      bool
+}{
+{perAction: false, same: true},
+{perAction: true, same: false},
+}
+for _, tc := range cases {
+bs := newBreakerSet(breakerLimits{maxFails: 1, cooldown: time.Second, probes: 1}, tc.perAction, nil)
+a, _ := bs.get("pred:1", "edge1:low")
+b, _ := bs.get("pred:1", "edge1:med")
+if (a == b) != tc.same {
+t.Fatalf("perAction=%v: shared breaker %v, want %v", tc.perAction, a == b, tc.same)
+}
+}
+}
@@ -2,35 +2,75 @@ package main
 
 import (
 "bufio"
+"encoding/json"
 "net/http"
 "regexp"
+"sort"
 "strconv"
 "strings"
 "sync"
 "time"
+
+"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+mSiteFactor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_site_capacity_factor",
+Help: "Latency/cost multiplier applied to edge actions at each site",
+}, []string{"site"})
+mSiteStale = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_site_capacity_stale",
+Help: "1 when a site's operator metrics were missing for capacity.stale_scrapes scrapes",
+}, []string{"site"})
 )
 
-// CapPoller pulls csn_edges_up from a Prometheus /metrics endpoint
+func init() {
+prometheus.MustRegister(mSiteFactor, mSiteStale)
+}
+
+// capParams tune how operator metrics turn into capacity factors.
+type capParams struct {
+coef         float64 // per extra edge up: base = max(floor, 1 - coef*(edgesUp-1))
+floor        float64
+cpuCoef      float64 // site = base * (1 + cpuCoef*cpu + queueCoef*queue)
+queueCoef    float64
+staleScrapes int // scrapes a value may be missing before it is ignored
+}
+
+// siteCap is the last known state of one edge site.
+type siteCap struct {
+up      bool
+cpu     float64
+queue   float64
+seenSeq int // scrape sequence in which the site last appeared
+seen    time.Time
+}
+
+// CapPoller pulls csn_edges_up and the per-site csn_edge_up/csn_edge_cpu/
+// csn_edge_queue_depth{site="..."} gauges from a Prometheus /metrics endpoint
 type CapPoller struct {
 url       string
-coef      float64
-floor     float64
 client    *http.Client
 mu        sync.RWMutex
+params    capParams
 edgesUp   int
-lastFact  float64
+seq       int // scrape attempts
+okSeq     int // last successful scrape
+sites     map[string]*siteCap
 reGauge   *regexp.Regexp
+reSite    *regexp.Regexp
 }
 
-func NewCapPoller(url string, coef float64, floor float64) *CapPoller {
+func NewCapPoller(url string, params capParams) *CapPoller {
 return &CapPoller{
 url:    url,
-coef:   coef,
-        floor:  floor,
+params: params,
 client: &http.Client{Timeout: 1200 * time.Millisecond},
 reGauge: regexp.MustCompile(`^csn_edges_up\s+([0-9]+(?:\.[0-9]+)?)$`),
+reSite:  regexp.MustCompile(`^(csn_edge_up|csn_edge_cpu|csn_edge_queue_depth)\{[^}]*site="([^"]+)"[^}]*\}\s+(\S+)$`),
 edgesUp: 1,
-lastFact: 1.0,
+sites:   make(map[string]*siteCap),
 }
 }
 
@@ -45,39 +85,155 @@ p.tick()
 }
 
 func (p *CapPoller) tick() {
+p.mu.Lock()
+p.seq++
+seq := p.seq
+p.mu.Unlock()
+defer p.publish()
+
 resp, err := p.client.Get(p.url)
 if err != nil {
 return
 }
 defer resp.Body.Close()
+if resp.StatusCode != http.StatusOK {
+return
+}
 sc := bufio.NewScanner(resp.Body)
 val := 1
+type sample struct {
+up           *bool
+cpu, queue   *float64
+}
+seen := map[string]*sample{}
 for sc.Scan() {
 line := strings.TrimSpace(sc.Text())
-m := p.reGauge.FindStringSubmatch(line)
-if len(m) == 2 {
+if m := p.reGauge.FindStringSubmatch(line); len(m) == 2 {
 f, err := strconv.ParseFloat(m[1], 64)
 if err == nil && f >= 0 {
 val = int(f + 0.0001)
-break
 }
+continue
+}
+m := p.reSite.FindStringSubmatch(line)
+if len(m) != 4 {
+continue
+}
+f, err := strconv.ParseFloat(m[3], 64)
+if err != nil {
+continue
+}
+s := seen[m[2]]
+if s == nil {
+s = &sample{}
+seen[m[2]] = s
+}
+switch m[1] {
+case "csn_edge_up":
+up := f > 0
+s.up = &up
+case "csn_edge_cpu":
+s.cpu = &f
+case "csn_edge_queue_depth":
+s.queue = &f
 }
 }
-// compute factor = max(floor, 1 - coef*(edgesUp-1))
-f := 1.0 - p.coef*float64(val-1)
-if f < p.floor {
-f = p.floor
+if sc.Err() != nil {
+return
 }
+
 p.mu.Lock()
+defer p.mu.Unlock()
+p.okSeq = seq
 p.edgesUp = val
-p.lastFact = f
+now := time.Now()
+for name, s := range seen {
+st := p.sites[name]
+if st == nil {
+// a site that only reports load is assumed up
+st = &siteCap{up: true}
+p.sites[name] = st
+}
+if s.up != nil {
+st.up = *s.up
+}
+if s.cpu != nil {
+st.cpu = *s.cpu
+}
+if s.queue != nil {
+st.queue = *s.queue
+}
+st.seenSeq, st.seen = seq, now
+}
+}
+
+// publish refreshes the per-site gauges.
+func (p *CapPoller) publish() {
+p.mu.RLock()
+defer p.mu.RUnlock()
+for name, st := range p.sites {
+mSiteFactor.WithLabelValues(name).Set(p.siteFactorLocked(st))
+stale := 0.0
+if p.staleLocked(st.seenSeq) {
+stale = 1
+}
+mSiteStale.WithLabelValues(name).Set(stale)
+}
+}
+
+// SetParams changes the coefficients, floor and staleness limit; the next lookup uses them.
+func (p *CapPoller) SetParams(params capParams) {
+p.mu.Lock()
+p.params = params
 p.mu.Unlock()
 }
 
+func (p *CapPoller) staleLocked(seenSeq int) bool {
+return p.seq-seenSeq >= p.params.staleScrapes
+}
+
+// baseLocked is the fleet-wide factor from csn_edges_up; neutral when stale.
+func (p *CapPoller) baseLocked() float64 {
+if p.staleLocked(p.okSeq) {
+return 1.0
+}
+f := 1.0 - p.params.coef*float64(p.edgesUp-1)
+if f < p.params.floor {
+f = p.params.floor
+}
+return f
+}
+
+func (p *CapPoller) siteFactorLocked(st *siteCap) float64 {
+base := p.baseLocked()
+if st == nil || p.staleLocked(st.seenSeq) {
+return base
+}
+return base * (1 + p.params.cpuCoef*st.cpu + p.params.queueCoef*st.queue)
+}
+
+// Factor is the fleet-wide factor (no per-site load).
 func (p *CapPoller) Factor() float64 {
 p.mu.RLock()
 defer p.mu.RUnlock()
-return p.lastFact
+return p.baseLocked()
+}
+
+// SiteFactor is the multiplier for edge actions at site; sites without fresh
+// metrics get the fleet-wide factor.
+func (p *CapPoller) SiteFactor(site string) float64 {
+p.mu.RLock()
+defer p.mu.RUnlock()
+return p.siteFactorLocked(p.sites[site])
+}
+
+// SiteDown reports a site whose fresh metrics say it is down. Unknown or stale
+// sites are not considered down.
+func (p *CapPoller) SiteDown(site string) bool {
+p.mu.RLock()
+defer p.mu.RUnlock()
+st := p.sites[site]
+return st != nil && !st.up && !p.staleLocked(st.seenSeq)
 }
 
 func (p *CapPoller) Edges() int {
@@ -85,3 +241,39 @@ p.mu.RLock()
 defer p.mu.RUnlock()
 return p.edgesUp
 }
+
+type siteView struct {
+Site     string    `json:"site"`
+Up       bool      `json:"up"`
+CPU      float64   `json:"cpu"`
+Queue    float64   `json:"queue_depth"`
+Factor   float64   `json:"factor"`
+Stale    bool      `json:"stale"`
+LastSeen time.Time `json:"last_seen"`
+}
+
+type capacityView struct {
+EdgesUp    int        `json:"edges_up"`
+BaseFactor float64    `json:"base_factor"`
+Stale      bool       `json:"stale"`
+Sites      []siteView `json:"sites"`
+}
+
+func (p *CapPoller) snapshot() capacityView {
+p.mu.RLock()
+defer p.mu.RUnlock()
+v := capacityView{EdgesUp: p.edgesUp, BaseFactor: p.baseLocked(), Stale: p.staleLocked(p.okSeq)}
+for name, st := range p.sites {
+v.Sites = append(v.Sites, siteView{Site: name, Up: st.up, CPU: st.cpu, Queue: st.queue, Factor: p.siteFactorLocked(st), Stale: p.staleLocked(st.seenSeq), LastSeen: st.seen})
+}
+sort.Slice(v.Sites, func(i, j int) bool { return v.Sites[i].Site < v.Sites[j].Site })
+return v
+}
+
+// registerCapacityHandlers exposes GET /capacity with the per-site view.
+func registerCapacityHandlers(p *CapPoller) {
+http.HandleFunc("/capacity", func(w http.ResponseWriter, r *http.Request) {
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(p.snapshot())
+})
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+"context"
+crand "crypto/rand"
+"encoding/binary"
+"fmt"
+mrand "math/rand"
+"strconv"
+"sync"
+"time"
+
+"google.golang.org/grpc/metadata"
+)
+
+// clock is where the Decider reads time. A nil clock is the system clock.
+type clock interface {
+Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func clockNow(c clock) time.Time {
+if c == nil {
+return time.Now()
+}
+return c.Now()
+}
+
+// manualClock is the deterministic-mode clock: it only moves when a request
+// carries a timestamp (metadataTS) or, without one, by step per request, and
+// never goes backwards.
+type manualClock struct {
+step time.Duration
+
+mu  sync.Mutex
+now time.Time
+}
+
+func newManualClock(start time.Time, step time.Duration) *manualClock {
+return &manualClock{now: start, step: step}
+}
+
+func (c *manualClock) Now() time.Time {
+c.mu.Lock()
+defer c.mu.Unlock()
+return c.now
+}
+
+// advance moves to ts when it is later than now; a zero ts steps forward.
+func (c *manualClock) advance(ts time.Time) time.Time {
+c.mu.Lock()
+defer c.mu.Unlock()
+if ts.IsZero() {
+c.now = c.now.Add(c.step)
+} else if ts.After(c.now) {
+c.now = ts
+}
+return c.now
+}
+
+// metadataTS is the gRPC metadata key a replay uses to carry the recorded
+// request time (RFC 3339 or Unix milliseconds).
+const metadataTS = "csn-ts"
+
+func requestTime(md metadata.MD) (time.Time, error) {
+v := md.Get(metadataTS)
+if len(v) == 0 {
+return time.Time{}, nil
+}
+if ms, err := strconv.ParseInt(v[0], 10, 64); err == nil {
+return time.UnixMilli(ms).UTC(), nil
+}
+t, err := time.Parse(time.RFC3339Nano, v[0])
+if err != nil {
+return time.Time{}, fmt.Errorf("%s: %v", metadataTS, err)
+}
+return t, nil
+}
+
+// lockedRand is the Decider's random source, shared by request goroutines. A
+// nil *lockedRand falls back to the global math/rand.
+type lockedRand struct {
+mu sync.Mutex
+r  *mrand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+return &lockedRand{r: mrand.New(mrand.NewSource(seed))}
+}
+
+// cryptoSeed is the seed outside deterministic mode.
+func cryptoSeed() int64 {
+var b [8]byte
+if _, err := crand.Read(b[:]); err != nil {
+return time.Now().UnixNano()
+}
+return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+func (r *lockedRand) Float64() float64 {
+if r == nil {
+return mrand.Float64()
+}
+r.mu.Lock()
+defer r.mu.Unlock()
+return r.r.Float64()
+}
+
+func (r *lockedRand) NormFloat64() float64 {
+if r == nil {
+return mrand.NormFloat64()
+}
+r.mu.Lock()
+defer r.mu.Unlock()
+return r.r.NormFloat64()
+}
+
+func (r *lockedRand) Int63() int64 {
+if r == nil {
+return mrand.Int63()
+}
+r.mu.Lock()
+defer r.mu.Unlock()
+return r.r.Int63()
+}
+
+func (r *lockedRand) Intn(n int) int {
+if r == nil {
+return mrand.Intn(n)
+}
+r.mu.Lock()
+defer r.mu.Unlock()
+return r.r.Intn(n)
+}
+
+func (r *lockedRand) Read(b []byte) {
+if r == nil {
+_, _ = crand.Read(b)
+return
+}
+r.mu.Lock()
+defer r.mu.Unlock()
+_, _ = r.r.Read(b)
+}
+
+// clockFromConfig returns the system clock and a randomly seeded source, or in
+// deterministic mode a manual clock from deterministic.start and a source
+// seeded with deterministic.seed.
+func clockFromConfig(c *deciderConfig) (clock, *manualClock, *lockedRand, error) {
+if !c.Deterministic {
+return systemClock{}, nil, newLockedRand(cryptoSeed()), nil
+}
+start, err := time.Parse(time.RFC3339Nano, c.DetStart)
+if err != nil {
+return nil, nil, nil, fmt.Errorf("deterministic.start: %v", err)
+}
+mc := newManualClock(start, time.Duration(c.DetStepMs)*time.Millisecond)
+return mc, mc, newLockedRand(int64(c.DetSeed)), nil
+}
+
+func (s *deciderServer) now() time.Time { return clockNow(s.clock) }
+
+// syncClock runs at the top of Decide and ReportOutcome. In deterministic mode
+// it moves the manual clock to the request's csn-ts (or one step on) and runs
+// the periodic work that tickers do otherwise, so replaying a request stream
+// sequentially gives the same decisions.
+func (s *deciderServer) syncClock(ctx context.Context) error {
+if s.manual == nil {
+return nil
+}
+md, _ := metadata.FromIncomingContext(ctx)
+ts, err := requestTime(md)
+if err != nil {
+return err
+}
+now := s.manual.advance(ts)
+s.mu.Lock()
+if s.lastGovern.IsZero() {
+s.lastGovern = now
+}
+for !s.lastGovern.Add(governEvery).After(now) {
+s.governLocked()
+s.lastGovern = s.lastGovern.Add(governEvery)
+}
+s.mu.Unlock()
+s.reapExpired(now)
+return nil
+}
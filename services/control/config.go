@@ -0,0 +1,1013 @@
+package main
+
+import (
+"encoding/json"
+"fmt"
+"log"
+"math"
+"net/http"
+"os"
+"os/signal"
+"path/filepath"
+"reflect"
+"sort"
+"strconv"
+"strings"
+"sync"
+"syscall"
+"time"
+
+"github.com/prometheus/client_golang/prometheus"
+yaml "go.yaml.in/yaml/v2"
+
+"github.com/mulat/csn/catalog"
+)
+
+var mConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_config_reloads_total",
+Help: "Decider config reloads by result (ok|error)",
+}, []string{"result"})
+
+func init() {
+prometheus.MustRegister(mConfigReloads)
+}
+
+// deciderConfig holds every Decider knob. Values come from built-in defaults,
+// then the -config file (YAML or JSON), then environment overrides.
+type deciderConfig struct {
+PredictorAddr string
+CatalogPath   string
+Listen        string
+MetricsListen string
+
+LambdaEnergy  float64
+AlphaSLOBase  float64
+ExploreStdCap float64
+Epsilon       float64
+UseConformal  bool
+PropSamples   int
+
+EwmaAlpha   float64
+FairGammaMs float64
+
+TargetEps       float64
+WinSize         int
+Eta             float64
+UpdateEveryMs   int
+SLOClasses      map[string]sloClassSpec
+SLOAssign       map[string]string
+SLODefaultClass string
+
+BreakerMaxFails   int
+BreakerCooldownMs int
+BreakerProbes     int
+BreakerPerAction  bool
+
+QuotaRate    float64
+QuotaBurst   float64
+QuotaTenants map[string]quotaLimit
+QuotaDeny    string
+
+CapMetricsURL string
+CapEdgeCoef   float64
+CapFloor      float64
+CapCPUCoef    float64
+CapQueueCoef  float64
+CapStale      int
+
+OutcomeTimeoutMs int
+
+Policy         string
+PolicyTenants  map[string]string
+ShadowPolicies map[string]string
+
+ExperimentName  string
+ExperimentUnit  string
+ExperimentArms  map[string]string
+ExperimentSplit map[string]float64
+
+BanditMode  string
+BanditAlpha float64
+BanditV     float64
+
+AuditPath  string
+AuditMaxMB int
+AuditKeep  int
+
+CacheSize  int
+CacheTTLMs int
+CacheQuant map[string]float64
+
+TelemetryURL       string
+TelemetryQueue     int
+TelemetryBatch     int
+TelemetryFlushMs   int
+TelemetryRetries   int
+TelemetryBackoffMs int
+TelemetrySpill     string
+TelemetrySpillMB   int
+
+DriftBaseline   string
+DriftWindow     int
+DriftBins       int
+DriftMinSamples int
+DriftPSI        float64
+DriftKSAlpha    float64
+DriftEvalMs     int
+
+RetrainHook       string
+RetrainSustainMs  int
+RetrainCooldownMs int
+RetrainTimeoutMs  int
+RetrainMinDrifted int
+RetrainViolRate   float64
+
+Deterministic bool
+DetSeed       int
+DetStart      string
+DetStepMs     int
+}
+
+func defaultConfig() *deciderConfig {
+return &deciderConfig{
+PredictorAddr: "127.0.0.1:7001",
+Listen:        ":7002",
+MetricsListen: ":9102",
+
+LambdaEnergy:  80.0,
+AlphaSLOBase:  2.0,
+ExploreStdCap: 8.0,
+Epsilon:       0.10,
+UseConformal:  true,
+PropSamples:   200,
+
+EwmaAlpha:   0.3,
+FairGammaMs: 10.0,
+
+TargetEps:       0.10,
+WinSize:         50,
+Eta:             5.0,
+UpdateEveryMs:   5000,
+SLOClasses:      map[string]sloClassSpec{},
+SLOAssign:       map[string]string{},
+SLODefaultClass: defaultSLOClass,
+
+// 5 consecutive failures -> 10s open
+BreakerMaxFails:   5,
+BreakerCooldownMs: 10000,
+BreakerProbes:     1,
+BreakerPerAction:  false,
+
+// 50 rps, burst 100 per-tenant
+QuotaRate:    50.0,
+QuotaBurst:   100.0,
+QuotaTenants: map[string]quotaLimit{},
+QuotaDeny:    "reply",
+
+CapMetricsURL: "http://127.0.0.1:9103/metrics",
+CapEdgeCoef:   0.15,
+CapFloor:      0.3,
+CapCPUCoef:    0.5,
+CapQueueCoef:  0.05,
+CapStale:      3,
+
+OutcomeTimeoutMs: 3000,
+
+Policy:         "csn",
+PolicyTenants:  map[string]string{},
+ShadowPolicies: map[string]string{},
+
+ExperimentName:  "off",
+ExperimentUnit:  "tenant",
+ExperimentArms:  map[string]string{},
+ExperimentSplit: map[string]float64{},
+
+BanditMode:  "lints",
+BanditAlpha: 4.0,
+BanditV:     4.0,
+
+AuditPath:  "experiments/decisions.jsonl",
+AuditMaxMB: 64,
+AuditKeep:  5,
+
+CacheSize:  4096,
+CacheTTLMs: 2000,
+CacheQuant: map[string]float64{},
+
+TelemetryURL:       "http://127.0.0.1:9105/ingest",
+TelemetryQueue:     4096,
+TelemetryBatch:     50,
+TelemetryFlushMs:   500,
+TelemetryRetries:   3,
+TelemetryBackoffMs: 200,
+TelemetrySpill:     "experiments/telemetry_spill.jsonl",
+TelemetrySpillMB:   64,
+
+DriftBaseline:   "experiments/telemetry.csv",
+DriftWindow:     500,
+DriftBins:       10,
+DriftMinSamples: 100,
+DriftPSI:        0.2,
+DriftKSAlpha:    0.01,
+DriftEvalMs:     5000,
+
+RetrainHook:       "off",
+RetrainSustainMs:  60000,
+RetrainCooldownMs: 1800000,
+RetrainTimeoutMs:  600000,
+RetrainMinDrifted: 1,
+RetrainViolRate:   0.3,
+
+Deterministic: false,
+DetSeed:       1,
+DetStart:      "2025-01-01T00:00:00Z",
+DetStepMs:     100,
+}
+}
+
+// setting describes one config key: where it lives in the file, which env
+// var overrides it, its type/range, and whether it can change without a restart.
+type setting struct {
+key   string
+env   string
+ptr   func(c *deciderConfig) interface{}
+min   float64
+max   float64
+enum  []string
+hot   bool
+}
+
+var noMax = math.Inf(1)
+
+var configSchema = []setting{
+{key: "predictor_addr", env: "CSN_PREDICTOR_ADDR", ptr: func(c *deciderConfig) interface{} { return &c.PredictorAddr }},
+{key: "catalog.path", env: "CSN_CATALOG", ptr: func(c *deciderConfig) interface{} { return &c.CatalogPath }},
+{key: "listen", env: "CSN_LISTEN", ptr: func(c *deciderConfig) interface{} { return &c.Listen }},
+{key: "metrics_listen", env: "CSN_METRICS_LISTEN", ptr: func(c *deciderConfig) interface{} { return &c.MetricsListen }},
+
+{key: "objective.lambda_energy", ptr: func(c *deciderConfig) interface{} { return &c.LambdaEnergy }, max: noMax, hot: true},
+{key: "objective.alpha_slo_base", ptr: func(c *deciderConfig) interface{} { return &c.AlphaSLOBase }, max: noMax, hot: true},
+{key: "objective.explore_std_cap", ptr: func(c *deciderConfig) interface{} { return &c.ExploreStdCap }, max: noMax, hot: true},
+{key: "objective.epsilon", ptr: func(c *deciderConfig) interface{} { return &c.Epsilon }, max: 1, hot: true},
+{key: "objective.use_conformal", env: "CSN_USE_CONFORMAL", ptr: func(c *deciderConfig) interface{} { return &c.UseConformal }, hot: true},
+{key: "objective.propensity_samples", ptr: func(c *deciderConfig) interface{} { return &c.PropSamples }, max: 10000, hot: true},
+
+{key: "fairness.ewma_alpha", ptr: func(c *deciderConfig) interface{} { return &c.EwmaAlpha }, max: 1, hot: true},
+{key: "fairness.gamma_ms", ptr: func(c *deciderConfig) interface{} { return &c.FairGammaMs }, max: noMax, hot: true},
+
+{key: "slo.target_eps", ptr: func(c *deciderConfig) interface{} { return &c.TargetEps }, max: 1, hot: true},
+{key: "slo.win_size", ptr: func(c *deciderConfig) interface{} { return &c.WinSize }, min: 1, max: 1e6, hot: true},
+{key: "slo.eta", ptr: func(c *deciderConfig) interface{} { return &c.Eta }, max: noMax, hot: true},
+{key: "slo.update_every_ms", ptr: func(c *deciderConfig) interface{} { return &c.UpdateEveryMs }, min: 1, max: noMax, hot: true},
+{key: "slo.classes", ptr: func(c *deciderConfig) interface{} { return &c.SLOClasses }, hot: true},
+{key: "slo.assign", env: "CSN_SLO_CLASS_MAP", ptr: func(c *deciderConfig) interface{} { return &c.SLOAssign }, hot: true},
+{key: "slo.default_class", env: "CSN_SLO_DEFAULT_CLASS", ptr: func(c *deciderConfig) interface{} { return &c.SLODefaultClass }, hot: true},
+
+{key: "breaker.max_fails", ptr: func(c *deciderConfig) interface{} { return &c.BreakerMaxFails }, min: 1, max: noMax, hot: true},
+{key: "breaker.cooldown_ms", ptr: func(c *deciderConfig) interface{} { return &c.BreakerCooldownMs }, min: 1, max: noMax, hot: true},
+{key: "breaker.half_open_probes", ptr: func(c *deciderConfig) interface{} { return &c.BreakerProbes }, min: 1, max: noMax, hot: true},
+{key: "breaker.per_action", env: "CSN_BREAKER_PER_ACTION", ptr: func(c *deciderConfig) interface{} { return &c.BreakerPerAction }},
+
+{key: "quota.rate", ptr: func(c *deciderConfig) interface{} { return &c.QuotaRate }, max: noMax, hot: true},
+{key: "quota.burst", ptr: func(c *deciderConfig) interface{} { return &c.QuotaBurst }, max: noMax, hot: true},
+{key: "quota.tenants", env: "CSN_QUOTA_MAP", ptr: func(c *deciderConfig) interface{} { return &c.QuotaTenants }, hot: true},
+{key: "quota.deny", env: "CSN_QUOTA_DENY", ptr: func(c *deciderConfig) interface{} { return &c.QuotaDeny }, enum: []string{"reply", "status"}, hot: true},
+
+{key: "capacity.metrics_url", env: "OP_METRICS_URL", ptr: func(c *deciderConfig) interface{} { return &c.CapMetricsURL }},
+{key: "capacity.edge_coef", env: "CSN_EDGE_CAP_COEF", ptr: func(c *deciderConfig) interface{} { return &c.CapEdgeCoef }, max: noMax, hot: true},
+{key: "capacity.floor", ptr: func(c *deciderConfig) interface{} { return &c.CapFloor }, max: 1, hot: true},
+{key: "capacity.cpu_coef", ptr: func(c *deciderConfig) interface{} { return &c.CapCPUCoef }, max: noMax, hot: true},
+{key: "capacity.queue_coef", ptr: func(c *deciderConfig) interface{} { return &c.CapQueueCoef }, max: noMax, hot: true},
+{key: "capacity.stale_scrapes", ptr: func(c *deciderConfig) interface{} { return &c.CapStale }, min: 1, max: noMax, hot: true},
+
+{key: "outcome.timeout_ms", env: "CSN_OUTCOME_TIMEOUT_MS", ptr: func(c *deciderConfig) interface{} { return &c.OutcomeTimeoutMs }, min: 1, max: noMax, hot: true},
+
+{key: "policy.default", env: "CSN_POLICY", ptr: func(c *deciderConfig) interface{} { return &c.Policy }, hot: true},
+{key: "policy.tenants", env: "CSN_POLICY_MAP", ptr: func(c *deciderConfig) interface{} { return &c.PolicyTenants }, hot: true},
+{key: "policy.shadow", env: "CSN_SHADOW_POLICIES", ptr: func(c *deciderConfig) interface{} { return &c.ShadowPolicies }, hot: true},
+
+{key: "experiment.name", env: "CSN_EXPERIMENT", ptr: func(c *deciderConfig) interface{} { return &c.ExperimentName }, hot: true},
+{key: "experiment.unit", env: "CSN_EXPERIMENT_UNIT", ptr: func(c *deciderConfig) interface{} { return &c.ExperimentUnit }, enum: []string{"tenant", "app", "request"}, hot: true},
+{key: "experiment.arms", env: "CSN_EXPERIMENT_ARMS", ptr: func(c *deciderConfig) interface{} { return &c.ExperimentArms }, hot: true},
+{key: "experiment.split", env: "CSN_EXPERIMENT_SPLIT", ptr: func(c *deciderConfig) interface{} { return &c.ExperimentSplit }, hot: true},
+
+{key: "bandit.mode", env: "CSN_BANDIT", ptr: func(c *deciderConfig) interface{} { return &c.BanditMode }, enum: []string{"lints", "linucb", "off"}, hot: true},
+{key: "bandit.alpha", env: "CSN_BANDIT_ALPHA", ptr: func(c *deciderConfig) interface{} { return &c.BanditAlpha }, max: noMax, hot: true},
+{key: "bandit.v", env: "CSN_BANDIT_V", ptr: func(c *deciderConfig) interface{} { return &c.BanditV }, max: noMax, hot: true},
+
+{key: "audit.path", env: "CSN_AUDIT_LOG", ptr: func(c *deciderConfig) interface{} { return &c.AuditPath }},
+{key: "audit.max_mb", env: "CSN_AUDIT_MAX_MB", ptr: func(c *deciderConfig) interface{} { return &c.AuditMaxMB }, min: 1, max: noMax},
+{key: "audit.keep", env: "CSN_AUDIT_KEEP", ptr: func(c *deciderConfig) interface{} { return &c.AuditKeep }, max: 1000},
+
+{key: "predcache.size", env: "CSN_PREDCACHE_SIZE", ptr: func(c *deciderConfig) interface{} { return &c.CacheSize }, max: noMax, hot: true},
+{key: "predcache.ttl_ms", env: "CSN_PREDCACHE_TTL_MS", ptr: func(c *deciderConfig) interface{} { return &c.CacheTTLMs }, min: 1, max: noMax, hot: true},
+{key: "predcache.quant", env: "CSN_PREDCACHE_QUANT", ptr: func(c *deciderConfig) interface{} { return &c.CacheQuant }, hot: true},
+
+{key: "telemetry.url", env: "CSN_SENSE_URL", ptr: func(c *deciderConfig) interface{} { return &c.TelemetryURL }},
+{key: "telemetry.queue_size", ptr: func(c *deciderConfig) interface{} { return &c.TelemetryQueue }, min: 1, max: 1e6},
+{key: "telemetry.batch_size", ptr: func(c *deciderConfig) interface{} { return &c.TelemetryBatch }, min: 1, max: 10000},
+{key: "telemetry.flush_ms", ptr: func(c *deciderConfig) interface{} { return &c.TelemetryFlushMs }, min: 1, max: noMax},
+{key: "telemetry.max_retries", ptr: func(c *deciderConfig) interface{} { return &c.TelemetryRetries }, max: 100},
+{key: "telemetry.backoff_ms", ptr: func(c *deciderConfig) interface{} { return &c.TelemetryBackoffMs }, min: 1, max: noMax},
+{key: "telemetry.spill_path", env: "CSN_TELEMETRY_SPILL", ptr: func(c *deciderConfig) interface{} { return &c.TelemetrySpill }},
+{key: "telemetry.spill_max_mb", ptr: func(c *deciderConfig) interface{} { return &c.TelemetrySpillMB }, min: 1, max: noMax},
+
+{key: "drift.baseline_path", env: "CSN_DRIFT_BASELINE", ptr: func(c *deciderConfig) interface{} { return &c.DriftBaseline }},
+{key: "drift.window", ptr: func(c *deciderConfig) interface{} { return &c.DriftWindow }, min: 10, max: 1e6, hot: true},
+{key: "drift.bins", ptr: func(c *deciderConfig) interface{} { return &c.DriftBins }, min: 2, max: 100, hot: true},
+{key: "drift.min_samples", ptr: func(c *deciderConfig) interface{} { return &c.DriftMinSamples }, min: 2, max: noMax, hot: true},
+{key: "drift.psi_threshold", ptr: func(c *deciderConfig) interface{} { return &c.DriftPSI }, max: noMax, hot: true},
+{key: "drift.ks_alpha", ptr: func(c *deciderConfig) interface{} { return &c.DriftKSAlpha }, max: 1, hot: true},
+{key: "drift.eval_ms", ptr: func(c *deciderConfig) interface{} { return &c.DriftEvalMs }, min: 100, max: noMax},
+
+{key: "retrain.hook", env: "CSN_RETRAIN_HOOK", ptr: func(c *deciderConfig) interface{} { return &c.RetrainHook }, hot: true},
+{key: "retrain.sustain_ms", ptr: func(c *deciderConfig) interface{} { return &c.RetrainSustainMs }, max: noMax, hot: true},
+{key: "retrain.cooldown_ms", ptr: func(c *deciderConfig) interface{} { return &c.RetrainCooldownMs }, max: noMax, hot: true},
+{key: "retrain.timeout_ms", ptr: func(c *deciderConfig) interface{} { return &c.RetrainTimeoutMs }, min: 1, max: noMax, hot: true},
+{key: "retrain.min_drifted", ptr: func(c *deciderConfig) interface{} { return &c.RetrainMinDrifted }, max: noMax, hot: true},
+{key: "retrain.viol_rate", ptr: func(c *deciderConfig) interface{} { return &c.RetrainViolRate }, max: 1, hot: true},
+
+{key: "deterministic.enabled", env: "CSN_DETERMINISTIC", ptr: func(c *deciderConfig) interface{} { return &c.Deterministic }},
+{key: "deterministic.seed", env: "CSN_SEED", ptr: func(c *deciderConfig) interface{} { return &c.DetSeed }, min: math.MinInt64, max: math.MaxInt64},
+{key: "deterministic.start", ptr: func(c *deciderConfig) interface{} { return &c.DetStart }},
+{key: "deterministic.step_ms", ptr: func(c *deciderConfig) interface{} { return &c.DetStepMs }, min: 1, max: noMax},
+}
+
+func lookupSetting(key string) *setting {
+for i := range configSchema {
+if configSchema[i].key == key {
+return &configSchema[i]
+}
+}
+return nil
+}
+
+// normalize turns YAML's map[interface{}]interface{} into map[string]interface{}.
+func normalize(v interface{}) interface{} {
+switch t := v.(type) {
+case map[interface{}]interface{}:
+m := make(map[string]interface{}, len(t))
+for k, x := range t {
+m[fmt.Sprint(k)] = normalize(x)
+}
+return m
+case map[string]interface{}:
+for k, x := range t {
+t[k] = normalize(x)
+}
+return t
+case []interface{}:
+for i, x := range t {
+t[i] = normalize(x)
+}
+}
+return v
+}
+
+// flatten walks the document into dotted keys, stopping at map-typed settings.
+func flatten(prefix string, v interface{}, out map[string]interface{}) {
+m, ok := v.(map[string]interface{})
+if !ok || (prefix != "" && isMapSetting(prefix)) {
+out[prefix] = v
+return
+}
+for k, x := range m {
+key := k
+if prefix != "" {
+key = prefix + "." + k
+}
+flatten(key, x, out)
+}
+}
+
+func isMapSetting(key string) bool {
+st := lookupSetting(key)
+if st == nil {
+return false
+}
+switch st.ptr(&deciderConfig{}).(type) {
+case *map[string]string, *map[string]float64, *map[string]sloClassSpec, *map[string]quotaLimit:
+return true
+}
+return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+switch n := v.(type) {
+case float64:
+return n, true
+case int:
+return float64(n), true
+case int64:
+return float64(n), true
+}
+return 0, false
+}
+
+// assign stores a decoded file value (or a raw env string) into the setting.
+func (st *setting) assign(c *deciderConfig, v interface{}) error {
+if s, ok := v.(string); ok {
+parsed, err := st.parseString(c, s)
+if err != nil {
+return err
+}
+v = parsed
+}
+switch p := st.ptr(c).(type) {
+case *string:
+s, ok := v.(string)
+if !ok {
+return fmt.Errorf("%s: want string, got %T", st.key, v)
+}
+if len(st.enum) > 0 && !contains(st.enum, s) {
+return fmt.Errorf("%s: %q is not one of %s", st.key, s, strings.Join(st.enum, "|"))
+}
+*p = s
+case *bool:
+b, ok := v.(bool)
+if !ok {
+return fmt.Errorf("%s: want bool, got %T", st.key, v)
+}
+*p = b
+case *float64:
+f, ok := toFloat(v)
+if !ok {
+return fmt.Errorf("%s: want number, got %T", st.key, v)
+}
+if err := st.checkRange(f); err != nil {
+return err
+}
+*p = f
+case *int:
+f, ok := toFloat(v)
+if !ok || f != math.Trunc(f) {
+return fmt.Errorf("%s: want integer, got %v", st.key, v)
+}
+if err := st.checkRange(f); err != nil {
+return err
+}
+*p = int(f)
+case *map[string]string:
+m, ok := v.(map[string]interface{})
+if !ok {
+return fmt.Errorf("%s: want mapping, got %T", st.key, v)
+}
+out := make(map[string]string, len(m))
+for k, x := range m {
+s, ok := x.(string)
+if !ok {
+return fmt.Errorf("%s.%s: want string, got %T", st.key, k, x)
+}
+out[k] = s
+}
+*p = out
+case *map[string]float64:
+m, ok := v.(map[string]interface{})
+if !ok {
+return fmt.Errorf("%s: want mapping, got %T", st.key, v)
+}
+out := make(map[string]float64, len(m))
+for k, x := range m {
+f, ok := toFloat(x)
+if !ok || f < 0 {
+return fmt.Errorf("%s.%s: want non-negative number, got %v", st.key, k, x)
+}
+out[k] = f
+}
+*p = out
+case *map[string]sloClassSpec:
+m, ok := v.(map[string]interface{})
+if !ok {
+return fmt.Errorf("%s: want mapping, got %T", st.key, v)
+}
+out := make(map[string]sloClassSpec, len(m))
+for k, x := range m {
+spec, err := decodeSLOClass(x)
+if err != nil {
+return fmt.Errorf("%s.%s: %v", st.key, k, err)
+}
+out[k] = spec
+}
+*p = out
+case *map[string]quotaLimit:
+m, ok := v.(map[string]interface{})
+if !ok {
+return fmt.Errorf("%s: want mapping, got %T", st.key, v)
+}
+out := make(map[string]quotaLimit, len(m))
+for k, x := range m {
+l, err := decodeQuotaLimit(x)
+if err != nil {
+return fmt.Errorf("%s.%s: %v", st.key, k, err)
+}
+out[k] = l
+}
+*p = out
+}
+return nil
+}
+
+// decodeQuotaLimit reads {rate, burst} or the env form "rate/burst".
+func decodeQuotaLimit(v interface{}) (quotaLimit, error) {
+var l quotaLimit
+if s, ok := v.(string); ok {
+parts := strings.Split(s, "/")
+if len(parts) != 2 {
+return l, fmt.Errorf("bad limit %q (want rate/burst)", s)
+}
+r, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+b, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+if err1 != nil || err2 != nil {
+return l, fmt.Errorf("bad limit %q (want rate/burst)", s)
+}
+l = quotaLimit{Rate: r, Burst: b}
+} else {
+m, ok := v.(map[string]interface{})
+if !ok {
+return l, fmt.Errorf("want mapping, got %T", v)
+}
+seen := 0
+for k, x := range m {
+f, ok := toFloat(x)
+if !ok {
+return l, fmt.Errorf("%s: want number, got %T", k, x)
+}
+switch k {
+case "rate":
+l.Rate = f
+case "burst":
+l.Burst = f
+default:
+return l, fmt.Errorf("unknown key %q", k)
+}
+seen++
+}
+if seen != 2 {
+return l, fmt.Errorf("both rate and burst are required")
+}
+}
+if l.Rate < 0 || l.Burst < 1 {
+return l, fmt.Errorf("rate must be >= 0 and burst >= 1")
+}
+return l, nil
+}
+
+// decodeSLOClass reads {target_eps, win_size, eta}; missing fields stay nil.
+func decodeSLOClass(v interface{}) (sloClassSpec, error) {
+var spec sloClassSpec
+m, ok := v.(map[string]interface{})
+if !ok {
+return spec, fmt.Errorf("want mapping, got %T", v)
+}
+for k, x := range m {
+f, ok := toFloat(x)
+if !ok {
+return spec, fmt.Errorf("%s: want number, got %T", k, x)
+}
+switch k {
+case "target_eps":
+spec.TargetEps = &f
+case "eta":
+spec.Eta = &f
+case "win_size":
+if f != math.Trunc(f) {
+return spec, fmt.Errorf("win_size: want integer, got %v", f)
+}
+n := int(f)
+spec.WinSize = &n
+default:
+return spec, fmt.Errorf("unknown key %q", k)
+}
+}
+return spec, nil
+}
+
+// parseString converts an env/string value to the setting's type; map
+// settings take "k=v,k=v".
+func (st *setting) parseString(c *deciderConfig, s string) (interface{}, error) {
+s = strings.TrimSpace(s)
+switch st.ptr(c).(type) {
+case *bool:
+switch strings.ToLower(s) {
+case "1", "true", "on", "yes":
+return true, nil
+case "0", "false", "off", "no":
+return false, nil
+}
+return nil, fmt.Errorf("%s: bad bool %q", st.key, s)
+case *float64, *int:
+f, err := strconv.ParseFloat(s, 64)
+if err != nil {
+return nil, fmt.Errorf("%s: bad number %q", st.key, s)
+}
+return f, nil
+case *map[string]string, *map[string]float64, *map[string]quotaLimit:
+m := map[string]interface{}{}
+for _, kv := range strings.Split(s, ",") {
+kv = strings.TrimSpace(kv)
+if kv == "" {
+continue
+}
+i := strings.Index(kv, "=")
+if i <= 0 {
+return nil, fmt.Errorf("%s: bad entry %q (want key=value)", st.key, kv)
+}
+k, v := strings.TrimSpace(kv[:i]), strings.TrimSpace(kv[i+1:])
+if _, isNum := st.ptr(c).(*map[string]float64); isNum {
+f, err := strconv.ParseFloat(v, 64)
+if err != nil {
+return nil, fmt.Errorf("%s: bad number in %q", st.key, kv)
+}
+m[k] = f
+} else {
+m[k] = v
+}
+}
+return m, nil
+}
+return s, nil
+}
+
+func (st *setting) checkRange(f float64) error {
+if f < st.min || f > st.max {
+return fmt.Errorf("%s: %v out of range [%v, %v]", st.key, f, st.min, st.max)
+}
+return nil
+}
+
+func contains(list []string, s string) bool {
+for _, x := range list {
+if x == s {
+return true
+}
+}
+return false
+}
+
+// validate checks constraints that span keys or need more than a range.
+func (c *deciderConfig) validate() error {
+if c.QuotaBurst < 1 {
+return fmt.Errorf("quota.burst: must be >= 1")
+}
+if _, err := resolveSLOClasses(c); err != nil {
+return err
+}
+if _, err := quantFromConfig(c.CacheQuant); err != nil {
+return fmt.Errorf("predcache.quant: %v", err)
+}
+if err := checkRetrainHook(c.RetrainHook); err != nil {
+return fmt.Errorf("retrain.hook: %v", err)
+}
+if _, err := time.Parse(time.RFC3339Nano, c.DetStart); err != nil {
+return fmt.Errorf("deterministic.start: %v", err)
+}
+if c.DriftMinSamples > c.DriftWindow {
+return fmt.Errorf("drift.min_samples: %d exceeds drift.window %d", c.DriftMinSamples, c.DriftWindow)
+}
+cat, err := loadCatalog(c.CatalogPath)
+if err != nil {
+return fmt.Errorf("catalog.path: %v", err)
+}
+probe := &deciderServer{}
+if _, err := probe.buildPolicies(c.Policy, joinPairs(c.PolicyTenants)); err != nil {
+return fmt.Errorf("policy: %v", err)
+}
+for who, name := range c.PolicyTenants {
+if a := strings.TrimPrefix(name, "static:"); a != name {
+if _, ok := cat.Lookup(a); !ok {
+return fmt.Errorf("policy.tenants.%s: unknown action %q", who, a)
+}
+}
+}
+if a := strings.TrimPrefix(c.Policy, "static:"); a != c.Policy {
+if _, ok := cat.Lookup(a); !ok {
+return fmt.Errorf("policy.default: unknown action %q", a)
+}
+}
+shadows, err := probe.buildShadows(c.ShadowPolicies)
+if err != nil {
+return fmt.Errorf("policy.shadow: %v", err)
+}
+if err := checkVariantActions(cat, "policy.shadow", shadows); err != nil {
+return err
+}
+exp, err := probe.experimentFromConfig(c)
+if err != nil {
+return err
+}
+if exp != nil {
+arms := make([]*policyVariant, len(exp.arms))
+for i, a := range exp.arms {
+arms[i] = a.v
+}
+if err := checkVariantActions(cat, "experiment.arms", arms); err != nil {
+return err
+}
+}
+return nil
+}
+
+// checkVariantActions rejects static variants whose action is not in the catalog.
+func checkVariantActions(cat *catalog.Catalog, key string, vs []*policyVariant) error {
+for _, v := range vs {
+if p, ok := v.pol.(staticPolicy); ok {
+if _, ok := cat.Lookup(p.action); !ok {
+return fmt.Errorf("%s.%s: unknown action %q", key, v.name, p.action)
+}
+}
+}
+return nil
+}
+
+// loadConfig builds the effective configuration and records where each key came from.
+func loadConfig(path string) (*deciderConfig, map[string]string, error) {
+c := defaultConfig()
+src := make(map[string]string, len(configSchema))
+for _, st := range configSchema {
+src[st.key] = "default"
+}
+if path != "" {
+raw, err := os.ReadFile(path)
+if err != nil {
+return nil, nil, err
+}
+var doc interface{}
+switch strings.ToLower(filepath.Ext(path)) {
+case ".yaml", ".yml":
+err = yaml.Unmarshal(raw, &doc)
+default:
+err = json.Unmarshal(raw, &doc)
+}
+if err != nil {
+return nil, nil, fmt.Errorf("%s: %v", path, err)
+}
+flat := map[string]interface{}{}
+if doc != nil {
+flatten("", normalize(doc), flat)
+}
+var unknown []string
+for k := range flat {
+if lookupSetting(k) == nil {
+unknown = append(unknown, k)
+}
+}
+if len(unknown) > 0 {
+sort.Strings(unknown)
+return nil, nil, fmt.Errorf("%s: unknown keys: %s", path, strings.Join(unknown, ", "))
+}
+for k, v := range flat {
+st := lookupSetting(k)
+if err := st.assign(c, v); err != nil {
+return nil, nil, fmt.Errorf("%s: %v", path, err)
+}
+src[k] = "file:" + path
+}
+}
+for i := range configSchema {
+st := &configSchema[i]
+if st.env == "" {
+continue
+}
+v, ok := os.LookupEnv(st.env)
+if !ok || strings.TrimSpace(v) == "" {
+continue
+}
+if err := st.assign(c, v); err != nil {
+return nil, nil, fmt.Errorf("env %s: %v", st.env, err)
+}
+src[st.key] = "env:" + st.env
+}
+if err := c.validate(); err != nil {
+return nil, nil, err
+}
+return c, src, nil
+}
+
+type configValue struct {
+Value  interface{} `json:"value"`
+Source string      `json:"source"`
+Hot    bool        `json:"hot_reload"`
+}
+
+// describe renders the config as key -> {value, source, hot_reload}.
+func describeConfig(c *deciderConfig, src map[string]string) map[string]configValue {
+out := make(map[string]configValue, len(configSchema))
+for _, st := range configSchema {
+var v interface{}
+switch p := st.ptr(c).(type) {
+case *string:
+v = *p
+case *bool:
+v = *p
+case *float64:
+v = *p
+case *int:
+v = *p
+case *map[string]string:
+v = *p
+case *map[string]float64:
+v = *p
+case *map[string]sloClassSpec:
+v = *p
+case *map[string]quotaLimit:
+v = *p
+}
+out[st.key] = configValue{Value: v, Source: src[st.key], Hot: st.hot}
+}
+return out
+}
+
+func breakerLimitsFromConfig(c *deciderConfig) breakerLimits {
+return breakerLimits{maxFails: c.BreakerMaxFails, cooldown: time.Duration(c.BreakerCooldownMs) * time.Millisecond, probes: c.BreakerProbes}
+}
+
+func capParamsFromConfig(c *deciderConfig) capParams {
+return capParams{coef: c.CapEdgeCoef, floor: c.CapFloor, cpuCoef: c.CapCPUCoef, queueCoef: c.CapQueueCoef, staleScrapes: c.CapStale}
+}
+
+// joinPairs renders a map as sorted "k=v,k=v", the form the policy and
+// quantisation parsers take.
+func joinPairs[V any](m map[string]V) string {
+keys := make([]string, 0, len(m))
+for k := range m {
+keys = append(keys, k)
+}
+sort.Strings(keys)
+parts := make([]string, len(keys))
+for i, k := range keys {
+parts[i] = fmt.Sprintf("%s=%v", k, m[k])
+}
+return strings.Join(parts, ",")
+}
+
+// configState is the last applied configuration and where each value came from.
+type configState struct {
+mu     sync.Mutex
+path   string
+cfg    *deciderConfig
+src    map[string]string
+loaded time.Time
+}
+
+// applyConfig pushes hot keys into the running server. Keys that are only read
+// at startup are logged so the operator knows a restart is needed.
+func (s *deciderServer) applyConfig(c *deciderConfig, src map[string]string) error {
+s.config.mu.Lock()
+defer s.config.mu.Unlock()
+old := s.config.cfg
+
+var ps *policySet
+if old == nil || c.Policy != old.Policy || joinPairs(c.PolicyTenants) != joinPairs(old.PolicyTenants) {
+// rebuilt only on change so stateful policies (ucb) keep what they learned
+p, err := s.policiesFromConfig(c)
+if err != nil {
+return fmt.Errorf("policy: %v", err)
+}
+ps = p
+}
+var shadows []*policyVariant
+shadowsChanged := old == nil || joinPairs(c.ShadowPolicies) != joinPairs(old.ShadowPolicies)
+if shadowsChanged {
+// same as policies: rebuilt only on change
+sh, err := s.buildShadows(c.ShadowPolicies)
+if err != nil {
+return fmt.Errorf("policy.shadow: %v", err)
+}
+shadows = sh
+}
+var exp *experiment
+expChanged := old == nil || c.ExperimentName != old.ExperimentName || c.ExperimentUnit != old.ExperimentUnit ||
+joinPairs(c.ExperimentArms) != joinPairs(old.ExperimentArms) || joinPairs(c.ExperimentSplit) != joinPairs(old.ExperimentSplit)
+if expChanged {
+// rebuilt only on change so arm results keep accumulating
+e, err := s.experimentFromConfig(c)
+if err != nil {
+return err
+}
+exp = e
+}
+q, err := quantFromConfig(c.CacheQuant)
+if err != nil {
+return fmt.Errorf("predcache.quant: %v", err)
+}
+classes, err := resolveSLOClasses(c)
+if err != nil {
+return err
+}
+
+s.mu.Lock()
+s.lambdaEnergy = c.LambdaEnergy
+s.alphaSLOBase = c.AlphaSLOBase
+s.exploreStdCap = c.ExploreStdCap
+s.epsilon = c.Epsilon
+s.useConformal = c.UseConformal
+s.propensitySamples = c.PropSamples
+s.ewmaAlpha = c.EwmaAlpha
+s.fairGammaMs = c.FairGammaMs
+s.applySLOLocked(classes, c)
+s.updateEvery = time.Duration(c.UpdateEveryMs) * time.Millisecond
+s.outcomeTimeout = time.Duration(c.OutcomeTimeoutMs) * time.Millisecond
+s.quotaDeny = c.QuotaDeny
+if ps != nil {
+s.policies = ps
+}
+if shadowsChanged {
+s.shadows = shadows
+}
+if expChanged {
+s.experiment = exp
+}
+s.mu.Unlock()
+mExploreEpsilon.Set(c.Epsilon)
+mGammaFair.Set(c.FairGammaMs)
+
+s.bandit.configure(c.BanditMode, c.BanditAlpha, c.BanditV)
+s.cache.reconfigure(c.CacheSize, time.Duration(c.CacheTTLMs)*time.Millisecond, q)
+if s.breakers != nil {
+s.breakers.setLimits(breakerLimitsFromConfig(c))
+}
+if s.quota != nil {
+s.quota.setLimits(c.QuotaRate, c.QuotaBurst, c.QuotaTenants)
+}
+if capPoller != nil {
+capPoller.SetParams(capParamsFromConfig(c))
+}
+s.drift.configure(driftParamsFromConfig(c))
+s.retrain.configure(retrainParamsFromConfig(c))
+
+if old != nil {
+for _, st := range configSchema {
+if !st.hot && !reflect.DeepEqual(reflect.ValueOf(st.ptr(c)).Elem().Interface(), reflect.ValueOf(st.ptr(old)).Elem().Interface()) {
+log.Printf("config: %s changed; takes effect after restart", st.key)
+}
+}
+}
+s.config.cfg, s.config.src, s.config.loaded = c, src, time.Now()
+return nil
+}
+
+// reloadConfig re-reads the config file and environment. On any error the
+// running configuration is left untouched.
+func (s *deciderServer) reloadConfig() error {
+s.config.mu.Lock()
+path := s.config.path
+s.config.mu.Unlock()
+c, src, err := loadConfig(path)
+if err != nil {
+mConfigReloads.WithLabelValues("error").Inc()
+return err
+}
+if err := s.applyConfig(c, src); err != nil {
+mConfigReloads.WithLabelValues("error").Inc()
+return err
+}
+mConfigReloads.WithLabelValues("ok").Inc()
+return nil
+}
+
+// watchSIGHUP reloads the config on every SIGHUP.
+func (s *deciderServer) watchSIGHUP() {
+ch := make(chan os.Signal, 1)
+signal.Notify(ch, syscall.SIGHUP)
+go func() {
+for range ch {
+if err := s.reloadConfig(); err != nil {
+log.Printf("config reload (SIGHUP) failed: %v", err)
+} else {
+log.Printf("config reloaded (SIGHUP)")
+}
+}
+}()
+}
+
+type configView struct {
+Path     string                 `json:"path"`
+LoadedAt time.Time              `json:"loaded_at"`
+Values   map[string]configValue `json:"values"`
+}
+
+// registerConfigHandlers exposes GET /config (effective values with their
+// source) and POST /config/reload.
+func registerConfigHandlers(s *deciderServer) {
+http.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+s.config.mu.Lock()
+v := configView{Path: s.config.path, LoadedAt: s.config.loaded, Values: describeConfig(s.config.cfg, s.config.src)}
+s.config.mu.Unlock()
+w.Header().Set("Content-Type", "application/json")
+enc := json.NewEncoder(w)
+enc.SetIndent("", "  ")
+_ = enc.Encode(v)
+})
+http.HandleFunc("/config/reload", func(w http.ResponseWriter, r *http.Request) {
+if r.Method != http.MethodPost {
+http.Error(w, "POST only", http.StatusMethodNotAllowed)
+return
+}
+if err := s.reloadConfig(); err != nil {
+http.Error(w, err.Error(), http.StatusBadRequest)
+return
+}
+log.Printf("config reloaded (HTTP)")
+w.WriteHeader(http.StatusNoContent)
+})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+"math"
+
+"github.com/prometheus/client_golang/prometheus"
+
+pb "github.com/mulat/csn/proto"
+)
+
+var mDegraded = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_degraded_decisions_total",
+Help: "Decisions made by the rule-based heuristic because no prediction was available (cause=breaker|predictor)",
+}, []string{"cause"})
+
+func init() {
+prometheus.MustRegister(mDegraded)
+}
+
+// computeMs is the rough service time per kind and tier on an idle node.
+var computeMs = map[string]map[string]float64{
+"local": {"low": 60, "med": 40, "high": 30},
+"edge":  {"low": 30, "med": 20, "high": 15},
+"cloud": {"low": 20, "med": 12, "high": 10},
+}
+
+// cloudExtraRttMs is the additional WAN round trip to reach the cloud over the edge.
+const cloudExtraRttMs = 40.0
+
+// estimateLatencyMs is the degraded-mode latency estimate for action a:
+// transfer (input over bw, inflated by loss) plus RTT plus load-scaled compute.
+func estimateLatencyMs(c *pb.Context, a string) float64 {
+act := actionCatalog.Get(a)
+kind, tier := act.Kind, act.Tier
+comp := computeMs["edge"]["med"]
+if m, ok := computeMs[kind]; ok {
+if v, ok := m[tier]; ok {
+comp = v
+}
+}
+if kind == "local" {
+return comp * (1 + c.GetDeviceCpu())
+}
+bw := math.Max(0.1, c.GetBwMbps())
+loss := math.Min(0.5, math.Max(0, c.GetLoss()))
+transfer := c.GetInputKb() * 8 / bw / (1 - loss) // kb*8/Mbps = ms
+rtt := c.GetRttMs()
+if kind == "cloud" {
+rtt += cloudExtraRttMs
+} else {
+// edge slows down sharply once it is more than half busy
+comp *= 1 + 2*math.Max(0, c.GetEdgeCpu()-0.5)
+}
+return transfer + rtt + comp
+}
+
+// degradedChoice picks, among actions whose estimate meets the SLO, the one
+// using the fewest resources; if none does, the one with the lowest estimate.
+func degradedChoice(c *pb.Context, actions []string) string {
+slo := c.GetSloP95Ms()
+best, bestEst, bestRes := "", math.Inf(1), math.Inf(1)
+fastest, fastestEst := "", math.Inf(1)
+for _, a := range actions {
+est := estimateLatencyMs(c, a)
+if est < fastestEst {
+fastest, fastestEst = a, est
+}
+if slo > 0 && est > slo {
+continue
+}
+res := resourceIntensity(a)
+if res < bestRes || (res == bestRes && est < bestEst) {
+best, bestEst, bestRes = a, est, res
+}
+}
+if best != "" {
+return best
+}
+return fastest
+}
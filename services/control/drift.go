@@ -1,114 +1,539 @@
 package main
 
 import (
+"encoding/csv"
+"encoding/json"
+"errors"
+"fmt"
+"io"
+"log"
 "math"
+"net/http"
+"os"
+"sort"
+"strconv"
+"strings"
 "sync"
 "time"
 
 "github.com/prometheus/client_golang/prometheus"
+
+pb "github.com/mulat/csn/proto"
 )
 
 var (
 mDriftScore = prometheus.NewGauge(prometheus.GaugeOpts{
 Name: "csn_drift_score",
-Help: "Rolling drift score (|mean - baseline_mean| / baseline_std)",
+Help: "Largest per-feature PSI against the baseline",
 })
-mDriftWindow = prometheus.NewGauge(prometheus.GaugeOpts{
+mDriftWindow = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 Name: "csn_drift_window",
-Help: "Number of samples in the rolling window",
-})
+Help: "Samples in the rolling window per feature",
+}, []string{"feature"})
+mDriftPSI = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_drift_psi",
+Help: "Population stability index of the rolling window against the baseline",
+}, []string{"feature"})
+mDriftKS = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_drift_ks_stat",
+Help: "Two-sample Kolmogorov-Smirnov statistic D against the baseline",
+}, []string{"feature"})
+mDriftKSP = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_drift_ks_pvalue",
+Help: "Asymptotic p-value of the KS statistic",
+}, []string{"feature"})
+mDriftDetected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_drift_detected",
+Help: "1 when PSI exceeds drift.psi_threshold or the KS p-value is below drift.ks_alpha",
+}, []string{"feature"})
 )
 
 func init() {
-prometheus.MustRegister(mDriftScore, mDriftWindow)
+prometheus.MustRegister(mDriftScore, mDriftWindow, mDriftPSI, mDriftKS, mDriftKSP, mDriftDetected)
+}
+
+// Context features are named after the experiments/telemetry.csv columns so
+// their baseline can be read from the training telemetry. Residual features
+// (observed - predicted) have no column there and take their baseline from
+// the first window after start or reset.
+var (
+driftContextFeatures  = []string{"bw_mbps", "rtt_ms", "loss", "device_cpu", "edge_cpu", "input_kb", "slo_p95_ms"}
+driftResidualFeatures = []string{"lat_residual_ms", "en_residual_j"}
+)
+
+func driftContextValues(c *pb.Context) []float64 {
+return []float64{c.GetBwMbps(), c.GetRttMs(), c.GetLoss(), c.GetDeviceCpu(), c.GetEdgeCpu(), c.GetInputKb(), c.GetSloP95Ms()}
+}
+
+// maxBaseline caps the stored baseline; larger samples are thinned evenly
+// over their sorted order, which keeps the quantiles.
+const maxBaseline = 5000
+
+type driftParams struct {
+window       int
+bins         int
+minSamples   int
+psiThreshold float64
+ksAlpha      float64
+}
+
+// featureDrift is one feature's baseline and rolling window.
+type featureDrift struct {
+name       string
+baseline   []float64 // sorted
+baseSource string    // "file:<path>", "warmup" or "pinned"
+edges      []float64 // PSI bin edges (interior baseline quantiles)
+baseProp   []float64
+
+win []float64 // ring
+idx int
+n   int
+
+// last evaluation
+psi     float64
+ksD     float64
+ksP     float64
+drifted bool
+}
+
+func (f *featureDrift) add(x float64) {
+if math.IsNaN(x) || math.IsInf(x, 0) {
+return
+}
+f.win[f.idx] = x
+f.idx = (f.idx + 1) % len(f.win)
+if f.n < len(f.win) {
+f.n++
+}
+}
+
+// window returns the samples in the ring, oldest first.
+func (f *featureDrift) window() []float64 {
+out := make([]float64, 0, f.n)
+start := (f.idx - f.n + len(f.win)) % len(f.win)
+for i := 0; i < f.n; i++ {
+out = append(out, f.win[(start+i)%len(f.win)])
+}
+return out
+}
+
+func (f *featureDrift) resize(size int) {
+w := f.window()
+if len(w) > size {
+w = w[len(w)-size:]
+}
+f.win = make([]float64, size)
+f.idx, f.n = 0, 0
+for _, x := range w {
+f.add(x)
+}
+}
+
+func (f *featureDrift) clear() {
+f.idx, f.n = 0, 0
+f.psi, f.ksD, f.ksP, f.drifted = 0, 0, 1, false
+}
+
+// setBaseline stores xs (sorted, thinned) and precomputes the PSI bins.
+func (f *featureDrift) setBaseline(xs []float64, source string, bins int) {
+s := append([]float64(nil), xs...)
+sort.Float64s(s)
+if len(s) > maxBaseline {
+thin := make([]float64, maxBaseline)
+for i := range thin {
+thin[i] = s[i*len(s)/maxBaseline]
+}
+s = thin
+}
+f.baseline, f.baseSource = s, source
+f.rebin(bins)
+}
+
+func (f *featureDrift) rebin(bins int) {
+f.edges, f.baseProp = nil, nil
+if len(f.baseline) == 0 {
+return
+}
+for i := 1; i < bins; i++ {
+e := quantileSorted(f.baseline, float64(i)/float64(bins))
+if len(f.edges) == 0 || e > f.edges[len(f.edges)-1] {
+f.edges = append(f.edges, e)
+}
+}
+f.baseProp = binProportions(f.baseline, f.edges)
+}
+
+func quantileSorted(s []float64, q float64) float64 {
+i := int(math.Ceil(q*float64(len(s)))) - 1
+if i < 0 {
+i = 0
+}
+return s[i]
+}
+
+// binProportions assigns x to bin k when edges[k-1] < x <= edges[k].
+func binProportions(xs, edges []float64) []float64 {
+p := make([]float64, len(edges)+1)
+for _, x := range xs {
+p[sort.SearchFloat64s(edges, x)]++
+}
+for i := range p {
+p[i] /= float64(len(xs))
+}
+return p
+}
+
+// psi compares the window's bin proportions with the baseline's; empty bins
+// are floored so the log stays finite.
+func psi(base, cur []float64) float64 {
+const floor = 1e-4
+s := 0.0
+for i := range base {
+e, a := math.Max(base[i], floor), math.Max(cur[i], floor)
+s += (a - e) * math.Log(a/e)
+}
+return s
+}
+
+// ksTest returns the two-sample KS statistic of sorted a and b and its
+// asymptotic p-value.
+func ksTest(a, b []float64) (d, p float64) {
+i, j := 0, 0
+for i < len(a) && j < len(b) {
+x := math.Min(a[i], b[j])
+for i < len(a) && a[i] <= x {
+i++
+}
+for j < len(b) && b[j] <= x {
+j++
+}
+d = math.Max(d, math.Abs(float64(i)/float64(len(a))-float64(j)/float64(len(b))))
+}
+en := math.Sqrt(float64(len(a)) * float64(len(b)) / float64(len(a)+len(b)))
+return d, ksProb((en + 0.12 + 0.11/en) * d)
+}
+
+// ksProb is the Kolmogorov distribution tail Q(lambda).
+func ksProb(lambda float64) float64 {
+if lambda < 1e-3 {
+return 1
+}
+sum, sign := 0.0, 1.0
+for j := 1; j <= 100; j++ {
+term := sign * 2 * math.Exp(-2*float64(j*j)*lambda*lambda)
+sum += term
+if math.Abs(term) < 1e-10 {
+break
+}
+sign = -sign
+}
+return math.Min(1, math.Max(0, sum))
 }
 
+// driftWatcher compares recent request contexts and prediction residuals with
+// a baseline, per feature, using PSI and the KS test. Observing is O(1); the
+// tests run on a ticker (drift.eval_ms). A nil *driftWatcher ignores samples.
 type driftWatcher struct {
-mu             sync.Mutex
-baselineMean   float64
-baselineStd    float64
-baselineSet    bool
+mu           sync.Mutex
+params       driftParams
+baselinePath string
+features     map[string]*featureDrift
+order        []string
+}
+
+func newDriftWatcher(params driftParams, baselinePath string) *driftWatcher {
+d := &driftWatcher{params: params, baselinePath: baselinePath, features: make(map[string]*featureDrift)}
+for _, name := range append(append([]string(nil), driftContextFeatures...), driftResidualFeatures...) {
+d.features[name] = &featureDrift{name: name, win: make([]float64, params.window), ksP: 1}
+d.order = append(d.order, name)
+}
+if err := d.loadBaseline(driftContextFeatures); err != nil {
+log.Printf("drift: no file baseline, warming up from live traffic: %v", err)
+}
+return d
+}
 
-// rolling window stats (Welford)
-count   int
-mean    float64
-M2      float64
-winMax  int
+func driftFromConfig(c *deciderConfig) *driftWatcher {
+path := strings.TrimSpace(c.DriftBaseline)
+if path == "off" {
+path = ""
+}
+return newDriftWatcher(driftParamsFromConfig(c), path)
+}
+
+func driftParamsFromConfig(c *deciderConfig) driftParams {
+return driftParams{window: c.DriftWindow, bins: c.DriftBins, minSamples: c.DriftMinSamples, psiThreshold: c.DriftPSI, ksAlpha: c.DriftKSAlpha}
 }
 
-func newDriftWatcher(win int) *driftWatcher {
-return &driftWatcher{winMax: win}
+// readTelemetryColumns reads the named columns of a telemetry CSV; cells that
+// do not parse are skipped.
+func readTelemetryColumns(path string, names []string) (map[string][]float64, error) {
+f, err := os.Open(path)
+if err != nil {
+return nil, err
+}
+defer f.Close()
+r := csv.NewReader(f)
+r.FieldsPerRecord = -1
+header, err := r.Read()
+if err != nil {
+return nil, fmt.Errorf("%s: %w", path, err)
+}
+col := make(map[string]int, len(header))
+for i, h := range header {
+col[strings.TrimSpace(h)] = i
+}
+out := make(map[string][]float64, len(names))
+for {
+rec, err := r.Read()
+if err == io.EOF {
+break
+}
+if err != nil {
+return nil, fmt.Errorf("%s: %w", path, err)
+}
+for _, name := range names {
+i, ok := col[name]
+if !ok || i >= len(rec) {
+continue
+}
+if v, err := strconv.ParseFloat(strings.TrimSpace(rec[i]), 64); err == nil {
+out[name] = append(out[name], v)
+}
+}
+}
+return out, nil
 }
 
-func (d *driftWatcher) resetBaseline(mean, std float64) {
+// loadBaseline sets the baseline of the named features that have a column in
+// baselinePath.
+func (d *driftWatcher) loadBaseline(names []string) error {
+if d.baselinePath == "" {
+return fmt.Errorf("drift.baseline_path is off")
+}
+cols, err := readTelemetryColumns(d.baselinePath, names)
+if err != nil {
+return err
+}
 d.mu.Lock()
 defer d.mu.Unlock()
-d.baselineMean = mean
-d.baselineStd  = math.Max(std, 1e-6)
-d.baselineSet  = true
+for _, name := range names {
+if xs := cols[name]; len(xs) >= d.params.bins {
+d.features[name].setBaseline(xs, "file:"+d.baselinePath, d.params.bins)
+}
+}
+return nil
 }
 
-func (d *driftWatcher) addSample(x float64) {
+func (d *driftWatcher) observe(names []string, xs []float64) {
+if d == nil {
+return
+}
 d.mu.Lock()
 defer d.mu.Unlock()
-
-// update rolling stats (bounded window via light decay)
-d.count++
-delta := x - d.mean
-d.mean += delta / float64(d.count)
-d.M2 += delta * (x - d.mean)
-
-// soft decay to approximate finite window
-if d.count > d.winMax {
-d.count = int(float64(d.count) * 0.98)
-d.M2 *= 0.98
+for i, name := range names {
+d.features[name].add(xs[i])
+}
 }
 
-var std float64
-if d.count > 1 {
-std = math.Sqrt(d.M2 / float64(d.count-1))
-} else {
-std = 1e-6
+// observeContext records a Decide request's context.
+func (d *driftWatcher) observeContext(c *pb.Context) {
+d.observe(driftContextFeatures, driftContextValues(c))
 }
 
-// if no baseline yet, set it from first few seconds
-if !d.baselineSet {
-// require a minimal warmup
-if d.count > 30 {
-d.baselineMean = d.mean
-d.baselineStd  = math.Max(std, 1e-6)
-d.baselineSet  = true
+// observeResidual records observed minus predicted latency and energy.
+func (d *driftWatcher) observeResidual(lat, en float64) {
+d.observe(driftResidualFeatures, []float64{lat, en})
 }
-mDriftScore.Set(0)
-mDriftWindow.Set(float64(d.count))
+
+// configure applies hot-reloaded parameters.
+func (d *driftWatcher) configure(p driftParams) {
+if d == nil {
 return
 }
-
-// z-score distance from baseline
-score := math.Abs(d.mean - d.baselineMean) / math.Max(d.baselineStd, 1e-6)
-mDriftScore.Set(score)
-mDriftWindow.Set(float64(d.count))
+d.mu.Lock()
+defer d.mu.Unlock()
+old := d.params
+d.params = p
+for _, f := range d.features {
+if p.window != old.window {
+f.resize(p.window)
+}
+if p.bins != old.bins {
+f.rebin(p.bins)
+}
+}
 }
 
-func (d *driftWatcher) current() (mean, std float64, n int) {
-d.mu.Lock(); defer d.mu.Unlock()
-n = d.count
-if d.count > 1 {
-std = math.Sqrt(d.M2 / float64(d.count-1))
-} else {
-std = 0
+// evaluate runs PSI and KS for every feature with enough samples. A feature
+// without a baseline adopts its first full window (warm-up).
+func (d *driftWatcher) evaluate() {
+d.mu.Lock()
+defer d.mu.Unlock()
+score := 0.0
+for _, name := range d.order {
+f := d.features[name]
+mDriftWindow.WithLabelValues(name).Set(float64(f.n))
+if len(f.baseline) == 0 {
+if f.n >= len(f.win) && f.n >= d.params.minSamples {
+f.setBaseline(f.window(), "warmup", d.params.bins)
+f.clear()
 }
-mean = d.mean
-return
+continue
+}
+if f.n < d.params.minSamples {
+continue
+}
+w := f.window()
+sort.Float64s(w)
+f.psi = psi(f.baseProp, binProportions(w, f.edges))
+f.ksD, f.ksP = ksTest(f.baseline, w)
+f.drifted = f.psi > d.params.psiThreshold || f.ksP < d.params.ksAlpha
+score = math.Max(score, f.psi)
+mDriftPSI.WithLabelValues(name).Set(f.psi)
+mDriftKS.WithLabelValues(name).Set(f.ksD)
+mDriftKSP.WithLabelValues(name).Set(f.ksP)
+detected := 0.0
+if f.drifted {
+detected = 1
+}
+mDriftDetected.WithLabelValues(name).Set(detected)
+}
+mDriftScore.Set(score)
 }
 
-// optional periodic logging hook (not required)
-func (d *driftWatcher) startLogTicker() {
+func (d *driftWatcher) start(every time.Duration) {
 go func() {
-t := time.NewTicker(30 * time.Second)
+t := time.NewTicker(every)
 defer t.Stop()
 for range t.C {
-d.current()
+d.evaluate()
 }
 }()
 }
+
+var errUnknownFeature = errors.New("unknown feature")
+
+// selectLocked resolves the feature query parameter; empty means all.
+func (d *driftWatcher) selectLocked(feature string) ([]*featureDrift, error) {
+if feature == "" {
+out := make([]*featureDrift, 0, len(d.order))
+for _, name := range d.order {
+out = append(out, d.features[name])
+}
+return out, nil
+}
+f, ok := d.features[feature]
+if !ok {
+return nil, fmt.Errorf("%w %q", errUnknownFeature, feature)
+}
+return []*featureDrift{f}, nil
+}
+
+// reset drops the baseline and window of feature (all when empty); context
+// features reload from the baseline file, the rest warm up again.
+func (d *driftWatcher) reset(feature string) error {
+d.mu.Lock()
+fs, err := d.selectLocked(feature)
+if err != nil {
+d.mu.Unlock()
+return err
+}
+var names []string
+for _, f := range fs {
+f.baseline, f.baseSource, f.edges, f.baseProp = nil, "", nil, nil
+f.clear()
+names = append(names, f.name)
+}
+d.mu.Unlock()
+if err := d.loadBaseline(names); err != nil {
+log.Printf("drift: baseline reload: %v", err)
+}
+return nil
+}
+
+// pin makes the current window the baseline of feature (all when empty).
+func (d *driftWatcher) pin(feature string) error {
+d.mu.Lock()
+defer d.mu.Unlock()
+fs, err := d.selectLocked(feature)
+if err != nil {
+return err
+}
+for _, f := range fs {
+if f.n < d.params.minSamples {
+if feature != "" {
+return fmt.Errorf("feature %q has %d samples, need %d", f.name, f.n, d.params.minSamples)
+}
+continue
+}
+f.setBaseline(f.window(), "pinned", d.params.bins)
+f.clear()
+}
+return nil
+}
+
+type driftView struct {
+Feature    string  `json:"feature"`
+Samples    int     `json:"samples"`
+Baseline   int     `json:"baseline_samples"`
+Source     string  `json:"baseline_source,omitempty"`
+PSI        float64 `json:"psi"`
+KSStat     float64 `json:"ks_stat"`
+KSPValue   float64 `json:"ks_pvalue"`
+Drifted    bool    `json:"drifted"`
+}
+
+func (d *driftWatcher) snapshot() []driftView {
+d.mu.Lock()
+defer d.mu.Unlock()
+out := make([]driftView, 0, len(d.order))
+for _, name := range d.order {
+f := d.features[name]
+out = append(out, driftView{Feature: name, Samples: f.n, Baseline: len(f.baseline), Source: f.baseSource, PSI: f.psi, KSStat: f.ksD, KSPValue: f.ksP, Drifted: f.drifted})
+}
+return out
+}
+
+// drifted lists the features flagged by the last evaluation.
+func (d *driftWatcher) drifted() []string {
+if d == nil {
+return nil
+}
+d.mu.Lock()
+defer d.mu.Unlock()
+var out []string
+for _, name := range d.order {
+if d.features[name].drifted {
+out = append(out, name)
+}
+}
+return out
+}
+
+// registerDriftHandlers exposes GET /drift, POST /drift/reset?feature= and
+// POST /drift/pin?feature= (no feature: all).
+func registerDriftHandlers(d *driftWatcher) {
+http.HandleFunc("/drift", func(w http.ResponseWriter, r *http.Request) {
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(map[string]interface{}{"features": d.snapshot()})
+})
+for path, op := range map[string]func(string) error{"/drift/reset": d.reset, "/drift/pin": d.pin} {
+op := op
+http.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+if r.Method != http.MethodPost {
+http.Error(w, "POST only", http.StatusMethodNotAllowed)
+return
+}
+if err := op(r.URL.Query().Get("feature")); err != nil {
+code := http.StatusConflict
+if errors.Is(err, errUnknownFeature) {
+code = http.StatusNotFound
+}
+http.Error(w, err.Error(), code)
+return
+}
+w.WriteHeader(http.StatusNoContent)
+})
+}
+}
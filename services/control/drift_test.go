@@ -0,0 +1,81 @@
+package main
+
+import (
+"math"
+"testing"
+)
+
+func TestPSI(t *testing.T) {
+cases := []struct {
+name      string
+base, cur []float64
+want      float64
+}{
+{"identical", []float64{0.25, 0.25, 0.25, 0.25}, []float64{0.25, 0.25, 0.25, 0.25}, 0},
+{"shifted quartiles", []float64{0.25, 0.25, 0.25, 0.25}, []float64{0.1, 0.2, 0.3, 0.4}, 0.228217409573},
+{"small shift", []float64{0.2, 0.3, 0.5}, []float64{0.3, 0.3, 0.4}, 0.062860865942},
+{"empty bin is floored", []float64{0.5, 0.5}, []float64{1, 0}, 4.604318466669},
+}
+for _, tc := range cases {
+t.Run(tc.name, func(t *testing.T) {
+if got := psi(tc.base, tc.cur); math.Abs(got-tc.want) > 1e-9 {
+t.Fatalf("psi = %.12f, want %.12f", got, tc.want)
+}
+})
+}
+}
+
+func TestBinProportions(t *testing.T) {
+got := binProportions([]float64{0.5, 1, 1.5, 2, 3}, []float64{1, 2})
+want := []float64{0.4, 0.4, 0.2}
+for i := range want {
+if math.Abs(got[i]-want[i]) > 1e-12 {
+t.Fatalf("binProportions = %v, want %v", got, want)
+}
+}
+}
+
+func TestKS(t *testing.T) {
+cases := []struct {
+name string
+a, b []float64
+d    float64
+}{
+{"identical", []float64{1, 2, 3, 4}, []float64{1, 2, 3, 4}, 0},
+{"disjoint", []float64{1, 2, 3}, []float64{4, 5, 6}, 1},
+{"half overlap", []float64{1, 2, 3, 4}, []float64{3, 4, 5, 6}, 0.5},
+{"unequal sizes", []float64{1, 2}, []float64{1.5, 2.5, 3.5, 4.5}, 0.75},
+}
+for _, tc := range cases {
+t.Run(tc.name, func(t *testing.T) {
+d, p := ksTest(tc.a, tc.b)
+if math.Abs(d-tc.d) > 1e-12 {
+t.Fatalf("D = %g, want %g", d, tc.d)
+}
+if p < 0 || p > 1 {
+t.Fatalf("p = %g outside [0,1]", p)
+}
+if tc.d == 0 && p != 1 {
+t.Fatalf("p = %g for identical samples, want 1", p)
+}
+})
+}
+}
+
+func TestKSProb(t *testing.T) {
+// Kolmogorov distribution tail Q(lambda) = 2 sum (-1)^(j-1) exp(-2 j^2 lambda^2)
+cases := []struct {
+lambda, want float64
+}{
+{0, 1},
+{0.5, 0.963945243665},
+{1.0, 0.269999671677},
+{1.36, 0.049485876755},
+{3, 3.04599e-08},
+}
+for _, tc := range cases {
+if got := ksProb(tc.lambda); math.Abs(got-tc.want) > 1e-9 {
+t.Fatalf("ksProb(%g) = %.12f, want %.12f", tc.lambda, got, tc.want)
+}
+}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+"encoding/json"
+"fmt"
+"hash/fnv"
+"net/http"
+"sort"
+"strings"
+"sync"
+
+"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+mABDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_ab_decisions_total",
+Help: "Decisions served per experiment arm",
+}, []string{"experiment", "arm"})
+mABViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_ab_slo_violations_total",
+Help: "SLO violations per experiment arm, predicted at decision time or observed in reported outcomes",
+}, []string{"experiment", "arm", "source"})
+mABLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+Name:    "csn_ab_latency_ms",
+Help:    "Latency of the chosen action per experiment arm (source=predicted|observed)",
+Buckets: []float64{10, 25, 50, 75, 100, 150, 200, 300, 500, 1000},
+}, []string{"experiment", "arm", "source"})
+mABEnergy = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+Name:    "csn_ab_energy_j",
+Help:    "Energy of the chosen action per experiment arm (source=predicted|observed)",
+Buckets: []float64{.05, .1, .2, .3, .5, .75, 1, 1.5, 2, 5},
+}, []string{"experiment", "arm", "source"})
+)
+
+func init() {
+prometheus.MustRegister(mABDecisions, mABViolations, mABLatency, mABEnergy)
+}
+
+// experiment splits traffic between policy arms. A unit (tenant, tenant/app or
+// request) is hashed together with the experiment name into [0,1) and lands in
+// the arm whose cumulative share covers it, so assignment is the same on every
+// replica and across restarts as long as name, arms and split are unchanged.
+// Renaming the experiment reshuffles everyone.
+type experiment struct {
+name string
+unit string // tenant | app | request
+arms []*abArm
+}
+
+type abArm struct {
+v     *policyVariant
+share float64 // normalised
+upTo  float64 // cumulative share, the arm covers [upTo-share, upTo)
+
+mu    sync.Mutex
+stats armStats
+}
+
+type armStats struct {
+Decisions  int     `json:"decisions"`
+Predicted  int     `json:"predicted"`
+PredViol   int     `json:"pred_slo_violations"`
+PredLatSum float64 `json:"-"`
+PredEnSum  float64 `json:"-"`
+Outcomes   int     `json:"outcomes"`
+ObsViol    int     `json:"obs_slo_violations"`
+ObsLatSum  float64 `json:"-"`
+ObsEnSum   float64 `json:"-"`
+}
+
+// experimentFromConfig builds experiment.arms/split; nil when experiment.name
+// is "off" or empty. Arms without a split entry get weight 0; an empty split
+// means equal weights.
+func (s *deciderServer) experimentFromConfig(c *deciderConfig) (*experiment, error) {
+name := strings.TrimSpace(c.ExperimentName)
+if name == "" || name == "off" {
+return nil, nil
+}
+variants, err := s.buildVariants(c.ExperimentArms)
+if err != nil {
+return nil, fmt.Errorf("experiment.arms: %v", err)
+}
+if len(variants) == 0 {
+return nil, fmt.Errorf("experiment.arms: experiment %q has no arms", name)
+}
+for a := range c.ExperimentSplit {
+if _, ok := c.ExperimentArms[a]; !ok {
+return nil, fmt.Errorf("experiment.split: unknown arm %q", a)
+}
+}
+total := 0.0
+weights := make([]float64, len(variants))
+for i, v := range variants {
+weights[i] = 1
+if len(c.ExperimentSplit) > 0 {
+weights[i] = c.ExperimentSplit[v.name]
+}
+total += weights[i]
+}
+if total <= 0 {
+return nil, fmt.Errorf("experiment.split: weights sum to 0")
+}
+e := &experiment{name: name, unit: c.ExperimentUnit}
+cum := 0.0
+for i, v := range variants {
+cum += weights[i] / total
+e.arms = append(e.arms, &abArm{v: v, share: weights[i] / total, upTo: cum})
+}
+// rounding must not leave a gap below 1
+e.arms[len(e.arms)-1].upTo = 1
+return e, nil
+}
+
+// unitKey is what the experiment hashes for a request.
+func (e *experiment) unitKey(tenant, app, decisionID string) string {
+switch e.unit {
+case "app":
+return tenant + "/" + app
+case "request":
+return decisionID
+}
+return tenant
+}
+
+// assign returns the arm for the request; nil without an experiment.
+func (e *experiment) assign(tenant, app, decisionID string) *abArm {
+if e == nil {
+return nil
+}
+h := fnv.New64a()
+h.Write([]byte(e.name))
+h.Write([]byte{0})
+h.Write([]byte(e.unitKey(tenant, app, decisionID)))
+// FNV's high bits barely move for keys like "t1".."t99"; mix before using them
+k := h.Sum64()
+k ^= k >> 33
+k *= 0xff51afd7ed558ccd
+k ^= k >> 33
+k *= 0xc4ceb9fe1a85ec53
+k ^= k >> 33
+x := float64(k>>11) / (1 << 53)
+for _, a := range e.arms {
+if x < a.upTo && a.share > 0 {
+return a
+}
+}
+// x fell into a zero-share arm at the top; take the last arm with traffic
+for i := len(e.arms) - 1; i >= 0; i-- {
+if e.arms[i].share > 0 {
+return e.arms[i]
+}
+}
+return nil
+}
+
+// recordDecision counts a served decision; chosen is nil when no prediction was
+// available (degraded), in which case only the decision is counted.
+func (e *experiment) recordDecision(a *abArm, chosen *candidate) {
+mABDecisions.WithLabelValues(e.name, a.v.name).Inc()
+a.mu.Lock()
+defer a.mu.Unlock()
+a.stats.Decisions++
+if chosen == nil {
+return
+}
+a.stats.Predicted++
+a.stats.PredLatSum += chosen.muLat
+a.stats.PredEnSum += chosen.muEn
+mABLatency.WithLabelValues(e.name, a.v.name, "predicted").Observe(chosen.muLat)
+mABEnergy.WithLabelValues(e.name, a.v.name, "predicted").Observe(chosen.muEn)
+if chosen.sloPenalty() > 0 {
+a.stats.PredViol++
+mABViolations.WithLabelValues(e.name, a.v.name, "predicted").Inc()
+}
+}
+
+// recordOutcome folds a reported outcome into the arm the decision was served by.
+func (e *experiment) recordOutcome(a *abArm, latMs, enJ float64, violated bool) {
+mABLatency.WithLabelValues(e.name, a.v.name, "observed").Observe(latMs)
+mABEnergy.WithLabelValues(e.name, a.v.name, "observed").Observe(enJ)
+a.mu.Lock()
+defer a.mu.Unlock()
+a.stats.Outcomes++
+a.stats.ObsLatSum += latMs
+a.stats.ObsEnSum += enJ
+if violated {
+a.stats.ObsViol++
+mABViolations.WithLabelValues(e.name, a.v.name, "observed").Inc()
+}
+}
+
+type armView struct {
+Name  string  `json:"name"`
+Spec  string  `json:"spec"`
+Share float64 `json:"share"`
+armStats
+PredViolRate  float64 `json:"pred_violation_rate"`
+MeanPredLatMs float64 `json:"mean_pred_latency_ms"`
+MeanPredEnJ   float64 `json:"mean_pred_energy_j"`
+ObsViolRate   float64 `json:"obs_violation_rate"`
+MeanObsLatMs  float64 `json:"mean_obs_latency_ms"`
+MeanObsEnJ    float64 `json:"mean_obs_energy_j"`
+}
+
+type experimentView struct {
+Name string    `json:"name"`
+Unit string    `json:"unit"`
+Arms []armView `json:"arms"`
+}
+
+func ratio(a, b float64) float64 {
+if b == 0 {
+return 0
+}
+return a / b
+}
+
+func (e *experiment) view() experimentView {
+v := experimentView{Name: e.name, Unit: e.unit, Arms: make([]armView, 0, len(e.arms))}
+for _, a := range e.arms {
+a.mu.Lock()
+st := a.stats
+a.mu.Unlock()
+pn, on := float64(st.Predicted), float64(st.Outcomes)
+v.Arms = append(v.Arms, armView{
+Name:          a.v.name,
+Spec:          a.v.spec,
+Share:         a.share,
+armStats:      st,
+PredViolRate:  ratio(float64(st.PredViol), pn),
+MeanPredLatMs: ratio(st.PredLatSum, pn),
+MeanPredEnJ:   ratio(st.PredEnSum, pn),
+ObsViolRate:   ratio(float64(st.ObsViol), on),
+MeanObsLatMs:  ratio(st.ObsLatSum, on),
+MeanObsEnJ:    ratio(st.ObsEnSum, on),
+})
+}
+sort.Slice(v.Arms, func(i, j int) bool { return v.Arms[i].Name < v.Arms[j].Name })
+return v
+}
+
+// registerExperimentHandlers exposes GET /experiment (per-arm results since
+// the experiment was last (re)configured) and GET /experiment/assign?tenant=&app=
+// (the arm a tenant is in; request-unit experiments have no fixed answer).
+func registerExperimentHandlers(s *deciderServer) {
+current := func(w http.ResponseWriter) *experiment {
+s.mu.Lock()
+e := s.experiment
+s.mu.Unlock()
+if e == nil {
+http.Error(w, "no experiment (experiment.name is off)", http.StatusNotFound)
+}
+return e
+}
+http.HandleFunc("/experiment", func(w http.ResponseWriter, r *http.Request) {
+e := current(w)
+if e == nil {
+return
+}
+w.Header().Set("Content-Type", "application/json")
+enc := json.NewEncoder(w)
+enc.SetIndent("", "  ")
+_ = enc.Encode(e.view())
+})
+http.HandleFunc("/experiment/assign", func(w http.ResponseWriter, r *http.Request) {
+e := current(w)
+if e == nil {
+return
+}
+if e.unit == "request" {
+http.Error(w, "experiment.unit is request; arms are drawn per decision", http.StatusBadRequest)
+return
+}
+tenant := r.URL.Query().Get("tenant")
+if tenant == "" {
+tenant = "default"
+}
+app := r.URL.Query().Get("app")
+s.mu.Lock()
+_, pinned := s.policies.pinned(tenant, app)
+s.mu.Unlock()
+if pinned {
+http.Error(w, "tenant is pinned by policy.tenants and not in the experiment", http.StatusConflict)
+return
+}
+a := e.assign(tenant, app, "")
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(map[string]string{"experiment": e.name, "arm": a.v.name, "spec": a.v.spec})
+})
+}
@@ -12,40 +12,62 @@ mExploreEpsilon = prometheus.NewGauge(prometheus.GaugeOpts{
 Name: "csn_explore_epsilon",
 Help: "Current exploration rate epsilon",
 })
-mViolRate = prometheus.NewGauge(prometheus.GaugeOpts{
+mViolRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 Name: "csn_viol_rate",
-Help: "Rolling SLO violation rate",
-})
+Help: "Rolling SLO violation rate per service class",
+}, []string{"class"})
 )
 
 func init() {
 prometheus.MustRegister(mExploreEpsilon, mViolRate)
 }
 
-func (s *deciderServer) currentViolRate() float64 {
-s.mu.Lock()
-defer s.mu.Unlock()
-return s.currentViolRateLocked()
+// sloPressureLocked updates the per-class violation gauges and reports whether
+// any class is over its target.
+func (s *deciderServer) sloPressureLocked() bool {
+over := false
+for _, c := range s.sloClasses {
+rate := c.violRate()
+mViolRate.WithLabelValues(c.name).Set(rate)
+if rate > c.targetEps {
+over = true
+}
+}
+return over
 }
 
-func (s *deciderServer) startExplorationGovernor() {
-// seed gauges
-mExploreEpsilon.Set(s.epsilon)
-mViolRate.Set(s.currentViolRate())
+// governEvery is the exploration governor's period.
+const governEvery = 5 * time.Second
 
-go func() {
-t := time.NewTicker(5 * time.Second)
-defer t.Stop()
-for range t.C {
-rate := s.currentViolRate()
-// shrink epsilon quickly on violations; grow slowly when healthy
-if rate > s.targetEps {
+// governLocked shrinks epsilon quickly while any class violates and grows it
+// slowly when all are healthy.
+func (s *deciderServer) governLocked() {
+if s.sloPressureLocked() {
 s.epsilon = math.Max(0.01, s.epsilon*0.5)
 } else {
 s.epsilon = math.Min(0.20, s.epsilon*1.05)
 }
 mExploreEpsilon.Set(s.epsilon)
-mViolRate.Set(rate)
+}
+
+// startExplorationGovernor seeds the gauges and, outside deterministic mode,
+// runs the governor every governEvery (syncClock drives it otherwise).
+func (s *deciderServer) startExplorationGovernor() {
+s.mu.Lock()
+mExploreEpsilon.Set(s.epsilon)
+s.sloPressureLocked()
+s.mu.Unlock()
+if s.manual != nil {
+return
+}
+
+go func() {
+t := time.NewTicker(governEvery)
+defer t.Stop()
+for range t.C {
+s.mu.Lock()
+s.governLocked()
+s.mu.Unlock()
 }
 }()
 }
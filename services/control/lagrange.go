@@ -1,6 +1,7 @@
 package main
 
 import (
+"encoding/json"
 "net/http"
 "strconv"
 "sync"
@@ -11,9 +12,9 @@ import (
 var (
 deciderInstance *deciderServer
 
-mMuSLO     = prometheus.NewGauge(prometheus.GaugeOpts{
-Name: "csn_mu_slo", Help: "Current SLO Lagrange multiplier",
-})
+mMuSLO     = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_mu_slo", Help: "Current SLO Lagrange multiplier per service class",
+}, []string{"class"})
 mGammaFair = prometheus.NewGauge(prometheus.GaugeOpts{
 Name: "csn_gamma_fair_ms", Help: "Current fairness penalty (ms per excess resource unit)",
 })
@@ -25,38 +26,54 @@ handlersOnce sync.Once
 func registerLagrangeHandlers(ds *deciderServer) {
 deciderInstance = ds
 handlersOnce.Do(func() {
-// Read current values
+// Read current values: {"mu_slo":{"gold":..,"default":..},"gamma_fair_ms":..}
 http.HandleFunc("/lagrange/get", func(w http.ResponseWriter, r *http.Request) {
 ds.mu.Lock()
-muSLO := ds.muSLO
+mus := make(map[string]float64, len(ds.sloClasses))
+for name, c := range ds.sloClasses {
+mus[name] = c.muSLO
+}
 gamma := ds.fairGammaMs
 ds.mu.Unlock()
-_, _ = w.Write([]byte(
-`{"mu_slo":` + strconv.FormatFloat(muSLO, 'f', 6, 64) +
-`,"gamma_fair_ms":` + strconv.FormatFloat(gamma, 'f', 6, 64) + `}`))
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(map[string]interface{}{"mu_slo": mus, "gamma_fair_ms": gamma})
 })
 
-// Update values via query/form (e.g., /lagrange/set?mu_slo=3&gamma_fair_ms=15)
+// Update values via query/form (e.g., /lagrange/set?mu_slo=3&class=gold&gamma_fair_ms=15);
+// without class, mu_slo is applied to every class
 http.HandleFunc("/lagrange/set", func(w http.ResponseWriter, r *http.Request) {
 if err := r.ParseForm(); err != nil {
 http.Error(w, err.Error(), 400)
 return
 }
+class := r.Form.Get("class")
+ds.mu.Lock()
+defer ds.mu.Unlock()
+if class != "" {
+if _, ok := ds.sloClasses[class]; !ok {
+http.Error(w, "unknown class "+strconv.Quote(class), 404)
+return
+}
+}
 if v := r.Form.Get("mu_slo"); v != "" {
 if f, err := strconv.ParseFloat(v, 64); err == nil {
-ds.mu.Lock(); ds.muSLO = f; ds.mu.Unlock()
+for name, c := range ds.sloClasses {
+if class == "" || class == name {
+c.muSLO = f
+}
+}
 }
 }
 if v := r.Form.Get("gamma_fair_ms"); v != "" {
 if f, err := strconv.ParseFloat(v, 64); err == nil {
-ds.mu.Lock(); ds.fairGammaMs = f; ds.mu.Unlock()
+ds.fairGammaMs = f
 }
 }
 // Update gauges
-ds.mu.Lock()
-mMuSLO.Set(ds.muSLO)
+for name, c := range ds.sloClasses {
+mMuSLO.WithLabelValues(name).Set(c.muSLO)
+}
 mGammaFair.Set(ds.fairGammaMs)
-ds.mu.Unlock()
 
 w.WriteHeader(http.StatusNoContent)
 })
@@ -2,15 +2,14 @@ package main
 
 import (
 "context"
-"crypto/rand"
-"encoding/binary"
+"flag"
 "fmt"
 "log"
 "math"
-mrand "math/rand"
 "net"
 "net/http"
 "os"
+"sort"
 "strconv"
 "strings"
 "sync"
@@ -18,6 +17,8 @@ mrand "math/rand"
 
 "github.com/prometheus/client_golang/prometheus/promhttp"
 "google.golang.org/grpc"
+"google.golang.org/grpc/codes"
+"google.golang.org/grpc/status"
 
 pb "github.com/mulat/csn/proto"
 )
@@ -27,72 +28,84 @@ var capPoller *CapPoller
 
 // --- helpers ----------------------------------------------------------
 
-func parseKindTier(a string) (kind, tier string) {
-kind, tier = "edge", "med"
-if a == "" {
-return
+func actionCostMs(a string) float64 {
+return actionCatalog.Get(a).CostMs
 }
-parts := strings.Split(a, ":")
-k := parts[0]
-t := "med"
-if len(parts) > 1 {
-t = parts[1]
+
+func actionCostMsWithCap(a string, base float64, capFactor float64) float64 {
+if actionCatalog.Get(a).Kind == "edge" {
+return base * capFactor
 }
-if strings.HasPrefix(k, "edge") {
-k = "edge"
+return base
 }
-if strings.HasPrefix(k, "cloud") {
-k = "cloud"
+
+// actionCapFactor is the capacity factor for a: the site's own factor for edge
+// actions, the fleet-wide one otherwise.
+func actionCapFactor(a string, base float64) float64 {
+spec := actionCatalog.Get(a)
+if spec.Kind != "edge" || capPoller == nil {
+return base
 }
-if k != "local" && k != "edge" && k != "cloud" {
-k = "edge"
+return capPoller.SiteFactor(spec.Site)
 }
-if t != "low" && t != "med" && t != "high" {
-t = "med"
+
+// upActions drops actions at sites the operator reports down. If that would
+// leave nothing, the original list is kept and the predictor/breakers decide.
+func upActions(actions []string) ([]string, []string) {
+if capPoller == nil {
+return actions, nil
 }
-return k, t
+var up, down []string
+for _, a := range actions {
+if capPoller.SiteDown(actionCatalog.Get(a).Site) {
+down = append(down, a)
+continue
 }
-
-func actionCostMs(a string) float64 {
-kind, tier := parseKindTier(a)
-kindCost := map[string]float64{"local": 0, "edge": 15, "cloud": 40}[kind]
-tierCost := map[string]float64{"low": 0, "med": 40, "high": 120}[tier]
-return kindCost + tierCost
+up = append(up, a)
 }
-
-func actionCostMsWithCap(a string, base float64, capFactor float64) float64 {
-k, _ := parseKindTier(a)
-if k == "edge" {
-return base * capFactor
+if len(up) == 0 {
+return actions, down
 }
-return base
+return up, down
 }
 
 func resourceIntensity(a string) float64 {
-kind, tier := parseKindTier(a)
-base := map[string]float64{"low": 1, "med": 2, "high": 3}[tier]
-switch kind {
-case "local":
-base += 0
-case "edge":
-base += 1
-case "cloud":
-base += 2
-}
-return base
+return actionCatalog.Get(a).Intensity
 }
 
 // --- Decider server ----------------------------------------------------------
 
 type deciderServer struct {
- 	quota *quotaManager
-// resilience
-brk *circuitBreaker
+// per-tenant admission; quotaDeny is "reply" (Denied in DecideReply) or "status" (ResourceExhausted)
+quota     *quotaManager
+quotaDeny string
+// resilience: breakers per predictor endpoint (and action), see breaker.go
+breakers      *breakerSet
+predictorAddr string
 
 // gRPC
 pb.UnimplementedDeciderServer
 predictor pb.PredictorClient
 
+// scoring policy per tenant/app (policy.go)
+policies *policySet
+// residual learner blended into candidates (bandit.go)
+bandit *contextualBandit
+// JSONL decision/outcome log, nil when disabled (audit.go)
+audit *auditLog
+// decision telemetry to the sensing service, nil when disabled (sense.go)
+telemetry *senseQueue
+// per-feature PSI/KS drift of contexts and residuals (drift.go)
+drift *driftWatcher
+// fires the retraining hook on sustained drift/violations (retrain.go)
+retrain *retrainer
+// candidate configurations scored alongside the live policy (shadow.go)
+shadows []*policyVariant
+// A/B split of unpinned traffic between policy arms, nil when off (experiment.go)
+experiment *experiment
+// predictions keyed by quantised context, nil when disabled (predcache.go)
+cache *predCache
+
 // objective weights
 lambdaEnergy  float64
 alphaSLOBase  float64
@@ -100,43 +113,57 @@ exploreStdCap float64
 epsilon       float64
 useConformal  bool
 
+// score re-draws behind the reported propensity (propensity.go)
+propensitySamples int
+
 // fairness EWMA
 mu          sync.Mutex
 tenantEWMA  map[string]float64
 ewmaAlpha   float64
 fairGammaMs float64
 
-// SLO primal-dual
-muSLO       float64
-targetEps   float64
-winSize     int
-violWin     []int
-winIdx      int
-eta         float64
-lastUpdate  time.Time
+// SLO primal-dual, one multiplier and violation window per service class (slo.go)
+sloClasses  map[string]*sloClass
+sloAssign   map[string]string
+sloDefault  string
 updateEvery time.Duration
 
-// Admission/Quota
+// observed outcomes (ReportOutcome) and the fallback deadline for them
+outcomes       outcomeTable
+outcomeTimeout time.Duration
+
+// effective config and its provenance (config.go)
+config configState
+
+// time and randomness (clock.go); manual is set in deterministic mode
+clock      clock
+manual     *manualClock
+rng        *lockedRand
+lastGovern time.Time
 }
 
+// fairnessPenalty is the penalty tenant would incur if charged for a; it does
+// not update the EWMA (that happens in chargeTenant once the outcome is known).
 func (s *deciderServer) fairnessPenalty(tenant string, a string) float64 {
 s.mu.Lock()
 defer s.mu.Unlock()
-if s.tenantEWMA == nil {
-s.tenantEWMA = make(map[string]float64)
-}
-ri := resourceIntensity(a)
-prev := s.tenantEWMA[tenant]
-newv := s.ewmaAlpha*ri + (1.0-s.ewmaAlpha)*prev
-if prev == 0 {
-newv = ri
-}
-s.tenantEWMA[tenant] = newv
-sum := 0.0
-for _, v := range s.tenantEWMA {
+newv := s.nextEWMALocked(tenant, resourceIntensity(a))
+// summed in sorted order: map order would make the float sum, and so
+// decisions, differ between otherwise identical runs
+others := make([]float64, 0, len(s.tenantEWMA))
+for t, v := range s.tenantEWMA {
+if t != tenant {
+others = append(others, v)
+}
+}
+sort.Float64s(others)
+sum := newv
+n := 1
+for _, v := range others {
 sum += v
+n++
 }
-mean := sum / float64(len(s.tenantEWMA))
+mean := sum / float64(n)
 over := newv - mean
 if over <= 0 {
 return 0
@@ -144,35 +171,24 @@ return 0
 return s.fairGammaMs * over
 }
 
-func (s *deciderServer) recordViolation(v int) {
-s.mu.Lock()
-defer s.mu.Unlock()
-if len(s.violWin) == 0 {
-s.violWin = make([]int, s.winSize)
-}
-s.violWin[s.winIdx%len(s.violWin)] = v
-s.winIdx++
-now := time.Now()
-if now.Sub(s.lastUpdate) >= s.updateEvery {
-rate := s.currentViolRateLocked()
-s.muSLO = math.Max(0, s.muSLO+s.eta*(rate-s.targetEps))
-s.lastUpdate = now
+func (s *deciderServer) nextEWMALocked(tenant string, ri float64) float64 {
+prev := s.tenantEWMA[tenant]
+if prev == 0 {
+return ri
 }
+return s.ewmaAlpha*ri + (1.0-s.ewmaAlpha)*prev
 }
 
-func (s *deciderServer) currentViolRateLocked() float64 {
-if len(s.violWin) == 0 {
-return 0
-}
-sum := 0
-for _, x := range s.violWin {
-sum += x
+// chargeTenant folds the resources used by action a (scaled by usage) into the tenant's EWMA.
+func (s *deciderServer) chargeTenant(tenant string, a string, usage float64) {
+s.mu.Lock()
+defer s.mu.Unlock()
+if s.tenantEWMA == nil {
+s.tenantEWMA = make(map[string]float64)
 }
-return float64(sum) / float64(len(s.violWin))
+s.tenantEWMA[tenant] = s.nextEWMALocked(tenant, resourceIntensity(a)*usage)
 }
 
-type scored struct{ action string; u float64 }
-
 func capacityFactor() float64 {
 edgesUp := 1
 if v := strings.TrimSpace(os.Getenv("CSN_EDGES_UP")); v != "" {
@@ -190,250 +206,365 @@ return math.Max(0.3, 1.0-coef*float64(edgesUp-1))
 }
 
 func (s *deciderServer) Decide(ctx context.Context, req *pb.DecideRequest) (*pb.DecideReply, error) {
- 	// quota admission: deny if tenant exceeds credits
-		t := req.Ctx.GetTenantId()
-		if t == "" { t = "default" }
-		if s.quota != nil && !s.quota.allow(t, 1.0) {
-			return &pb.DecideReply{ChosenAction: "local:low", Explore: false}, nil
-		}
-// --- Admission/Quota: deny early if tenant exceeds credits ---
+if err := s.syncClock(ctx); err != nil {
+return nil, status.Error(codes.InvalidArgument, err.Error())
+}
+// actions must come from the catalog; nothing is remapped
+if err := actionCatalog.Check(req.FeasibleActions); err != nil {
+return nil, status.Error(codes.InvalidArgument, err.Error())
+}
+s.drift.observeContext(req.Ctx)
+
+// --- Admission/Quota: deny early if the tenant cannot afford even the cheapest action ---
 tenantID := req.Ctx.GetTenantId()
 if tenantID == "" {
 tenantID = "default"
 }
-if s.quota != nil && !s.quota.allow(tenantID, 1.0) {
-fallback := "local:low"
-if len(req.FeasibleActions) > 0 {
-fallback = req.FeasibleActions[0]
+if s.quota != nil {
+if ok, retry := s.quota.admit(tenantID, quotaCost(req.FeasibleActions)); !ok {
+return s.denyQuota(tenantID, req.FeasibleActions, retry)
 }
-return &pb.DecideReply{ChosenAction: fallback, Explore: false}, nil
 }
 
-// circuit breaker flag (value unused; allows breaker to gate predictor below)
-_ = s.brk != nil && !s.brk.allow()
-
 bestAction := ""
 bestU := math.Inf(-1)
+var tr decisionTrace
 
 cctx, cancel := context.WithTimeout(ctx, 600*time.Millisecond)
 defer cancel()
 
-jitter := func() float64 { return mrand.NormFloat64() * 0.5 }
-
-scores := make([]scored, 0, len(req.FeasibleActions))
-type obs struct{ a string; p95, slo float64 }
-observed := make([]obs, 0, len(req.FeasibleActions))
+cands := make([]candidate, 0, len(req.FeasibleActions))
+x := banditFeatures(req.Ctx)
 
 cf := 1.0
 if capPoller != nil {
 cf = capPoller.Factor()
 }
+// the ID is drawn up front: request-unit experiments hash it
+decisionID := s.newDecisionID()
 
-for _, a := range req.FeasibleActions {
-// circuit breaker: prefer cheapest/local when open
-if s.brk != nil && !s.brk.allow() {
-if bestAction == "" {
-bestAction = a
-}
+s.mu.Lock()
+pol, pinned := s.policies.pinned(tenantID, req.Ctx.GetAppId())
+alphaBase := s.alphaSLOBase
+lambdaEnergy, exploreStdCap := s.lambdaEnergy, s.exploreStdCap
+exp := s.experiment
+var arm *abArm
+if !pinned {
+pol = s.policies.def
+if arm = exp.assign(tenantID, req.Ctx.GetAppId(), decisionID); arm != nil {
+pol = arm.v.pol
+tr.arm = arm.v.name
+if arm.v.alphaSLOBase != nil {
+alphaBase = *arm.v.alphaSLOBase
+}
+}
+}
+class := s.sloClassLocked(tenantID, req.Ctx.GetAppId())
+alphaEff := alphaBase + class.muSLO
+tr.muSLO = class.muSLO
+tr.sloClass = class.name
+tr.epsilon = s.epsilon
+useConformal := s.useConformal
+propSamples := s.propensitySamples
+shadows := s.shadows
+s.mu.Unlock()
+tr.capFactor = cf
+
+// circuit breakers drop actions whose predictor is failing; when nothing is
+// left the degraded heuristic decides (reason "degraded")
+s.cache.observeCapacity(cf)
+feasible, down := upActions(req.FeasibleActions)
+tr.sitesDown = down
+preds, blocked := s.predictAll(cctx, req.Ctx, feasible)
+tr.breakerOpen = blocked
+
+for i, resp := range preds {
+if resp == nil {
 continue
 }
-
-resp, err := s.predictor.Predict(cctx, &pb.PredictRequest{Ctx: req.Ctx, Action: a})
-if err != nil {
-if s.brk != nil {
-s.brk.onFailure()
+a := feasible[i]
+af := actionCapFactor(a, cf)
+c := candidate{
+action:    a,
+muLat:     float64(resp.MuLatencyMs),
+varLat:    math.Max(1e-9, float64(resp.VarLatency)),
+muEn:      float64(resp.MuEnergyJ),
+slo:       float64(req.Ctx.SloP95Ms),
+costMs:    actionCostMsWithCap(a, actionCostMs(a), af),
+capFactor: af,
+alphaEff:  alphaEff,
+
+lambdaEnergy:  lambdaEnergy,
+exploreStdCap: exploreStdCap,
+energyWeight:  lambdaEnergy,
+}
+if actionCatalog.Get(a).Kind == "edge" {
+c.muLat = c.muLat * af
+}
+if useConformal {
+c.p95eff = float64(resp.P95ConformalMs)
+} else {
+c.p95eff = c.muLat + 1.645*math.Sqrt(c.varLat)
 }
-continue
+c.p95Conf = float64(resp.P95ConformalMs)
+c.predLat, c.predEn = c.muLat, c.muEn
+if s.bandit.enabled() {
+s.bandit.adjust(&c, x)
 }
-if s.brk != nil {
-s.brk.onSuccess()
+cands = append(cands, c)
 }
 
-mLat := float64(resp.MuLatencyMs)
-vLat := math.Max(1e-9, float64(resp.VarLatency))
-mEn := float64(resp.MuEnergyJ)
-p95c := float64(resp.P95ConformalMs)
-slo := float64(req.Ctx.SloP95Ms)
-
-if k, _ := parseKindTier(a); k == "edge" {
-mLat = mLat * cf
+for i := range cands {
+cands[i].u = pol.Score(&cands[i])
+if cands[i].u > bestU {
+bestU = cands[i].u
+bestAction = cands[i].action
 }
-
-stdL := math.Min(math.Sqrt(vLat), s.exploreStdCap)
-latSample := mLat + mrand.NormFloat64()*stdL
-enSample := mEn
-
-var p95eff float64
-if s.useConformal {
-p95eff = p95c
-} else {
-p95eff = mLat + 1.645*math.Sqrt(vLat)
 }
 
-sloPenalty := math.Max(0, p95eff-slo)
-baseCost := actionCostMs(a)
-capF := 1.0
-if capPoller != nil {
-capF = capPoller.Factor()
-}
-costMs := actionCostMsWithCap(a, baseCost, capF)
-alphaEff := s.alphaSLOBase + s.muSLO
-
-U := -(latSample + s.lambdaEnergy*enSample + alphaEff*sloPenalty + costMs) + jitter()
-
-scores = append(scores, scored{action: a, u: U})
-observed = append(observed, obs{a: a, p95: p95eff, slo: slo})
-
-if U > bestU {
-bestU = U
-bestAction = a
-}
-}
+tr.argmax = bestAction
 
 // ε-greedy
-if len(scores) > 1 && mrand.Float64() < s.epsilon {
-idx := mrand.Intn(len(scores))
-for scores[idx].action == bestAction && len(scores) > 1 {
-idx = mrand.Intn(len(scores))
+if pol.Adaptive() && len(cands) > 1 && s.rng.Float64() < tr.epsilon {
+idx := s.rng.Intn(len(cands))
+for cands[idx].action == bestAction && len(cands) > 1 {
+idx = s.rng.Intn(len(cands))
 }
-bestAction = scores[idx].action
+bestAction = cands[idx].action
+tr.epsChanged = true
 }
 
-if bestAction == "" && len(req.FeasibleActions) > 0 {
-bestAction = req.FeasibleActions[0]
+// degraded mode: no prediction at all, fall back to the rule-based heuristic
+// over the actions at up sites (tr.sitesDown already lists the rest)
+if bestAction == "" && len(feasible) > 0 {
+bestAction = degradedChoice(req.Ctx, feasible)
+tr.degraded = true
+cause := "predictor"
+if tr.breakerOpen {
+cause = "breaker"
+}
+mDegraded.WithLabelValues(cause).Inc()
 }
 
 // fairness recheck
-fpen := s.fairnessPenalty(tenantID, bestAction)
-if fpen > 0 && len(scores) > 1 {
+fpen := 0.0
+if pol.Adaptive() {
+fpen = s.fairnessPenalty(tenantID, bestAction)
+}
+if fpen > 0 && len(cands) > 1 {
 chosenU := math.Inf(-1)
-for _, sc := range scores {
-if sc.action == bestAction {
-chosenU = sc.u - fpen
+for _, c := range cands {
+if c.action == bestAction {
+chosenU = c.u - fpen
 break
 }
 }
-for _, sc := range scores {
-if sc.action == bestAction {
+for _, c := range cands {
+if c.action == bestAction {
 continue
 }
-if sc.u > chosenU {
-bestAction = sc.action
+if c.u > chosenU {
+bestAction = c.action
+tr.fairChanged = true
 break
 }
 }
 }
 
-// update violation window
-for _, o := range observed {
-if o.a == bestAction {
-v := 0
-if o.p95 > o.slo {
-v = 1
-}
-s.recordViolation(v)
+// bill the quota by what the chosen action consumes
+if s.quota != nil && bestAction != "" {
+s.quota.charge(tenantID, resourceIntensity(bestAction))
+}
+
+// the violation window and fairness EWMA are updated once the outcome is
+// reported (or times out), see outcome.go
+tr.chosen = bestAction
+pd := &pendingDecision{
+id:      decisionID,
+tenant:  tenantID,
+action:  bestAction,
+policy:  pol,
+class:   tr.sloClass,
+x:       x,
+slo:     float64(req.Ctx.GetSloP95Ms()),
+created: s.now(),
+}
+// propensity of the returned action; the degraded heuristic is deterministic
+tr.propensity = 1
+probs, method := s.selectionPropensity(pol, cands, tr.epsilon, tenantID, propSamples)
+tr.propMethod = method
+var chosen *candidate
+for i, c := range cands {
+if c.action == bestAction {
+tr.propensity = probs[i]
+tr.explore = i != modalCandidate(probs)
+chosen = &cands[i]
+pd.predicted = true
+pd.muLat, pd.muEn, pd.p95eff = c.muLat, c.muEn, c.p95eff
+pd.predLat, pd.predEn = c.predLat, c.predEn
 break
 }
 }
+if arm != nil {
+exp.recordDecision(arm, chosen)
+pd.exp, pd.arm = exp, arm
+}
+tr.shadows = evaluateShadows(shadows, cands, chosen, tr.muSLO)
+mPropensity.Observe(tr.propensity)
+s.auditDecision(decisionID, req, pol, cands, probs, tr)
+s.trackDecision(decisionID, pd)
+
+// batched delivery to the sensing service (sense.go)
+s.telemetry.enqueue(newSensePayload(decisionID, tenantID, req.Ctx, bestAction, chosen))
 
-// optional sensing hook (no-op if not present)
-postSense(req.Ctx, bestAction)
+reply := &pb.DecideReply{ChosenAction: bestAction, Explore: tr.explore, DecisionId: decisionID, Reason: tr.reason(), Degraded: tr.degraded, Arm: tr.arm, Propensity: tr.propensity}
+if req.GetExplain() {
+reply.Breakdown = s.explain(tenantID, cands)
+}
+return reply, nil
+}
 
-return &pb.DecideReply{ChosenAction: bestAction, Explore: true}, nil
+// explain breaks each candidate's utility into its terms, with the energy
+// weight of the policy that scored it (an arm's lambda_energy, say). Policies
+// other than csn leave latSample/jitter unset, so the breakdown shows the mean
+// instead.
+func (s *deciderServer) explain(tenant string, cands []candidate) []*pb.ActionBreakdown {
+out := make([]*pb.ActionBreakdown, 0, len(cands))
+for _, c := range cands {
+lat := c.latSample
+if lat == 0 {
+lat = c.muLat
+}
+out = append(out, &pb.ActionBreakdown{
+Action:          c.action,
+LatencySampleMs: lat,
+EnergyTerm:      c.energyWeight * c.muEn,
+SloPenalty:      c.alphaEff * c.sloPenalty(),
+AlphaEff:        c.alphaEff,
+CostMs:          c.costMs,
+FairnessPenalty: s.fairnessPenalty(tenant, c.action),
+Jitter:          c.jitter,
+Utility:         c.u,
+})
+}
+return out
 }
 
 // --- main --------------------------------------------------------------------
 
 func main() {
-// strong RNG seed
-var b [8]byte
-if _, err := rand.Read(b[:]); err == nil {
-mrand.Seed(int64(binary.LittleEndian.Uint64(b[:])))
-} else {
-mrand.Seed(time.Now().UnixNano())
+cfgPath := flag.String("config", "", "Decider config file (.yaml/.yml or .json); CSN_* env vars override it")
+flag.Parse()
+
+// defaults < -config file < env overrides (see config.go)
+cfg, src, err := loadConfig(*cfgPath)
+if err != nil {
+log.Fatalf("config: %v", err)
 }
 
-useConf := true
-if v := strings.TrimSpace(os.Getenv("CSN_USE_CONFORMAL")); v != "" {
-if v == "0" || strings.ToLower(v) == "false" || v == "off" {
-useConf = false
+// system clock and a random seed, or the replayable clock and seed of
+// deterministic mode (clock.go)
+clk, manual, rng, err := clockFromConfig(cfg)
+if err != nil {
+log.Fatalf("config: %v", err)
 }
+
+// action catalog shared with the predictor proxy and the invoker tools
+if actionCatalog, err = loadCatalog(cfg.CatalogPath); err != nil {
+log.Fatalf("catalog: %v", err)
 }
 
 // predictor proxy
-conn, err := grpc.Dial("127.0.0.1:7001", grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(2*time.Second))
+conn, err := grpc.Dial(cfg.PredictorAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(2*time.Second))
 if err != nil {
 log.Fatalf("connect predictor: %v", err)
 }
 defer conn.Close()
 pred := pb.NewPredictorClient(conn)
 
-// capacity poller from Operator metrics
-opURL := strings.TrimSpace(os.Getenv("OP_METRICS_URL"))
-if opURL == "" {
-opURL = "http://127.0.0.1:9103/metrics"
-}
-coef := 0.15
-if v := strings.TrimSpace(os.Getenv("CSN_EDGE_CAP_COEF")); v != "" {
-if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
-coef = f
-}
-}
-capPoller = NewCapPoller(opURL, coef, 0.3)
+// capacity poller from Operator metrics; live load cannot be replayed, so
+// deterministic mode runs at the base factor
+if manual == nil {
+capPoller = NewCapPoller(cfg.CapMetricsURL, capParamsFromConfig(cfg))
 capPoller.Start()
+registerCapacityHandlers(capPoller)
+}
 
 // metrics + control HTTP (lagrange.go registers handlers on default mux)
 go func() {
 http.Handle("/metrics", promhttp.Handler())
-log.Println("Metrics server on :9102")
-if err := http.ListenAndServe(":9102", nil); err != nil {
+log.Printf("Metrics server on %s", cfg.MetricsListen)
+if err := http.ListenAndServe(cfg.MetricsListen, nil); err != nil {
 log.Printf("metrics server error: %v", err)
 }
 }()
 
 // gRPC listener
-lis, err := net.Listen("tcp", ":7002")
+lis, err := net.Listen("tcp", cfg.Listen)
 if err != nil {
 log.Fatalf("listen: %v", err)
 }
 
-// decider config
+// decider state; tunables are filled in by applyConfig below
 s := grpc.NewServer()
 ds := &deciderServer{
-predictor:     pred,
-lambdaEnergy:  80.0,
-alphaSLOBase:  2.0,
-exploreStdCap: 8.0,
-epsilon:       0.10,
-useConformal:  useConf,
+predictor: pred,
+clock:     clk,
+manual:    manual,
+rng:       rng,
 
-tenantEWMA:  make(map[string]float64),
-ewmaAlpha:   0.3,
-fairGammaMs: 10.0,
+tenantEWMA: make(map[string]float64),
 
-muSLO:       0.0,
-targetEps:   0.10,
-winSize:     50,
-violWin:     make([]int, 50),
-winIdx:      0,
-eta:         5.0,
-lastUpdate:  time.Now(),
-updateEvery: 5 * time.Second,
+// circuit breaker and per-tenant admission/quota
+breakers:      newBreakerSet(breakerLimitsFromConfig(cfg), cfg.BreakerPerAction, clk),
+predictorAddr: cfg.PredictorAddr,
+quota:         newQuotaManager(cfg.QuotaRate, cfg.QuotaBurst, clk),
 }
+ds.config.path = *cfgPath
 
-// circuit breaker: 5 consecutive failures -> 10s open
-ds.brk = newBreaker(5, 10*time.Second)
+// contextual bandit over predictor residuals (bandit.mode=lints|linucb|off)
+ds.bandit = banditFromConfig(cfg, rng)
+registerBanditHandlers(ds.bandit)
 
-// Admission/Quota: 50 rps, burst 100 per-tenant
-ds.quota = newQuotaManager(50.0, 100.0)
+// prediction cache (predcache.size=0 disables)
+pc, err := predCacheFromConfig(cfg, clk)
+if err != nil {
+log.Fatalf("prediction cache config: %v", err)
+}
+ds.cache = pc
+
+// decision audit log (audit.path="off" disables)
+ds.audit = auditLogFromConfig(cfg, clk)
+// decision telemetry (telemetry.url="off" disables)
+ds.telemetry = senseQueueFromConfig(cfg)
+// drift against the training telemetry (drift.baseline_path)
+ds.drift = driftFromConfig(cfg)
+// retraining hook (retrain.hook="off" disables)
+ds.retrain = newRetrainer(ds, retrainParamsFromConfig(cfg))
+
+// objective, fairness, SLO and scoring policies
+if err := ds.applyConfig(cfg, src); err != nil {
+log.Fatalf("config: %v", err)
+}
+registerConfigHandlers(ds)
+registerLagrangeHandlers(ds)
+registerBreakerHandlers(ds.breakers)
+registerDriftHandlers(ds.drift)
+registerRetrainHandlers(ds.retrain)
+registerExperimentHandlers(ds)
+ds.watchSIGHUP()
 
 // exploration governor (exports csn_explore_epsilon)
- 	// admission/quota: 50 rps, burst 100 tokens per-tenant
-		ds.quota = newQuotaManager(50.0, 100.0)
 ds.startExplorationGovernor()
+ds.startOutcomeReaper()
+ds.drift.start(time.Duration(cfg.DriftEvalMs) * time.Millisecond)
+ds.retrain.start()
 
 pb.RegisterDeciderServer(s, ds)
-fmt.Printf("Decider listening on :7002 (TS+e+fairness+SLO+AQ) useConformal=%v\n", useConf)
+if manual != nil {
+log.Printf("deterministic mode: seed=%d start=%s step=%dms; capacity poller off", cfg.DetSeed, cfg.DetStart, cfg.DetStepMs)
+}
+fmt.Printf("Decider listening on %s (TS+e+fairness+SLO+AQ) useConformal=%v\n", cfg.Listen, cfg.UseConformal)
 if err := s.Serve(lis); err != nil {
 log.Fatalf("serve: %v", err)
 }
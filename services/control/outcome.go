@@ -0,0 +1,214 @@
+package main
+
+import (
+"context"
+"crypto/rand"
+"encoding/hex"
+"math"
+"sort"
+"sync"
+"time"
+
+"github.com/prometheus/client_golang/prometheus"
+"google.golang.org/grpc/codes"
+"google.golang.org/grpc/status"
+
+pb "github.com/mulat/csn/proto"
+)
+
+var (
+mOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_outcomes_total",
+Help: "Resolved decision outcomes by source (reported|timeout)",
+}, []string{"source"})
+mOutcomeUnknown = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_outcome_unknown_total",
+Help: "Outcome reports for unknown or already expired decision IDs",
+})
+mPendingDecisions = prometheus.NewGauge(prometheus.GaugeOpts{
+Name: "csn_pending_decisions",
+Help: "Decisions waiting for an observed outcome",
+})
+)
+
+func init() {
+prometheus.MustRegister(mOutcomes, mOutcomeUnknown, mPendingDecisions)
+}
+
+// maxPending bounds the outcome table; beyond it decisions resolve immediately
+// from their predictions.
+const maxPending = 100000
+
+// pendingDecision is what Decide knew about the chosen action; it is resolved
+// by ReportOutcome or, after outcomeTimeout, from the prediction itself.
+type pendingDecision struct {
+id        string
+tenant    string
+action    string
+policy    Policy
+exp       *experiment // with arm: the experiment arm that served the decision
+arm       *abArm
+class     string // SLO class whose window the outcome counts against
+predicted bool // false when no prediction was available (breaker open)
+x         []float64 // bandit features of the request context
+muLat     float64
+muEn      float64
+predLat   float64 // raw predictor output, for bandit residuals
+predEn    float64
+p95eff    float64
+slo       float64
+created   time.Time
+}
+
+type outcomeTable struct {
+mu      sync.Mutex
+pending map[string]*pendingDecision
+}
+
+// newDecisionID is random, or drawn from the seeded source in deterministic
+// mode so replays hand out the same IDs.
+func (s *deciderServer) newDecisionID() string {
+var b [16]byte
+if s.manual != nil {
+s.rng.Read(b[:])
+} else if _, err := rand.Read(b[:]); err != nil {
+return time.Now().Format("20060102150405.000000000")
+}
+return hex.EncodeToString(b[:])
+}
+
+func (s *deciderServer) trackDecision(id string, p *pendingDecision) {
+s.outcomes.mu.Lock()
+if s.outcomes.pending == nil {
+s.outcomes.pending = make(map[string]*pendingDecision)
+}
+if len(s.outcomes.pending) >= maxPending {
+s.outcomes.mu.Unlock()
+s.resolveFromPrediction(p)
+return
+}
+s.outcomes.pending[id] = p
+n := len(s.outcomes.pending)
+s.outcomes.mu.Unlock()
+mPendingDecisions.Set(float64(n))
+}
+
+func (s *deciderServer) takeDecision(id string) *pendingDecision {
+s.outcomes.mu.Lock()
+defer s.outcomes.mu.Unlock()
+p, ok := s.outcomes.pending[id]
+if !ok {
+return nil
+}
+delete(s.outcomes.pending, id)
+mPendingDecisions.Set(float64(len(s.outcomes.pending)))
+return p
+}
+
+func (s *deciderServer) ReportOutcome(ctx context.Context, req *pb.OutcomeRequest) (*pb.OutcomeReply, error) {
+if err := s.syncClock(ctx); err != nil {
+return nil, status.Error(codes.InvalidArgument, err.Error())
+}
+if req.GetDecisionId() == "" {
+return nil, status.Error(codes.InvalidArgument, "decision_id is required")
+}
+if req.GetObservedLatencyMs() < 0 || req.GetObservedEnergyJ() < 0 {
+return nil, status.Error(codes.InvalidArgument, "observed values must be non-negative")
+}
+p := s.takeDecision(req.GetDecisionId())
+if p == nil {
+mOutcomeUnknown.Inc()
+return &pb.OutcomeReply{Accepted: false}, nil
+}
+lat := req.GetObservedLatencyMs()
+v := 0
+if lat > p.slo {
+v = 1
+}
+s.recordViolation(p.class, v)
+if p.arm != nil {
+p.exp.recordOutcome(p.arm, lat, req.GetObservedEnergyJ(), v == 1)
+}
+// charge fairness by how much the action actually consumed relative to its prediction
+usage := 1.0
+if p.predicted && p.muLat > 0 {
+usage = math.Min(2.0, math.Max(0.5, lat/p.muLat))
+}
+s.chargeTenant(p.tenant, p.action, usage)
+if p.predicted {
+s.drift.observeResidual(lat-p.predLat, req.GetObservedEnergyJ()-p.predEn)
+}
+if s.bandit != nil && p.predicted {
+s.bandit.observe(p.action, p.x, lat-p.predLat, req.GetObservedEnergyJ()-p.predEn)
+}
+if o, ok := p.policy.(outcomeObserver); ok {
+o.observe(p.action, lat, req.GetObservedEnergyJ())
+}
+mOutcomes.WithLabelValues("reported").Inc()
+en := req.GetObservedEnergyJ()
+s.audit.write(&auditRecord{Kind: "outcome", DecisionID: p.id, Source: "reported", ObservedLatencyMs: &lat, ObservedEnergyJ: &en})
+return &pb.OutcomeReply{Accepted: true}, nil
+}
+
+// resolveFromPrediction is the fallback when no outcome arrives in time.
+func (s *deciderServer) resolveFromPrediction(p *pendingDecision) {
+if p.predicted {
+v := 0
+if p.p95eff > p.slo {
+v = 1
+}
+s.recordViolation(p.class, v)
+}
+s.chargeTenant(p.tenant, p.action, 1.0)
+mOutcomes.WithLabelValues("timeout").Inc()
+s.audit.write(&auditRecord{Kind: "outcome", DecisionID: p.id, Source: "timeout"})
+}
+
+// reapExpired resolves decisions whose outcome did not arrive within
+// outcomeTimeout of now, oldest first.
+func (s *deciderServer) reapExpired(now time.Time) {
+s.mu.Lock()
+cutoff := now.Add(-s.outcomeTimeout)
+s.mu.Unlock()
+var expired []*pendingDecision
+s.outcomes.mu.Lock()
+for id, p := range s.outcomes.pending {
+if p.created.Before(cutoff) {
+expired = append(expired, p)
+delete(s.outcomes.pending, id)
+}
+}
+n := len(s.outcomes.pending)
+s.outcomes.mu.Unlock()
+mPendingDecisions.Set(float64(n))
+sort.Slice(expired, func(i, j int) bool {
+if !expired[i].created.Equal(expired[j].created) {
+return expired[i].created.Before(expired[j].created)
+}
+return expired[i].id < expired[j].id
+})
+for _, p := range expired {
+s.resolveFromPrediction(p)
+}
+}
+
+// startOutcomeReaper runs reapExpired periodically outside deterministic mode
+// (syncClock runs it otherwise).
+func (s *deciderServer) startOutcomeReaper() {
+if s.manual != nil {
+return
+}
+s.mu.Lock()
+every := s.outcomeTimeout / 4
+s.mu.Unlock()
+if every < 100*time.Millisecond {
+every = 100 * time.Millisecond
+}
+go func() {
+t := time.NewTicker(every)
+defer t.Stop()
+for range t.C {
+s.reapExpired(s.now())
+}
+}()
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+"fmt"
+"math"
+"sort"
+"strconv"
+"strings"
+"sync"
+)
+
+// candidate is one feasible action with everything a policy may score on.
+type candidate struct {
+action   string
+muLat    float64 // capacity-adjusted mean latency
+varLat   float64
+muEn     float64
+predLat  float64 // muLat/muEn before the bandit correction
+predEn   float64
+sampled  bool    // muLat is already a bandit sample/optimistic estimate
+tsBase   float64 // LinTS: muLat is max(0, tsBase + tsStd·N(0,1)); tsStd 0 otherwise
+tsStd    float64
+p95eff   float64 // conformal or Gaussian p95, depending on useConformal
+p95Conf  float64 // conformal p95 as returned by the predictor
+slo      float64
+costMs   float64 // actionCostMsWithCap
+capFactor float64 // site capacity factor applied to muLat/costMs
+alphaEff float64 // alphaSLOBase + muSLO
+u        float64 // score assigned by the policy
+
+// live objective weights, copied under s.mu when Decide builds the candidate
+lambdaEnergy  float64
+exploreStdCap float64
+
+// utility terms, filled by utilityPolicy (explain mode); energyWeight
+// starts at the live lambda and takes a variant's override when scored
+latSample    float64
+jitter       float64
+energyWeight float64
+}
+
+func (c *candidate) sloPenalty() float64 { return math.Max(0, c.p95eff-c.slo) }
+
+// Policy scores candidates; Decide picks the highest score.
+type Policy interface {
+Name() string
+Score(c *candidate) float64
+// Adaptive policies get ε-greedy exploration and the fairness recheck on top.
+Adaptive() bool
+}
+
+// outcomeObserver is implemented by policies that learn from ReportOutcome.
+type outcomeObserver interface {
+observe(action string, latMs, enJ float64)
+}
+
+// --- utility (default) -------------------------------------------------------
+
+// utilityPolicy is the original CSN objective: sampled latency + weighted
+// energy + SLO penalty + action cost, with a little jitter to break ties.
+// lambdaEnergy overrides the server's weight (shadow policies, shadow.go);
+// rng replaces s.rng (propensity re-draws).
+type utilityPolicy struct {
+s            *deciderServer
+lambdaEnergy *float64
+rng          *lockedRand
+}
+
+func (p utilityPolicy) Name() string   { return "csn" }
+func (p utilityPolicy) Adaptive() bool { return true }
+func (p utilityPolicy) Score(c *candidate) float64 {
+rng := p.s.rng
+if p.rng != nil {
+rng = p.rng
+}
+c.latSample = c.muLat
+if !c.sampled {
+stdL := math.Min(math.Sqrt(c.varLat), c.exploreStdCap)
+c.latSample += rng.NormFloat64() * stdL
+}
+c.jitter = rng.NormFloat64() * 0.5
+lambda := c.lambdaEnergy
+if p.lambdaEnergy != nil {
+lambda = *p.lambdaEnergy
+}
+c.energyWeight = lambda
+return -(c.latSample + lambda*c.muEn + c.alphaEff*c.sloPenalty() + c.costMs) + c.jitter
+}
+
+// --- baselines ----------------------------------------------------------------
+
+type greedyLatencyPolicy struct{}
+
+func (greedyLatencyPolicy) Name() string               { return "greedy_latency" }
+func (greedyLatencyPolicy) Adaptive() bool             { return false }
+func (greedyLatencyPolicy) Score(c *candidate) float64 { return -c.muLat }
+
+type minEnergyPolicy struct{}
+
+func (minEnergyPolicy) Name() string   { return "min_energy" }
+func (minEnergyPolicy) Adaptive() bool { return false }
+
+// Score prefers lower energy; latency only breaks ties.
+func (minEnergyPolicy) Score(c *candidate) float64 { return -c.muEn - 1e-6*c.muLat }
+
+// staticPolicy always picks one action; if it is not feasible the first feasible action wins.
+type staticPolicy struct{ action string }
+
+func (p staticPolicy) Name() string   { return "static:" + p.action }
+func (p staticPolicy) Adaptive() bool { return false }
+func (p staticPolicy) Score(c *candidate) float64 {
+if c.action == p.action {
+return 1
+}
+return 0
+}
+
+// ucbPolicy is UCB1 over observed per-action cost (latency + lambda*energy),
+// using the prediction as the prior until an action has outcomes.
+// lambdaEnergy overrides the server's weight (variants); nil follows reloads.
+type ucbPolicy struct {
+s            *deciderServer
+c            float64 // exploration bonus scale (ms)
+lambdaEnergy *float64
+
+mu    sync.Mutex
+n     map[string]float64
+mean  map[string]float64
+total float64
+}
+
+func newUCBPolicy(s *deciderServer, c float64) *ucbPolicy {
+return &ucbPolicy{s: s, c: c, n: make(map[string]float64), mean: make(map[string]float64)}
+}
+
+// lambda is the energy weight in effect; the live one is read under s.mu
+// (observe has no candidate to take it from).
+func (p *ucbPolicy) lambda() float64 {
+if p.lambdaEnergy != nil {
+return *p.lambdaEnergy
+}
+p.s.mu.Lock()
+defer p.s.mu.Unlock()
+return p.s.lambdaEnergy
+}
+
+func (p *ucbPolicy) Name() string   { return "ucb" }
+func (p *ucbPolicy) Adaptive() bool { return false }
+func (p *ucbPolicy) Score(c *candidate) float64 {
+lambda := c.lambdaEnergy
+if p.lambdaEnergy != nil {
+lambda = *p.lambdaEnergy
+}
+c.energyWeight = lambda
+p.mu.Lock()
+defer p.mu.Unlock()
+n := p.n[c.action]
+cost := c.muLat + lambda*c.muEn
+if n > 0 {
+cost = p.mean[c.action]
+}
+bonus := p.c * math.Sqrt(2*math.Log(p.total+1)/(n+1))
+return -cost + bonus
+}
+
+func (p *ucbPolicy) observe(action string, latMs, enJ float64) {
+cost := latMs + p.lambda()*enJ
+p.mu.Lock()
+defer p.mu.Unlock()
+p.n[action]++
+p.total++
+p.mean[action] += (cost - p.mean[action]) / p.n[action]
+}
+
+// --- selection ----------------------------------------------------------------
+
+// policySet maps "tenant/app" or "tenant" to a policy, with a default.
+type policySet struct {
+def      Policy
+byTenant map[string]Policy
+}
+
+// pinned is the explicit tenant/app or tenant mapping, if any; pinned traffic
+// stays out of experiments.
+func (ps *policySet) pinned(tenant, app string) (Policy, bool) {
+if p, ok := ps.byTenant[tenant+"/"+app]; ok {
+return p, true
+}
+p, ok := ps.byTenant[tenant]
+return p, ok
+}
+
+// newPolicy builds a policy by name: csn, greedy_latency, min_energy, static:<action>, ucb.
+// Stateful policies are shared per name so tenants mapped to "ucb" learn together.
+func (s *deciderServer) newPolicy(name string, shared map[string]Policy) (Policy, error) {
+if p, ok := shared[name]; ok {
+return p, nil
+}
+var p Policy
+switch {
+case name == "" || name == "csn":
+p = utilityPolicy{s: s}
+case name == "greedy_latency":
+p = greedyLatencyPolicy{}
+case name == "min_energy":
+p = minEnergyPolicy{}
+case strings.HasPrefix(name, "static:"):
+a := strings.TrimPrefix(name, "static:")
+if a == "" {
+return nil, fmt.Errorf("static policy needs an action, e.g. static:edge1:med")
+}
+p = staticPolicy{action: a}
+case name == "ucb":
+p = newUCBPolicy(s, 20.0)
+default:
+return nil, fmt.Errorf("unknown policy %q", name)
+}
+shared[name] = p
+return p, nil
+}
+
+// buildPolicies parses a default policy name and a mapping such as
+// "tenantA=greedy_latency,tenantB/app2=static:edge1:med".
+func (s *deciderServer) buildPolicies(def, mapping string) (*policySet, error) {
+shared := make(map[string]Policy)
+d, err := s.newPolicy(strings.TrimSpace(def), shared)
+if err != nil {
+return nil, err
+}
+ps := &policySet{def: d, byTenant: make(map[string]Policy)}
+for _, kv := range strings.Split(mapping, ",") {
+kv = strings.TrimSpace(kv)
+if kv == "" {
+continue
+}
+i := strings.Index(kv, "=")
+if i <= 0 {
+return nil, fmt.Errorf("bad policy mapping %q (want tenant[/app]=policy)", kv)
+}
+p, err := s.newPolicy(strings.TrimSpace(kv[i+1:]), shared)
+if err != nil {
+return nil, err
+}
+ps.byTenant[strings.TrimSpace(kv[:i])] = p
+}
+return ps, nil
+}
+
+// policiesFromConfig builds the set from policy.default and policy.tenants.
+func (s *deciderServer) policiesFromConfig(c *deciderConfig) (*policySet, error) {
+return s.buildPolicies(c.Policy, joinPairs(c.PolicyTenants))
+}
+
+// policyVariant is a named policy with optional objective overrides, used for
+// shadow policies (shadow.go) and experiment arms (experiment.go).
+type policyVariant struct {
+name         string
+spec         string
+pol          Policy
+alphaSLOBase *float64 // nil: live value
+}
+
+// parseVariant reads "<policy>[;lambda_energy=X][;alpha_slo_base=Y]". Each
+// variant gets its own policy instance, so a variant ucb never shares state
+// with the live one.
+func (s *deciderServer) parseVariant(name, spec string) (*policyVariant, error) {
+parts := strings.Split(spec, ";")
+v := &policyVariant{name: name, spec: spec}
+var lambda *float64
+for _, kv := range parts[1:] {
+kv = strings.TrimSpace(kv)
+i := strings.Index(kv, "=")
+if i <= 0 {
+return nil, fmt.Errorf("bad override %q (want key=value)", kv)
+}
+f, err := strconv.ParseFloat(strings.TrimSpace(kv[i+1:]), 64)
+if err != nil || f < 0 {
+return nil, fmt.Errorf("bad value in %q", kv)
+}
+switch strings.TrimSpace(kv[:i]) {
+case "lambda_energy":
+lambda = &f
+case "alpha_slo_base":
+v.alphaSLOBase = &f
+default:
+return nil, fmt.Errorf("unknown override %q (lambda_energy, alpha_slo_base)", kv[:i])
+}
+}
+pol, err := s.newPolicy(strings.TrimSpace(parts[0]), map[string]Policy{})
+if err != nil {
+return nil, err
+}
+if lambda != nil {
+switch p := pol.(type) {
+case utilityPolicy:
+p.lambdaEnergy = lambda
+pol = p
+case *ucbPolicy:
+p.lambdaEnergy = lambda
+default:
+return nil, fmt.Errorf("policy %s has no lambda_energy", pol.Name())
+}
+}
+v.pol = pol
+return v, nil
+}
+
+// buildVariants parses name -> spec, sorted by name.
+func (s *deciderServer) buildVariants(m map[string]string) ([]*policyVariant, error) {
+names := make([]string, 0, len(m))
+for n := range m {
+names = append(names, n)
+}
+sort.Strings(names)
+out := make([]*policyVariant, 0, len(names))
+for _, n := range names {
+v, err := s.parseVariant(n, m[n])
+if err != nil {
+return nil, fmt.Errorf("%s: %v", n, err)
+}
+out = append(out, v)
+}
+return out, nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+"container/list"
+"fmt"
+"math"
+"strconv"
+"strings"
+"sync"
+"time"
+
+"github.com/prometheus/client_golang/prometheus"
+
+pb "github.com/mulat/csn/proto"
+)
+
+var (
+mCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_predcache_hits_total",
+Help: "Prediction cache hits",
+})
+mCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_predcache_misses_total",
+Help: "Prediction cache misses",
+})
+mCacheEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_predcache_evictions_total",
+Help: "Prediction cache evictions by reason (size|ttl|capacity|config)",
+}, []string{"reason"})
+mCacheEntries = prometheus.NewGauge(prometheus.GaugeOpts{
+Name: "csn_predcache_entries",
+Help: "Entries currently in the prediction cache",
+})
+)
+
+func init() {
+prometheus.MustRegister(mCacheHits, mCacheMisses, mCacheEvictions, mCacheEntries)
+}
+
+// quantField is one context field and the bucket width used for the cache key.
+type quantField struct {
+name string
+get  func(*pb.Context) float64
+step float64 // 0 leaves the field out of the key
+}
+
+func defaultQuant() []quantField {
+return []quantField{
+{"bw_mbps", (*pb.Context).GetBwMbps, 5},
+{"rtt_ms", (*pb.Context).GetRttMs, 5},
+{"loss", (*pb.Context).GetLoss, 0.001},
+{"device_cpu", (*pb.Context).GetDeviceCpu, 0.05},
+{"battery_soc", (*pb.Context).GetBatterySoc, 0},
+{"edge_cpu", (*pb.Context).GetEdgeCpu, 0.05},
+{"input_kb", (*pb.Context).GetInputKb, 32},
+{"slo_p95_ms", (*pb.Context).GetSloP95Ms, 10},
+}
+}
+
+// parseQuant overrides default steps from "bw_mbps=10,input_kb=64".
+func parseQuant(spec string) ([]quantField, error) {
+q := defaultQuant()
+for _, kv := range strings.Split(spec, ",") {
+kv = strings.TrimSpace(kv)
+if kv == "" {
+continue
+}
+parts := strings.SplitN(kv, "=", 2)
+if len(parts) != 2 {
+return nil, fmt.Errorf("bad quantisation %q (want field=step)", kv)
+}
+step, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+if err != nil || step < 0 {
+return nil, fmt.Errorf("bad step in %q", kv)
+}
+found := false
+for i := range q {
+if q[i].name == strings.TrimSpace(parts[0]) {
+q[i].step = step
+found = true
+}
+}
+if !found {
+return nil, fmt.Errorf("unknown context field %q", parts[0])
+}
+}
+return q, nil
+}
+
+type cacheEntry struct {
+key     string
+reply   *pb.PredictReply
+expires time.Time
+}
+
+// predCache is a size-bounded LRU with TTL for predictor replies, keyed by
+// quantised context, action and model version. The predictor routes each
+// tenant/app to one upstream (canary.go), so the version a pair last got is
+// the one its lookups use; entries of a version nobody asks for any more age
+// out instead of the whole cache being flushed. It is flushed whenever the
+// capacity factor changes.
+type predCache struct {
+quant []quantField
+size  int
+ttl   time.Duration
+
+mu       sync.Mutex
+ll       *list.List
+items    map[string]*list.Element
+versions map[string]string // tenant/app -> model version last served
+capF     float64
+clock    clock
+}
+
+func newPredCache(size int, ttl time.Duration, quant []quantField, clk clock) *predCache {
+return &predCache{quant: quant, size: size, ttl: ttl, ll: list.New(), items: make(map[string]*list.Element), versions: make(map[string]string), capF: math.NaN(), clock: clk}
+}
+
+func versionUnit(c *pb.Context) string {
+return c.GetTenantId() + "/" + c.GetAppId()
+}
+
+func (pc *predCache) key(c *pb.Context, action string) string {
+var b strings.Builder
+b.WriteString(pc.versions[versionUnit(c)])
+b.WriteByte('|')
+b.WriteString(action)
+for _, q := range pc.quant {
+if q.step <= 0 {
+continue
+}
+b.WriteByte('|')
+b.WriteString(strconv.FormatInt(int64(math.Floor(q.get(c)/q.step)), 10))
+}
+return b.String()
+}
+
+func (pc *predCache) flushLocked(reason string) {
+if n := pc.ll.Len(); n > 0 {
+mCacheEvictions.WithLabelValues(reason).Add(float64(n))
+}
+pc.ll.Init()
+pc.items = make(map[string]*list.Element)
+pc.versions = make(map[string]string)
+mCacheEntries.Set(0)
+}
+
+// reconfigure applies new size/TTL/quantisation. The cache is flushed when the
+// key layout or TTL changes; a smaller size just evicts the oldest entries.
+func (pc *predCache) reconfigure(size int, ttl time.Duration, quant []quantField) {
+if pc == nil {
+return
+}
+pc.mu.Lock()
+defer pc.mu.Unlock()
+if ttl != pc.ttl || !sameQuant(quant, pc.quant) || size == 0 {
+pc.flushLocked("config")
+}
+pc.size, pc.ttl, pc.quant = size, ttl, quant
+for pc.ll.Len() > pc.size {
+old := pc.ll.Back()
+pc.ll.Remove(old)
+delete(pc.items, old.Value.(*cacheEntry).key)
+mCacheEvictions.WithLabelValues("size").Inc()
+}
+mCacheEntries.Set(float64(pc.ll.Len()))
+}
+
+func sameQuant(a, b []quantField) bool {
+if len(a) != len(b) {
+return false
+}
+for i := range a {
+if a[i].name != b[i].name || a[i].step != b[i].step {
+return false
+}
+}
+return true
+}
+
+// observeCapacity flushes the cache when the capacity factor moved.
+func (pc *predCache) observeCapacity(cf float64) {
+if pc == nil {
+return
+}
+pc.mu.Lock()
+defer pc.mu.Unlock()
+if cf != pc.capF {
+if !math.IsNaN(pc.capF) {
+pc.flushLocked("capacity")
+}
+pc.capF = cf
+}
+}
+
+func (pc *predCache) get(c *pb.Context, action string) *pb.PredictReply {
+if pc == nil {
+return nil
+}
+pc.mu.Lock()
+defer pc.mu.Unlock()
+if pc.size == 0 {
+return nil
+}
+el, ok := pc.items[pc.key(c, action)]
+if !ok {
+mCacheMisses.Inc()
+return nil
+}
+e := el.Value.(*cacheEntry)
+if clockNow(pc.clock).After(e.expires) {
+pc.ll.Remove(el)
+delete(pc.items, e.key)
+mCacheEvictions.WithLabelValues("ttl").Inc()
+mCacheEntries.Set(float64(pc.ll.Len()))
+mCacheMisses.Inc()
+return nil
+}
+pc.ll.MoveToFront(el)
+mCacheHits.Inc()
+return e.reply
+}
+
+func (pc *predCache) put(c *pb.Context, action string, r *pb.PredictReply) {
+if pc == nil || r == nil {
+return
+}
+pc.mu.Lock()
+defer pc.mu.Unlock()
+if pc.size == 0 {
+return
+}
+unit := versionUnit(c)
+if v, ok := pc.versions[unit]; !ok || v != r.GetModelVersion() {
+// one pair per entry at most: forgetting all pairs only costs misses
+if !ok && len(pc.versions) >= pc.size {
+pc.versions = make(map[string]string)
+}
+pc.versions[unit] = r.GetModelVersion()
+}
+k := pc.key(c, action)
+if el, ok := pc.items[k]; ok {
+e := el.Value.(*cacheEntry)
+e.reply, e.expires = r, clockNow(pc.clock).Add(pc.ttl)
+pc.ll.MoveToFront(el)
+return
+}
+pc.items[k] = pc.ll.PushFront(&cacheEntry{key: k, reply: r, expires: clockNow(pc.clock).Add(pc.ttl)})
+for pc.ll.Len() > pc.size {
+old := pc.ll.Back()
+pc.ll.Remove(old)
+delete(pc.items, old.Value.(*cacheEntry).key)
+mCacheEvictions.WithLabelValues("size").Inc()
+}
+mCacheEntries.Set(float64(pc.ll.Len()))
+}
+
+// quantFromConfig overrides default steps from predcache.quant.
+func quantFromConfig(m map[string]float64) ([]quantField, error) {
+return parseQuant(joinPairs(m))
+}
+
+// predCacheFromConfig builds the cache from predcache.size/ttl_ms/quant. A
+// size of 0 keeps the cache disabled until a reload gives it a size.
+func predCacheFromConfig(c *deciderConfig, clk clock) (*predCache, error) {
+q, err := quantFromConfig(c.CacheQuant)
+if err != nil {
+return nil, err
+}
+return newPredCache(c.CacheSize, time.Duration(c.CacheTTLMs)*time.Millisecond, q, clk), nil
+}
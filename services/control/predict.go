@@ -0,0 +1,171 @@
+package main
+
+import (
+"context"
+"sync"
+"sync/atomic"
+
+"google.golang.org/grpc/codes"
+"google.golang.org/grpc/status"
+
+pb "github.com/mulat/csn/proto"
+)
+
+// batchUnsupported is set once the predictor answers PredictBatch with
+// Unimplemented, so later decisions go straight to the fan-out.
+var batchUnsupported atomic.Bool
+
+// predictAll scores all actions for one context, serving what it can from
+// the prediction cache; failed actions are left nil. blocked is true when an
+// open breaker kept at least one action from being predicted.
+func (s *deciderServer) predictAll(ctx context.Context, c *pb.Context, actions []string) (out []*pb.PredictReply, blocked bool) {
+out = make([]*pb.PredictReply, len(actions))
+var missIdx []int
+var missActions []string
+for i, a := range actions {
+if r := s.cache.get(c, a); r != nil {
+out[i] = r
+continue
+}
+missIdx = append(missIdx, i)
+missActions = append(missActions, a)
+}
+if len(missActions) == 0 {
+return out, false
+}
+fresh, blocked := s.predictUncached(ctx, c, missActions)
+if len(missIdx) < len(actions) && !sameVersion(out, fresh) {
+// the pair moved to another model since the hits were cached (canary
+// percentage changed): score everything again under the new one
+fresh, blocked = s.predictUncached(ctx, c, actions)
+missIdx = missIdx[:0]
+for i := range actions {
+missIdx = append(missIdx, i)
+}
+}
+for j, i := range missIdx {
+out[i] = fresh[j]
+s.cache.put(c, actions[i], fresh[j])
+}
+return out, blocked
+}
+
+// sameVersion reports whether the cached and fresh replies (nil ones aside)
+// all come from one model version.
+func sameVersion(cached, fresh []*pb.PredictReply) bool {
+v, seen := "", false
+for _, rs := range [][]*pb.PredictReply{cached, fresh} {
+for _, r := range rs {
+if r == nil {
+continue
+}
+if seen && r.GetModelVersion() != v {
+return false
+}
+v, seen = r.GetModelVersion(), true
+}
+}
+return true
+}
+
+// predictUncached asks the breakers which actions may be predicted, tries a
+// single PredictBatch call for those and falls back to parallel Predict calls.
+// Each permitted breaker gets one outcome for the whole attempt: a failed batch
+// counts once (its actions are not retried on a breaker it just opened), and
+// a fan-out counts as one failure if any of the breaker's calls failed.
+func (s *deciderServer) predictUncached(ctx context.Context, c *pb.Context, actions []string) ([]*pb.PredictReply, bool) {
+out := make([]*pb.PredictReply, len(actions))
+ep := s.predictorAddr
+// one permit per breaker: per-endpoint mode shares a breaker across actions
+permit := make(map[*circuitBreaker]bool)
+var brks []*circuitBreaker
+var idx []int
+var brkOf []*circuitBreaker // breaker of each allowed action
+var allowed []string
+blocked := false
+for i, a := range actions {
+b, l := s.breakers.get(ep, a)
+ok, seen := permit[b]
+if !seen {
+ok = b.allow(l)
+permit[b] = ok
+if ok {
+brks = append(brks, b)
+}
+}
+if !ok {
+blocked = true
+continue
+}
+idx = append(idx, i)
+brkOf = append(brkOf, b)
+allowed = append(allowed, a)
+}
+if len(allowed) == 0 {
+return out, blocked
+}
+
+// report, when set, is the breaker outcome still owed by the fan-out
+report := true
+if !batchUnsupported.Load() {
+resp, err := s.predictor.PredictBatch(ctx, &pb.PredictBatchRequest{Ctx: c, Actions: allowed})
+if err == nil && len(resp.Predictions) == len(allowed) {
+for _, b := range brks {
+b.onSuccess(s.breakers.currentLimits())
+}
+for j, i := range idx {
+out[i] = resp.Predictions[j]
+}
+return out, blocked
+}
+if status.Code(err) == codes.Unimplemented {
+batchUnsupported.Store(true)
+} else {
+report = false
+opened := make(map[*circuitBreaker]bool)
+for _, b := range brks {
+opened[b] = b.onFailure(s.breakers.currentLimits())
+}
+var keepIdx []int
+var keepBrk []*circuitBreaker
+for j, b := range brkOf {
+if opened[b] {
+blocked = true
+continue
+}
+keepIdx = append(keepIdx, idx[j])
+keepBrk = append(keepBrk, b)
+}
+idx, brkOf = keepIdx, keepBrk
+}
+}
+
+var wg sync.WaitGroup
+var mu sync.Mutex
+failed := make(map[*circuitBreaker]bool)
+for j, i := range idx {
+wg.Add(1)
+go func(i int, a string, b *circuitBreaker) {
+defer wg.Done()
+resp, err := s.predictor.Predict(ctx, &pb.PredictRequest{Ctx: c, Action: a})
+if err != nil {
+mu.Lock()
+failed[b] = true
+mu.Unlock()
+return
+}
+out[i] = resp
+}(i, actions[i], brkOf[j])
+}
+wg.Wait()
+if report {
+for _, b := range brks {
+if failed[b] {
+b.onFailure(s.breakers.currentLimits())
+} else {
+b.onSuccess(s.breakers.currentLimits())
+}
+}
+}
+return out, blocked
+}
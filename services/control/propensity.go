@@ -0,0 +1,135 @@
+package main
+
+import (
+"math"
+
+"github.com/prometheus/client_golang/prometheus"
+)
+
+var mPropensity = prometheus.NewHistogram(prometheus.HistogramOpts{
+Name:    "csn_decision_propensity",
+Help:    "Probability with which Decide selected the returned action",
+Buckets: []float64{.01, .02, .05, .1, .2, .3, .5, .7, .9, .99, 1},
+})
+
+func init() {
+prometheus.MustRegister(mPropensity)
+}
+
+// Propensity methods, as logged in the audit record.
+const (
+propensityExact      = "exact"
+propensityMonteCarlo = "monte_carlo"
+)
+
+// selectionPropensity returns, per candidate, the probability that Decide's
+// pipeline (policy argmax, ε-greedy, fairness recheck) ends on it, and how it
+// was computed. ε-greedy and the fairness recheck are applied exactly to each
+// set of scores. When the scores are random (csn samples latency and adds
+// jitter; LinTS samples each candidate's latency) the argmax probability is a
+// Monte Carlo estimate: the scores are re-drawn samples times, bandit sample
+// included, from a source seeded off s.rng, and the scores Decide actually
+// drew count as one more draw so the chosen action never gets probability 0.
+// Otherwise the result is exact.
+func (s *deciderServer) selectionPropensity(pol Policy, cands []candidate, eps float64, tenant string, samples int) ([]float64, string) {
+n := len(cands)
+p := make([]float64, n)
+if n == 0 {
+return p, propensityExact
+}
+if !pol.Adaptive() || n == 1 {
+eps = 0
+}
+// fairness penalties depend only on the tenant and the action
+var fpen []float64
+if pol.Adaptive() {
+fpen = make([]float64, n)
+for i, c := range cands {
+fpen[i] = s.fairnessPenalty(tenant, c.action)
+}
+}
+u := make([]float64, n)
+for i, c := range cands {
+u[i] = c.u
+}
+s.addSelection(p, u, fpen, eps)
+up, random := pol.(utilityPolicy)
+for _, c := range cands {
+random = random || c.tsStd > 0
+}
+if !random || n == 1 {
+return p, propensityExact
+}
+if samples <= 0 {
+// only the realised draw: the argmax gets all of 1-ε
+return p, propensityMonteCarlo
+}
+rng := newLockedRand(s.rng.Int63())
+if up.s != nil {
+up.rng = rng
+pol = up
+}
+own := make([]candidate, n)
+for k := 0; k < samples; k++ {
+copy(own, cands)
+for i := range own {
+if c := &own[i]; c.tsStd > 0 {
+c.muLat = math.Max(0, c.tsBase+c.tsStd*rng.NormFloat64())
+}
+u[i] = pol.Score(&own[i])
+}
+s.addSelection(p, u, fpen, eps)
+}
+for i := range p {
+p[i] /= float64(samples + 1)
+}
+return p, propensityMonteCarlo
+}
+
+// addSelection adds one draw of scores u to p: the argmax with 1-eps, every
+// other candidate with eps/(n-1), each moved by the fairness recheck.
+func (s *deciderServer) addSelection(p, u, fpen []float64, eps float64) {
+best, bestU := 0, math.Inf(-1)
+for i, x := range u {
+if x > bestU {
+best, bestU = i, x
+}
+}
+p[fairRecheck(best, u, fpen)] += 1 - eps
+if eps == 0 {
+return
+}
+share := eps / float64(len(u)-1)
+for i := range u {
+if i != best {
+p[fairRecheck(i, u, fpen)] += share
+}
+}
+}
+
+// fairRecheck mirrors Decide: the first other candidate scoring above the
+// picked one's utility minus its fairness penalty replaces it.
+func fairRecheck(picked int, u, fpen []float64) int {
+if fpen == nil || fpen[picked] <= 0 || len(u) < 2 {
+return picked
+}
+chosenU := u[picked] - fpen[picked]
+for i, x := range u {
+if i != picked && x > chosenU {
+return i
+}
+}
+return picked
+}
+
+// modalCandidate is the candidate the pipeline picks most often; Decide
+// reports a decision as exploration when it returned anything else.
+func modalCandidate(p []float64) int {
+best := 0
+for i, x := range p {
+if x > p[best] {
+best = i
+}
+}
+return best
+}
@@ -1,54 +1,185 @@
 package main
 
 import (
+"math"
 "sync"
 "time"
+
+"github.com/prometheus/client_golang/prometheus"
+"google.golang.org/grpc/codes"
+"google.golang.org/grpc/status"
+
+pb "github.com/mulat/csn/proto"
+)
+
+var (
+mQuotaAllowed = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_quota_allowed_total",
+Help: "Decide calls admitted by the per-tenant quota (tenants without a quota.tenants entry count as \"other\")",
+}, []string{"tenant"})
+mQuotaDenied = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_quota_denied_total",
+Help: "Decide calls denied by the per-tenant quota (tenants without a quota.tenants entry count as \"other\")",
+}, []string{"tenant"})
 )
 
+func init() {
+prometheus.MustRegister(mQuotaAllowed, mQuotaDenied)
+}
+
 type tokenBucket struct {
 rate      float64       // tokens per second
 burst     float64       // max bucket
-tokens    float64
+tokens    float64       // may go negative: costs are charged after the action is chosen
 lastFill  time.Time
+clock     clock
 mu        sync.Mutex
 }
 
-func newBucket(rate, burst float64) *tokenBucket {
-return &tokenBucket{rate: rate, burst: burst, tokens: burst, lastFill: time.Now()}
+func newBucket(rate, burst float64, clk clock) *tokenBucket {
+return &tokenBucket{rate: rate, burst: burst, tokens: burst, lastFill: clockNow(clk), clock: clk}
 }
 
-func (b *tokenBucket) allow(cost float64) bool {
-b.mu.Lock(); defer b.mu.Unlock()
-now := time.Now()
+func (b *tokenBucket) refillLocked() {
+now := clockNow(b.clock)
 elapsed := now.Sub(b.lastFill).Seconds()
 b.tokens = minF(b.burst, b.tokens + elapsed*b.rate)
 b.lastFill = now
+}
+
+// admit reports whether at least cost tokens are available without taking
+// them, and otherwise how long until they will be. A cost above the burst
+// needs a full bucket, or it could never be admitted.
+func (b *tokenBucket) admit(cost float64) (bool, time.Duration) {
+b.mu.Lock(); defer b.mu.Unlock()
+b.refillLocked()
+cost = minF(cost, b.burst)
 if b.tokens >= cost {
-b.tokens -= cost
-return true
+return true, 0
 }
-return false
+if b.rate <= 0 {
+return false, time.Duration(math.MaxInt64)
+}
+return false, time.Duration((cost - b.tokens) / b.rate * float64(time.Second))
+}
+
+// charge takes cost tokens; the balance may go negative and is repaid by refill.
+func (b *tokenBucket) charge(cost float64) {
+b.mu.Lock(); defer b.mu.Unlock()
+b.refillLocked()
+b.tokens -= cost
 }
 
 func minF(a,b float64) float64 { if a<b { return a }; return b }
 
+// quotaLimit is a tenant's refill rate (tokens/s) and burst. One token is the
+// cost of the lightest action (resourceIntensity("local:low") == 1).
+type quotaLimit struct {
+Rate  float64 `json:"rate" yaml:"rate"`
+Burst float64 `json:"burst" yaml:"burst"`
+}
+
 type quotaManager struct {
 mu sync.Mutex
 buckets map[string]*tokenBucket
 rate float64
 burst float64
+tenants map[string]quotaLimit // per-tenant overrides of rate/burst
+clock clock
+}
+
+func newQuotaManager(rate, burst float64, clk clock) *quotaManager {
+return &quotaManager{buckets: make(map[string]*tokenBucket), rate: rate, burst: burst, clock: clk}
+}
+
+func (q *quotaManager) limitLocked(tenant string) quotaLimit {
+if l, ok := q.tenants[tenant]; ok {
+return l
 }
+return quotaLimit{Rate: q.rate, Burst: q.burst}
+}
+
+// otherTenant labels the quota metrics of tenants without an override: tenant
+// IDs come from clients, so they cannot all become label values.
+const otherTenant = "other"
 
-func newQuotaManager(rate, burst float64) *quotaManager {
-return &quotaManager{buckets: make(map[string]*tokenBucket), rate: rate, burst: burst}
+func (q *quotaManager) label(tenant string) string {
+q.mu.Lock(); defer q.mu.Unlock()
+if _, ok := q.tenants[tenant]; ok {
+return tenant
+}
+return otherTenant
 }
 
-func (q *quotaManager) allow(tenant string, cost float64) bool {
+func (q *quotaManager) bucket(tenant string) *tokenBucket {
 q.mu.Lock(); defer q.mu.Unlock()
 b, ok := q.buckets[tenant]
 if !ok {
-b = newBucket(q.rate, q.burst)
+l := q.limitLocked(tenant)
+b = newBucket(l.Rate, l.Burst, q.clock)
 q.buckets[tenant] = b
 }
-return b.allow(cost)
+return b
+}
+
+// admit checks that the tenant can afford cost (normally the cheapest feasible
+// action) and counts the outcome; retry is how long until it could.
+func (q *quotaManager) admit(tenant string, cost float64) (ok bool, retry time.Duration) {
+ok, retry = q.bucket(tenant).admit(cost)
+if ok {
+mQuotaAllowed.WithLabelValues(q.label(tenant)).Inc()
+} else {
+mQuotaDenied.WithLabelValues(q.label(tenant)).Inc()
+}
+return ok, retry
+}
+
+// charge bills the tenant for the action actually chosen.
+func (q *quotaManager) charge(tenant string, cost float64) {
+q.bucket(tenant).charge(cost)
+}
+
+// setLimits applies new defaults and per-tenant overrides to the manager and
+// every existing bucket.
+func (q *quotaManager) setLimits(rate, burst float64, tenants map[string]quotaLimit) {
+q.mu.Lock(); defer q.mu.Unlock()
+q.rate, q.burst, q.tenants = rate, burst, tenants
+for t, b := range q.buckets {
+l := q.limitLocked(t)
+b.mu.Lock()
+b.rate, b.burst = l.Rate, l.Burst
+b.tokens = minF(b.tokens, l.Burst)
+b.mu.Unlock()
+}
+}
+
+// quotaCost is what admission requires: the cheapest feasible action.
+func quotaCost(actions []string) float64 {
+cost := math.Inf(1)
+for _, a := range actions {
+cost = math.Min(cost, resourceIntensity(a))
+}
+if math.IsInf(cost, 1) {
+return 1
+}
+return cost
+}
+
+// denyQuota answers a throttled Decide either with Denied set (and the cheapest
+// feasible action as a fallback for old clients) or with ResourceExhausted.
+func (s *deciderServer) denyQuota(tenant string, actions []string, retry time.Duration) (*pb.DecideReply, error) {
+retryMs := int64(math.Ceil(float64(retry) / float64(time.Millisecond)))
+s.mu.Lock()
+mode := s.quotaDeny
+s.mu.Unlock()
+if mode == "status" {
+return nil, status.Errorf(codes.ResourceExhausted, "tenant %q over quota, retry after %dms", tenant, retryMs)
+}
+fallback := "local:low"
+for i, a := range actions {
+if i == 0 || resourceIntensity(a) < resourceIntensity(fallback) {
+fallback = a
+}
+}
+return &pb.DecideReply{ChosenAction: fallback, Explore: false, Reason: "quota", Denied: true, RetryAfterMs: retryMs}, nil
 }
@@ -0,0 +1,356 @@
+package main
+
+import (
+"bytes"
+"context"
+"encoding/json"
+"fmt"
+"log"
+"net"
+"net/http"
+"net/url"
+"os"
+"os/exec"
+"sort"
+"strings"
+"sync"
+"time"
+
+"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+mRetrainTriggers = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_retrain_triggers_total",
+Help: "Retraining hook runs by cause (drift|violation|manual) and result (ok|error)",
+}, []string{"cause", "result"})
+mRetrainLast = prometheus.NewGauge(prometheus.GaugeOpts{
+Name: "csn_retrain_last_trigger_timestamp_seconds",
+Help: "Unix time of the last retraining hook run",
+})
+mRetrainHeld = prometheus.NewGauge(prometheus.GaugeOpts{
+Name: "csn_retrain_condition_seconds",
+Help: "How long the current drift/violation condition has held (0 when clear)",
+})
+)
+
+func init() {
+prometheus.MustRegister(mRetrainTriggers, mRetrainLast, mRetrainHeld)
+}
+
+// retrainHistory is how many past triggers GET /retrain returns.
+const retrainHistory = 20
+
+type retrainParams struct {
+hook        string // "exec:<cmd> [args]", "http://127.0.0.1:<port>/<path>" or "" (off)
+sustain     time.Duration
+cooldown    time.Duration
+timeout     time.Duration
+minDrifted  int     // drifted features needed; 0 ignores drift
+violRate    float64 // rolling violation rate of any SLO class; 0 ignores violations
+}
+
+// retrainTrigger records one hook run and why it fired.
+type retrainTrigger struct {
+TS         time.Time          `json:"ts"`
+Cause      string             `json:"cause"`
+Reason     string             `json:"reason"`
+Drifted    []string           `json:"drifted,omitempty"`
+ViolRates  map[string]float64 `json:"viol_rates,omitempty"`
+HeldMs     int64              `json:"held_ms"`
+Hook       string             `json:"hook"`
+Result     string             `json:"result"`
+Error      string             `json:"error,omitempty"`
+Output     string             `json:"output,omitempty"`
+DurationMs int64              `json:"duration_ms"`
+}
+
+// retrainer fires the retraining hook once drift or SLO violations have held
+// for retrain.sustain_ms, then stays quiet for retrain.cooldown_ms. Only one
+// hook runs at a time.
+type retrainer struct {
+s *deciderServer
+
+mu        sync.Mutex
+params    retrainParams
+since     time.Time // condition first seen; zero when clear
+lastFired time.Time
+running   bool
+history   []retrainTrigger
+}
+
+func retrainParamsFromConfig(c *deciderConfig) retrainParams {
+hook := strings.TrimSpace(c.RetrainHook)
+if hook == "off" {
+hook = ""
+}
+return retrainParams{
+hook:       hook,
+sustain:    time.Duration(c.RetrainSustainMs) * time.Millisecond,
+cooldown:   time.Duration(c.RetrainCooldownMs) * time.Millisecond,
+timeout:    time.Duration(c.RetrainTimeoutMs) * time.Millisecond,
+minDrifted: c.RetrainMinDrifted,
+violRate:   c.RetrainViolRate,
+}
+}
+
+// checkRetrainHook accepts "exec:<cmd>" or an http(s) URL on a loopback host.
+func checkRetrainHook(hook string) error {
+hook = strings.TrimSpace(hook)
+if hook == "" || hook == "off" {
+return nil
+}
+if strings.HasPrefix(hook, "exec:") {
+if len(strings.Fields(strings.TrimPrefix(hook, "exec:"))) == 0 {
+return fmt.Errorf("exec hook has no command")
+}
+return nil
+}
+u, err := url.Parse(hook)
+if err != nil {
+return err
+}
+if u.Scheme != "http" && u.Scheme != "https" {
+return fmt.Errorf("want exec:<cmd> or an http(s) URL, got %q", hook)
+}
+host := u.Hostname()
+if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+return fmt.Errorf("webhook host %q is not local", host)
+}
+return nil
+}
+
+func newRetrainer(s *deciderServer, p retrainParams) *retrainer {
+return &retrainer{s: s, params: p}
+}
+
+func (r *retrainer) configure(p retrainParams) {
+if r == nil {
+return
+}
+r.mu.Lock()
+r.params = p
+r.mu.Unlock()
+}
+
+// condition reports what currently calls for retraining.
+func (r *retrainer) condition(p retrainParams) (cause string, drifted []string, rates map[string]float64) {
+drifted = r.s.drift.drifted()
+r.s.mu.Lock()
+rates = make(map[string]float64, len(r.s.sloClasses))
+for name, c := range r.s.sloClasses {
+rates[name] = c.violRate()
+}
+r.s.mu.Unlock()
+if p.minDrifted > 0 && len(drifted) >= p.minDrifted {
+cause = "drift"
+}
+if p.violRate > 0 {
+for _, v := range rates {
+if v >= p.violRate {
+if cause == "" {
+cause = "violation"
+} else {
+cause = "drift+violation"
+}
+break
+}
+}
+}
+return cause, drifted, rates
+}
+
+func triggerReason(cause string, drifted []string, rates map[string]float64, p retrainParams) string {
+var parts []string
+if strings.Contains(cause, "drift") {
+parts = append(parts, "drift:"+strings.Join(drifted, ","))
+}
+if strings.Contains(cause, "violation") {
+var over []string
+for name, v := range rates {
+if v >= p.violRate {
+over = append(over, fmt.Sprintf("%s=%.3f", name, v))
+}
+}
+sort.Strings(over)
+parts = append(parts, "viol:"+strings.Join(over, ","))
+}
+return strings.Join(parts, ";")
+}
+
+// check runs once per tick.
+func (r *retrainer) check(now time.Time) {
+r.mu.Lock()
+p := r.params
+r.mu.Unlock()
+if p.hook == "" {
+mRetrainHeld.Set(0)
+return
+}
+cause, drifted, rates := r.condition(p)
+
+r.mu.Lock()
+if cause == "" {
+r.since = time.Time{}
+r.mu.Unlock()
+mRetrainHeld.Set(0)
+return
+}
+if r.since.IsZero() {
+r.since = now
+}
+held := now.Sub(r.since)
+mRetrainHeld.Set(held.Seconds())
+if held < p.sustain || r.running || (!r.lastFired.IsZero() && now.Sub(r.lastFired) < p.cooldown) {
+r.mu.Unlock()
+return
+}
+r.running, r.lastFired, r.since = true, now, time.Time{}
+r.mu.Unlock()
+
+tr := retrainTrigger{TS: now, Cause: cause, Reason: triggerReason(cause, drifted, rates, p), Drifted: drifted, ViolRates: rates, HeldMs: held.Milliseconds(), Hook: p.hook}
+go r.fire(tr, p)
+}
+
+// fireManual runs the hook now regardless of condition and cooldown.
+func (r *retrainer) fireManual(reason string) error {
+r.mu.Lock()
+p := r.params
+if p.hook == "" {
+r.mu.Unlock()
+return fmt.Errorf("retrain.hook is off")
+}
+if r.running {
+r.mu.Unlock()
+return fmt.Errorf("a retraining hook is already running")
+}
+now := r.s.now()
+r.running, r.lastFired, r.since = true, now, time.Time{}
+r.mu.Unlock()
+if reason == "" {
+reason = "manual"
+}
+go r.fire(retrainTrigger{TS: now, Cause: "manual", Reason: reason, Hook: p.hook}, p)
+return nil
+}
+
+func (r *retrainer) fire(tr retrainTrigger, p retrainParams) {
+log.Printf("retrain: firing %s (%s)", p.hook, tr.Reason)
+start := time.Now()
+ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+defer cancel()
+var out string
+var err error
+if strings.HasPrefix(p.hook, "exec:") {
+out, err = runExecHook(ctx, strings.TrimPrefix(p.hook, "exec:"), tr)
+} else {
+out, err = postWebhook(ctx, p.hook, tr)
+}
+tr.DurationMs = time.Since(start).Milliseconds()
+tr.Output = out
+tr.Result = "ok"
+if err != nil {
+tr.Result, tr.Error = "error", err.Error()
+log.Printf("retrain: %s failed after %dms: %v", p.hook, tr.DurationMs, err)
+} else {
+log.Printf("retrain: %s done in %dms", p.hook, tr.DurationMs)
+}
+mRetrainTriggers.WithLabelValues(tr.Cause, tr.Result).Inc()
+mRetrainLast.Set(float64(tr.TS.Unix()))
+r.s.audit.write(&auditRecord{Kind: "retrain", Retrain: &tr})
+
+r.mu.Lock()
+r.running = false
+r.history = append(r.history, tr)
+if len(r.history) > retrainHistory {
+r.history = r.history[len(r.history)-retrainHistory:]
+}
+r.mu.Unlock()
+}
+
+// tail keeps the end of hook output for the trigger record.
+func tail(b []byte, n int) string {
+if len(b) > n {
+b = b[len(b)-n:]
+}
+return strings.TrimSpace(string(b))
+}
+
+// runExecHook runs the command with the reason as its last argument (what
+// scripts/retrain_and_roll.sh takes) and in CSN_RETRAIN_REASON.
+func runExecHook(ctx context.Context, cmdline string, tr retrainTrigger) (string, error) {
+args := strings.Fields(cmdline)
+cmd := exec.CommandContext(ctx, args[0], append(args[1:], tr.Reason)...)
+cmd.Env = append(os.Environ(), "CSN_RETRAIN_REASON="+tr.Reason, "CSN_RETRAIN_CAUSE="+tr.Cause)
+out, err := cmd.CombinedOutput()
+return tail(out, 2048), err
+}
+
+// postWebhook POSTs the trigger record as JSON; non-2xx answers are errors.
+func postWebhook(ctx context.Context, hook string, tr retrainTrigger) (string, error) {
+buf, err := json.Marshal(tr)
+if err != nil {
+return "", err
+}
+req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook, bytes.NewReader(buf))
+if err != nil {
+return "", err
+}
+req.Header.Set("Content-Type", "application/json")
+resp, err := http.DefaultClient.Do(req)
+if err != nil {
+return "", err
+}
+defer resp.Body.Close()
+var body bytes.Buffer
+_, _ = body.ReadFrom(resp.Body)
+if resp.StatusCode/100 != 2 {
+return tail(body.Bytes(), 2048), fmt.Errorf("webhook answered %s", resp.Status)
+}
+return tail(body.Bytes(), 2048), nil
+}
+
+func (r *retrainer) start() {
+go func() {
+t := time.NewTicker(time.Second)
+defer t.Stop()
+for range t.C {
+r.check(r.s.now())
+}
+}()
+}
+
+// registerRetrainHandlers exposes GET /retrain (state and recent triggers) and
+// POST /retrain/fire?reason= (manual run, ignores sustain and cooldown).
+func registerRetrainHandlers(r *retrainer) {
+http.HandleFunc("/retrain", func(w http.ResponseWriter, req *http.Request) {
+r.mu.Lock()
+v := map[string]interface{}{
+"hook":        r.params.hook,
+"sustain_ms":  r.params.sustain.Milliseconds(),
+"cooldown_ms": r.params.cooldown.Milliseconds(),
+"running":     r.running,
+"history":     append([]retrainTrigger{}, r.history...),
+}
+if !r.since.IsZero() {
+v["condition_since"] = r.since
+}
+if !r.lastFired.IsZero() {
+v["last_fired"] = r.lastFired
+}
+r.mu.Unlock()
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(v)
+})
+http.HandleFunc("/retrain/fire", func(w http.ResponseWriter, req *http.Request) {
+if req.Method != http.MethodPost {
+http.Error(w, "POST only", http.StatusMethodNotAllowed)
+return
+}
+if err := r.fireManual(req.URL.Query().Get("reason")); err != nil {
+http.Error(w, err.Error(), http.StatusConflict)
+return
+}
+w.WriteHeader(http.StatusAccepted)
+})
+}
@@ -1,56 +1,408 @@
 package main
 
 import (
+"bufio"
 "bytes"
 "encoding/json"
+"fmt"
+"io"
+"log"
 "net/http"
 "os"
+"path/filepath"
+"strings"
 "time"
 
+"github.com/prometheus/client_golang/prometheus"
+
 pb "github.com/mulat/csn/proto"
 )
 
-var senseURL = func() string {
-if v := os.Getenv("CSN_SENSE_URL"); v != "" { return v }
-return "http://127.0.0.1:9105/ingest"
-}()
+var (
+mTelemetryQueue = prometheus.NewGauge(prometheus.GaugeOpts{
+Name: "csn_telemetry_queue_depth",
+Help: "Telemetry records waiting to be sent to the sensing service",
+})
+mTelemetrySent = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_telemetry_sent_total",
+Help: "Telemetry records delivered to the sensing service (including replayed spill)",
+})
+mTelemetryRetries = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_telemetry_retries_total",
+Help: "Batch send attempts after the first",
+})
+mTelemetryDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_telemetry_dropped_total",
+Help: "Telemetry records given up on (queue_full|spill_full|sink_down|rejected)",
+}, []string{"reason"})
+mTelemetrySpilled = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_telemetry_spilled_total",
+Help: "Telemetry records written to the spill file while the sink was down",
+})
+mTelemetrySpillBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+Name: "csn_telemetry_spill_bytes",
+Help: "Size of the telemetry spill file",
+})
+)
+
+func init() {
+prometheus.MustRegister(mTelemetryQueue, mTelemetrySent, mTelemetryRetries, mTelemetryDropped, mTelemetrySpilled, mTelemetrySpillBytes)
+}
 
 type sensePayload struct {
-Tenant  string   `json:"tenant"`
-App     string   `json:"app"`
-Bw      float64  `json:"bw"`
-Rtt     float64  `json:"rtt"`
-Loss    float64  `json:"loss"`
-DevCPU  float64  `json:"dev_cpu"`
-Soc     float64  `json:"soc"`
-EdgeCPU float64  `json:"edge_cpu"`
-InputKB float64  `json:"input_kb"`
-SloMS   float64  `json:"slo_ms"`
-Action  string   `json:"action"`
-LatMu   *float64 `json:"lat_mu,omitempty"`
-LatVar  *float64 `json:"lat_var,omitempty"`
-EnMu    *float64 `json:"en_mu,omitempty"`
-P95Conf *float64 `json:"p95_conf,omitempty"`
-}
-
-func postSense(c *pb.Context, action string) {
-if c == nil || action == "" { return }
-body := sensePayload{
-Tenant:  c.GetTenantId(),
-App:     c.GetAppId(),
-Bw:      c.GetBwMbps(),
-Rtt:     c.GetRttMs(),
-Loss:    c.GetLoss(),
-DevCPU:  c.GetDeviceCpu(),
-Soc:     c.GetBatterySoc(),
-EdgeCPU: c.GetEdgeCpu(),
-InputKB: c.GetInputKb(),
-SloMS:   c.GetSloP95Ms(),
-Action:  action,
-}
-buf, _ := json.Marshal(body)
-req, _ := http.NewRequest("POST", senseURL, bytes.NewReader(buf))
-req.Header.Set("Content-Type", "application/json")
-client := &http.Client{ Timeout: 300 * time.Millisecond }
-go client.Do(req) // fire-and-forget
+DecisionID string   `json:"decision_id"`
+Tenant     string   `json:"tenant"`
+App        string   `json:"app"`
+Bw         float64  `json:"bw"`
+Rtt        float64  `json:"rtt"`
+Loss       float64  `json:"loss"`
+DevCPU     float64  `json:"dev_cpu"`
+Soc        float64  `json:"soc"`
+EdgeCPU    float64  `json:"edge_cpu"`
+InputKB    float64  `json:"input_kb"`
+SloMS      float64  `json:"slo_ms"`
+Action     string   `json:"action"`
+LatMu      *float64 `json:"lat_mu,omitempty"`
+LatVar     *float64 `json:"lat_var,omitempty"`
+EnMu       *float64 `json:"en_mu,omitempty"`
+P95Conf    *float64 `json:"p95_conf,omitempty"`
+}
+
+// newSensePayload describes one decision; chosen is the winning candidate, nil
+// when the degraded heuristic decided without predictions. The prediction sent
+// is the predictor's, before the bandit correction. tenant is the one Decide
+// used; a missing app is "default" too, since sensing requires both.
+func newSensePayload(id, tenant string, c *pb.Context, action string, chosen *candidate) sensePayload {
+app := c.GetAppId()
+if app == "" {
+app = "default"
+}
+p := sensePayload{
+DecisionID: id,
+Tenant:     tenant,
+App:        app,
+Bw:         c.GetBwMbps(),
+Rtt:        c.GetRttMs(),
+Loss:       c.GetLoss(),
+DevCPU:     c.GetDeviceCpu(),
+Soc:        c.GetBatterySoc(),
+EdgeCPU:    c.GetEdgeCpu(),
+InputKB:    c.GetInputKb(),
+SloMS:      c.GetSloP95Ms(),
+Action:     action,
+}
+if chosen != nil {
+mu, v, en, p95 := chosen.predLat, chosen.varLat, chosen.predEn, chosen.p95Conf
+p.LatMu, p.LatVar, p.EnMu, p.P95Conf = &mu, &v, &en, &p95
+}
+return p
+}
+
+// senseQueue delivers decision telemetry to the sensing service in batches.
+// Enqueue never blocks Decide: a full queue drops. A batch is retried with
+// exponential backoff; while it waits, newer batches go to the spill file (or
+// wait in the queue when spilling is off). If the retries fail the sink is
+// marked down and batches go to a JSONL spill file, which is replayed once the
+// sink answers again. A nil *senseQueue discards everything.
+type senseQueue struct {
+url        string
+client     *http.Client
+ch         chan sensePayload
+batchSize  int
+flush      time.Duration
+maxRetries int
+backoff    time.Duration
+spillPath  string // "" disables spilling
+spillMax   int64
+
+// owned by the run goroutine
+down       bool
+spillBytes int64
+pending    []sensePayload // batch waiting for its next retry
+attempt    int
+wait       time.Duration
+}
+
+func newSenseQueue(url string, size, batch int, flush time.Duration, retries int, backoff time.Duration, spillPath string, spillMax int64) *senseQueue {
+q := &senseQueue{
+url:        url,
+client:     &http.Client{Timeout: 2 * time.Second},
+ch:         make(chan sensePayload, size),
+batchSize:  batch,
+flush:      flush,
+maxRetries: retries,
+backoff:    backoff,
+spillPath:  spillPath,
+spillMax:   spillMax,
+}
+if spillPath != "" {
+if st, err := os.Stat(spillPath); err == nil {
+// left over from a previous run; replayed on the first flush
+q.spillBytes = st.Size()
+mTelemetrySpillBytes.Set(float64(q.spillBytes))
+}
+}
+go q.run()
+return q
+}
+
+func senseQueueFromConfig(c *deciderConfig) *senseQueue {
+url := strings.TrimSpace(c.TelemetryURL)
+if url == "" || url == "off" {
+return nil
+}
+spill := strings.TrimSpace(c.TelemetrySpill)
+if spill == "off" {
+spill = ""
+}
+if spill != "" {
+if err := os.MkdirAll(filepath.Dir(spill), 0o755); err != nil {
+log.Printf("telemetry spill disabled: %v", err)
+spill = ""
+}
+}
+return newSenseQueue(url, c.TelemetryQueue, c.TelemetryBatch, time.Duration(c.TelemetryFlushMs)*time.Millisecond,
+c.TelemetryRetries, time.Duration(c.TelemetryBackoffMs)*time.Millisecond, spill, int64(c.TelemetrySpillMB)<<20)
+}
+
+func (q *senseQueue) enqueue(p sensePayload) {
+if q == nil || p.Action == "" {
+return
+}
+select {
+case q.ch <- p:
+mTelemetryQueue.Set(float64(len(q.ch)))
+default:
+mTelemetryDropped.WithLabelValues("queue_full").Inc()
+}
+}
+
+func (q *senseQueue) run() {
+t := time.NewTicker(q.flush)
+defer t.Stop()
+retry := time.NewTimer(0)
+if !retry.Stop() {
+<-retry.C
+}
+batch := make([]sensePayload, 0, q.batchSize)
+for {
+in := q.ch
+if q.pending != nil && (q.spillPath == "" || len(batch) >= q.batchSize) {
+// backing off with nowhere to put more: let the queue absorb it
+in = nil
+}
+select {
+case p := <-in:
+batch = append(batch, p)
+if len(batch) < q.batchSize {
+continue
+}
+case <-t.C:
+case <-retry.C:
+mTelemetryRetries.Inc()
+if d := q.resend(); d > 0 {
+retry.Reset(d)
+}
+continue
+}
+mTelemetryQueue.Set(float64(len(q.ch)))
+if q.pending != nil {
+if q.spillPath != "" && len(batch) > 0 {
+q.spill(batch)
+batch = batch[:0]
+}
+continue
+}
+if q.spillBytes > 0 {
+q.replay()
+}
+if len(batch) > 0 {
+if d := q.deliver(batch); d > 0 {
+retry.Reset(d)
+}
+batch = batch[:0]
+}
+}
+}
+
+// deliver sends batch. When the sink looks up and the send can be retried,
+// batch becomes pending and deliver returns the wait before resend; 0 means
+// it was sent, dropped or spilled.
+func (q *senseQueue) deliver(batch []sensePayload) time.Duration {
+if q.down && q.spillBytes == 0 {
+// nothing spilled to probe the sink with: this batch is the probe
+retry, err := q.send(batch)
+if err == nil || !retry {
+q.down = false
+if err != nil {
+mTelemetryDropped.WithLabelValues("rejected").Add(float64(len(batch)))
+}
+return 0
+}
+}
+if q.down {
+q.spill(batch)
+return 0
+}
+q.pending = append([]sensePayload(nil), batch...)
+q.attempt, q.wait = 0, q.backoff
+return q.outcome(q.send(q.pending))
+}
+
+// resend retries the pending batch; the wait doubles up to a minute.
+func (q *senseQueue) resend() time.Duration {
+q.attempt++
+if q.wait < time.Minute {
+q.wait *= 2
+}
+return q.outcome(q.send(q.pending))
+}
+
+// outcome settles the pending batch after a send, or returns the wait before
+// the next attempt.
+func (q *senseQueue) outcome(retry bool, err error) time.Duration {
+switch {
+case err == nil:
+case !retry:
+log.Printf("telemetry: batch rejected: %v", err)
+mTelemetryDropped.WithLabelValues("rejected").Add(float64(len(q.pending)))
+case q.attempt >= q.maxRetries:
+log.Printf("telemetry: sink down after %d attempts: %v", q.attempt+1, err)
+q.down = true
+q.spill(q.pending)
+default:
+return q.wait
+}
+q.pending = nil
+return 0
+}
+
+// send posts batch as a JSON array. retry is false for answers that will not
+// change on resend (4xx: every record failed validation).
+func (q *senseQueue) send(batch []sensePayload) (retry bool, err error) {
+buf, err := json.Marshal(batch)
+if err != nil {
+return false, err
+}
+resp, err := q.client.Post(q.url, "application/json", bytes.NewReader(buf))
+if err != nil {
+return true, err
+}
+defer resp.Body.Close()
+_, _ = io.Copy(io.Discard, resp.Body)
+switch {
+case resp.StatusCode >= 500:
+return true, fmt.Errorf("sink answered %s", resp.Status)
+case resp.StatusCode >= 400:
+return false, fmt.Errorf("sink answered %s", resp.Status)
+}
+mTelemetrySent.Add(float64(len(batch)))
+return false, nil
+}
+
+func (q *senseQueue) spill(batch []sensePayload) {
+if q.spillPath == "" {
+mTelemetryDropped.WithLabelValues("sink_down").Add(float64(len(batch)))
+return
+}
+var buf bytes.Buffer
+n := 0
+for _, p := range batch {
+line, err := json.Marshal(p)
+if err != nil {
+continue
+}
+if q.spillMax > 0 && q.spillBytes+int64(buf.Len()+len(line)+1) > q.spillMax {
+break
+}
+buf.Write(line)
+buf.WriteByte('\n')
+n++
+}
+if dropped := len(batch) - n; dropped > 0 {
+mTelemetryDropped.WithLabelValues("spill_full").Add(float64(dropped))
+}
+if n == 0 {
+return
+}
+f, err := os.OpenFile(q.spillPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+if err != nil {
+log.Printf("telemetry spill: %v", err)
+mTelemetryDropped.WithLabelValues("sink_down").Add(float64(n))
+return
+}
+w, err := f.Write(buf.Bytes())
+f.Close()
+q.spillBytes += int64(w)
+mTelemetrySpillBytes.Set(float64(q.spillBytes))
+if err != nil {
+log.Printf("telemetry spill: %v", err)
+return
+}
+mTelemetrySpilled.Add(float64(n))
+}
+
+// replay resends the spill file in batches. The first failure keeps the sink
+// down and rewrites the unsent tail; full success removes the file.
+func (q *senseQueue) replay() {
+f, err := os.Open(q.spillPath)
+if err != nil {
+q.spillBytes = 0
+mTelemetrySpillBytes.Set(0)
+return
+}
+var pending []sensePayload
+sc := bufio.NewScanner(f)
+sc.Buffer(make([]byte, 64*1024), 1<<20)
+for sc.Scan() {
+var p sensePayload
+if json.Unmarshal(sc.Bytes(), &p) == nil {
+pending = append(pending, p)
+}
+}
+f.Close()
+
+for len(pending) > 0 {
+n := q.batchSize
+if n > len(pending) {
+n = len(pending)
+}
+retry, err := q.send(pending[:n])
+if err != nil && retry {
+q.down = true
+q.rewriteSpill(pending)
+return
+}
+if err != nil {
+mTelemetryDropped.WithLabelValues("rejected").Add(float64(n))
+}
+pending = pending[n:]
+}
+if q.down {
+log.Printf("telemetry: sink back, spill replayed")
+}
+q.down = false
+_ = os.Remove(q.spillPath)
+q.spillBytes = 0
+mTelemetrySpillBytes.Set(0)
+}
+
+func (q *senseQueue) rewriteSpill(pending []sensePayload) {
+var buf bytes.Buffer
+for _, p := range pending {
+line, _ := json.Marshal(p)
+buf.Write(line)
+buf.WriteByte('\n')
+}
+tmp := q.spillPath + ".tmp"
+if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+log.Printf("telemetry spill: %v", err)
+return
+}
+if err := os.Rename(tmp, q.spillPath); err != nil {
+log.Printf("telemetry spill: %v", err)
+return
+}
+q.spillBytes = int64(buf.Len())
+mTelemetrySpillBytes.Set(float64(q.spillBytes))
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+"fmt"
+"math"
+"strconv"
+
+"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+mShadowDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_shadow_decisions_total",
+Help: "Shadow evaluations by shadow policy and whether it agreed with the live choice",
+}, []string{"shadow", "agree"})
+mShadowChosen = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_shadow_chosen_total",
+Help: "Actions picked by each shadow policy (shadow=\"live\" is the served choice on the same decisions)",
+}, []string{"shadow", "action"})
+mShadowPredViol = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_shadow_pred_slo_violations_total",
+Help: "Choices whose predicted p95 exceeds the SLO, per shadow policy and live",
+}, []string{"shadow"})
+mShadowPredEnergy = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_shadow_pred_energy_j_total",
+Help: "Predicted energy (J) of the chosen actions, per shadow policy and live",
+}, []string{"shadow"})
+mShadowEnergyDiff = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+Name:    "csn_shadow_energy_diff_j",
+Help:    "Predicted energy of the shadow choice minus the live choice",
+Buckets: []float64{-1, -0.5, -0.2, -0.1, -0.05, 0, 0.05, 0.1, 0.2, 0.5, 1},
+}, []string{"shadow"})
+)
+
+func init() {
+prometheus.MustRegister(mShadowDecisions, mShadowChosen, mShadowPredViol, mShadowPredEnergy, mShadowEnergyDiff)
+}
+
+// shadowLive is the label the served choice is recorded under.
+const shadowLive = "live"
+
+// shadowResult is what one shadow would have done; it goes to the decision log.
+type shadowResult struct {
+Name          string  `json:"name"`
+Spec          string  `json:"spec"`
+Action        string  `json:"action"`
+Agree         bool    `json:"agree"`
+PredViolation bool    `json:"pred_slo_violation"`
+PredEnergyJ   float64 `json:"pred_energy_j"`
+EnergyDiffJ   float64 `json:"energy_diff_j"`
+ViolationDiff int     `json:"violation_diff"` // shadow minus live: -1, 0 or 1
+}
+
+// buildShadows parses policy.shadow, name -> policy spec (parseVariant), e.g.
+// {low_energy: "csn;lambda_energy=160", greedy: greedy_latency}. A shadow only
+// ranks the candidates Decide already built, so it costs no predictor calls
+// and never changes the reply.
+func (s *deciderServer) buildShadows(m map[string]string) ([]*policyVariant, error) {
+if _, ok := m[shadowLive]; ok {
+return nil, fmt.Errorf("%q is reserved for the live policy", shadowLive)
+}
+return s.buildVariants(m)
+}
+
+// evaluateShadows lets every shadow pick from cands and records how its pick
+// compares with the live one. Shadows take their argmax: exploration and the
+// fairness recheck only apply to the live policy.
+func evaluateShadows(shadows []*policyVariant, cands []candidate, live *candidate, muSLO float64) []shadowResult {
+if len(shadows) == 0 || live == nil || len(cands) == 0 {
+return nil
+}
+liveViol := live.sloPenalty() > 0
+mShadowChosen.WithLabelValues(shadowLive, live.action).Inc()
+mShadowPredEnergy.WithLabelValues(shadowLive).Add(live.muEn)
+if liveViol {
+mShadowPredViol.WithLabelValues(shadowLive).Inc()
+}
+out := make([]shadowResult, 0, len(shadows))
+own := make([]candidate, len(cands))
+for _, sp := range shadows {
+copy(own, cands)
+best, bestU := -1, math.Inf(-1)
+for i := range own {
+if sp.alphaSLOBase != nil {
+own[i].alphaEff = *sp.alphaSLOBase + muSLO
+}
+if u := sp.pol.Score(&own[i]); u > bestU {
+best, bestU = i, u
+}
+}
+if best < 0 {
+continue
+}
+c := own[best]
+r := shadowResult{
+Name:          sp.name,
+Spec:          sp.spec,
+Action:        c.action,
+Agree:         c.action == live.action,
+PredViolation: c.sloPenalty() > 0,
+PredEnergyJ:   c.muEn,
+EnergyDiffJ:   c.muEn - live.muEn,
+}
+r.ViolationDiff = b2i(r.PredViolation) - b2i(liveViol)
+mShadowDecisions.WithLabelValues(sp.name, strconv.FormatBool(r.Agree)).Inc()
+mShadowChosen.WithLabelValues(sp.name, c.action).Inc()
+mShadowPredEnergy.WithLabelValues(sp.name).Add(c.muEn)
+if r.PredViolation {
+mShadowPredViol.WithLabelValues(sp.name).Inc()
+}
+mShadowEnergyDiff.WithLabelValues(sp.name).Observe(r.EnergyDiffJ)
+out = append(out, r)
+}
+return out
+}
+
+func b2i(b bool) int {
+if b {
+return 1
+}
+return 0
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+"fmt"
+"math"
+"time"
+
+"github.com/prometheus/client_golang/prometheus"
+)
+
+var mSLOTargetEps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_slo_target_eps",
+Help: "Target SLO violation rate per service class",
+}, []string{"class"})
+
+func init() {
+prometheus.MustRegister(mSLOTargetEps)
+}
+
+// defaultSLOClass is built from slo.target_eps/win_size/eta and serves every
+// tenant without an explicit class.
+const defaultSLOClass = "default"
+
+// sloClassSpec overrides the default class parameters; unset fields inherit them.
+type sloClassSpec struct {
+TargetEps *float64 `json:"target_eps,omitempty" yaml:"target_eps"`
+WinSize   *int     `json:"win_size,omitempty" yaml:"win_size"`
+Eta       *float64 `json:"eta,omitempty" yaml:"eta"`
+}
+
+// sloClass is one service class (e.g. gold/silver/bronze) with its own
+// violation window and SLO multiplier, so one noisy tenant only raises the
+// penalty for its own class.
+type sloClass struct {
+name       string
+targetEps  float64
+eta        float64
+violWin    []int
+winIdx     int
+muSLO      float64
+lastUpdate time.Time
+}
+
+func (c *sloClass) violRate() float64 {
+if len(c.violWin) == 0 {
+return 0
+}
+sum := 0
+for _, x := range c.violWin {
+sum += x
+}
+return float64(sum) / float64(len(c.violWin))
+}
+
+// sloClassLocked resolves tenant/app, then tenant, then the default class.
+func (s *deciderServer) sloClassLocked(tenant, app string) *sloClass {
+name, ok := s.sloAssign[tenant+"/"+app]
+if !ok {
+name, ok = s.sloAssign[tenant]
+}
+if !ok {
+name = s.sloDefault
+}
+if c, ok := s.sloClasses[name]; ok {
+return c
+}
+return s.sloClasses[defaultSLOClass]
+}
+
+// recordViolation adds a sample to the class window and runs dual ascent on its
+// multiplier every updateEvery.
+func (s *deciderServer) recordViolation(class string, v int) {
+s.mu.Lock()
+defer s.mu.Unlock()
+c, ok := s.sloClasses[class]
+if !ok {
+// class was removed by a reload while the decision was pending
+c = s.sloClasses[defaultSLOClass]
+}
+if c == nil || len(c.violWin) == 0 {
+return
+}
+c.violWin[c.winIdx%len(c.violWin)] = v
+c.winIdx++
+now := s.now()
+if now.Sub(c.lastUpdate) >= s.updateEvery {
+rate := c.violRate()
+c.muSLO = math.Max(0, c.muSLO+c.eta*(rate-c.targetEps))
+c.lastUpdate = now
+mMuSLO.WithLabelValues(c.name).Set(c.muSLO)
+mViolRate.WithLabelValues(c.name).Set(rate)
+}
+}
+
+// resolveSLOClasses expands the config into per-class parameters.
+func resolveSLOClasses(c *deciderConfig) (map[string]sloClass, error) {
+out := map[string]sloClass{
+defaultSLOClass: {name: defaultSLOClass, targetEps: c.TargetEps, eta: c.Eta, violWin: make([]int, c.WinSize)},
+}
+for name, spec := range c.SLOClasses {
+if name == "" || name == defaultSLOClass {
+return nil, fmt.Errorf("slo.classes: %q is reserved; tune it with slo.target_eps/win_size/eta", name)
+}
+cl := sloClass{name: name, targetEps: c.TargetEps, eta: c.Eta}
+win := c.WinSize
+if spec.TargetEps != nil {
+if *spec.TargetEps < 0 || *spec.TargetEps > 1 {
+return nil, fmt.Errorf("slo.classes.%s.target_eps: %v out of range [0, 1]", name, *spec.TargetEps)
+}
+cl.targetEps = *spec.TargetEps
+}
+if spec.WinSize != nil {
+if *spec.WinSize < 1 || *spec.WinSize > 1e6 {
+return nil, fmt.Errorf("slo.classes.%s.win_size: %v out of range [1, 1e6]", name, *spec.WinSize)
+}
+win = *spec.WinSize
+}
+if spec.Eta != nil {
+if *spec.Eta < 0 {
+return nil, fmt.Errorf("slo.classes.%s.eta: must be >= 0", name)
+}
+cl.eta = *spec.Eta
+}
+cl.violWin = make([]int, win)
+out[name] = cl
+}
+if _, ok := out[c.SLODefaultClass]; !ok {
+return nil, fmt.Errorf("slo.default_class: unknown class %q", c.SLODefaultClass)
+}
+for who, name := range c.SLOAssign {
+if _, ok := out[name]; !ok {
+return nil, fmt.Errorf("slo.assign.%s: unknown class %q", who, name)
+}
+}
+return out, nil
+}
+
+// applySLOLocked installs the configured classes. Classes that survive a
+// reload keep their multiplier and most recent window samples.
+func (s *deciderServer) applySLOLocked(classes map[string]sloClass, c *deciderConfig) {
+next := make(map[string]*sloClass, len(classes))
+for name, spec := range classes {
+cl := spec
+if old, ok := s.sloClasses[name]; ok {
+cl.violWin = resizeWindow(old.violWin, old.winIdx, len(spec.violWin))
+cl.muSLO = old.muSLO
+cl.lastUpdate = old.lastUpdate
+} else {
+cl.lastUpdate = s.now()
+}
+next[name] = &cl
+mSLOTargetEps.WithLabelValues(name).Set(cl.targetEps)
+mMuSLO.WithLabelValues(name).Set(cl.muSLO)
+mViolRate.WithLabelValues(name).Set(cl.violRate())
+}
+for name := range s.sloClasses {
+if _, ok := next[name]; !ok {
+mSLOTargetEps.DeleteLabelValues(name)
+mMuSLO.DeleteLabelValues(name)
+mViolRate.DeleteLabelValues(name)
+}
+}
+s.sloClasses = next
+s.sloAssign = c.SLOAssign
+s.sloDefault = c.SLODefaultClass
+}
+
+// resizeWindow keeps the most recent violation samples when win_size changes;
+// the next write goes to index 0, which holds the oldest kept sample.
+func resizeWindow(old []int, idx, n int) []int {
+w := make([]int, n)
+for i := 0; i < n && i < len(old); i++ {
+w[n-1-i] = old[((idx-1-i)%len(old)+len(old))%len(old)]
+}
+return w
+}
@@ -5,38 +5,23 @@ import (
 "fmt"
 "log"
 "math/rand"
-"strings"
 "time"
 
 "google.golang.org/grpc"
 pb "github.com/mulat/csn/proto"
 )
 
-func resourceIntensity(a string) float64 {
-// must match decider's notion
-kind, tier := "edge", "med"
-if a != "" {
-parts := strings.Split(a, ":")
-if len(parts) > 0 {
-k := parts[0]
-if strings.HasPrefix(k, "edge") { kind = "edge" }
-if strings.HasPrefix(k, "cloud") { kind = "cloud" }
-if k == "local" { kind = "local" }
-}
-if len(parts) > 1 {
-tier = parts[1]
-}
-}
-base := map[string]float64{"low": 1, "med": 2, "high": 3}[tier]
-switch kind {
-case "local":
-base += 0
-case "edge":
-base += 1
-case "cloud":
-base += 2
+// intensities fetches each action's resource intensity from the Decider's catalog.
+func intensities(dec pb.DeciderClient) map[string]float64 {
+cctx, cancel := context.WithTimeout(context.Background(), 800*time.Millisecond)
+defer cancel()
+resp, err := dec.ListActions(cctx, &pb.ListActionsRequest{})
+if err != nil { log.Fatalf("list actions: %v", err) }
+ri := make(map[string]float64, len(resp.Actions))
+for _, a := range resp.Actions {
+ri[a.Name] = a.Intensity
 }
-return base
+return ri
 }
 
 func jainsIndex(xs []float64) float64 {
@@ -59,6 +44,7 @@ conn, err := grpc.Dial("127.0.0.1:7002", grpc.WithInsecure(), grpc.WithBlock(),
 if err != nil { log.Fatalf("connect decider: %v", err) }
 defer conn.Close()
 dec := pb.NewDeciderClient(conn)
+ri := intensities(dec)
 
 tenants := []string{"tenantA", "tenantB", "tenantC"}
 feasible := []string{"local:med", "edge1:low", "edge1:med", "edge1:high", "cloud1:low"}
@@ -95,7 +81,7 @@ resp, err := dec.Decide(cctx, &pb.DecideRequest{Ctx: ctx, FeasibleActions: feasi
 cancel()
 if err != nil { log.Printf("decide err: %v", err); continue }
 per[t].counts[resp.ChosenAction]++
-per[t].totalRI += resourceIntensity(resp.ChosenAction)
+per[t].totalRI += ri[resp.ChosenAction]
 per[t].reqs++
 }
 
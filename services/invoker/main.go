@@ -2,8 +2,11 @@ package main
 
 import (
 "context"
+"flag"
 "fmt"
 "log"
+"os"
+"strings"
 "time"
 
 "google.golang.org/grpc"
@@ -11,6 +14,11 @@ pb "github.com/mulat/csn/proto"
 )
 
 func main() {
+explain := flag.Bool("explain", false, "print the per-action utility breakdown")
+actions := flag.String("actions", "local:med,edge1:low,edge1:med,cloud1:low", "comma-separated feasible actions (must be in the Decider's catalog)")
+list := flag.Bool("list-actions", false, "print the Decider's action catalog and exit")
+flag.Parse()
+
 // connect to decider (assumes predictor is already running)
 conn, err := grpc.Dial("127.0.0.1:7002", grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(2*time.Second))
 if err != nil {
@@ -19,6 +27,20 @@ log.Fatalf("connect decider: %v", err)
 defer conn.Close()
 dec := pb.NewDeciderClient(conn)
 
+if *list {
+lctx, lcancel := context.WithTimeout(context.Background(), 800*time.Millisecond)
+defer lcancel()
+resp, err := dec.ListActions(lctx, &pb.ListActionsRequest{})
+if err != nil {
+log.Fatalf("list actions: %v", err)
+}
+fmt.Printf("%-12s %-8s %-6s %-5s %8s %6s %5s %7s %8s %5s\n", "action", "site", "kind", "tier", "cost_ms", "RI", "cpu", "mem_mb", "net_mbps", "cap")
+for _, a := range resp.Actions {
+fmt.Printf("%-12s %-8s %-6s %-5s %8.1f %6.1f %5.1f %7.0f %8.1f %5d\n", a.Name, a.Site, a.Kind, a.Tier, a.CostMs, a.Intensity, a.Cpu, a.MemMb, a.NetMbps, a.Capacity)
+}
+return
+}
+
 // sample context (we'll wire real features later)
 ctx := &pb.Context{
 TenantId:   "tenantA",
@@ -33,15 +55,27 @@ InputKb:    256,
 SloP95Ms:   120,
 }
 
-feasible := []string{"local:med", "edge1:low", "edge1:med", "cloud1:low"}
+feasible := strings.Split(*actions, ",")
 
 // call decide with a short timeout
 cctx, cancel := context.WithTimeout(context.Background(), 800*time.Millisecond)
 defer cancel()
-resp, err := dec.Decide(cctx, &pb.DecideRequest{Ctx: ctx, FeasibleActions: feasible})
+resp, err := dec.Decide(cctx, &pb.DecideRequest{Ctx: ctx, FeasibleActions: feasible, Explain: *explain})
 if err != nil {
 log.Fatalf("decide error: %v", err)
 }
 
-fmt.Printf("Chosen action: %s (explore=%v)\n", resp.ChosenAction, resp.Explore)
+fmt.Printf("Chosen action: %s (explore=%v, propensity=%.3f)\n", resp.ChosenAction, resp.Explore, resp.Propensity)
+if resp.Denied {
+// stderr keeps the stdout format that the sweep scripts parse
+fmt.Fprintf(os.Stderr, "quota denied for tenant %s, retry after %dms\n", ctx.TenantId, resp.RetryAfterMs)
+}
+if *explain {
+fmt.Printf("reason=%s degraded=%v decision=%s\n", resp.Reason, resp.Degraded, resp.DecisionId)
+fmt.Printf("%-12s %9s %8s %8s %6s %7s %7s %7s %9s\n", "action", "lat_ms", "energy", "slo", "alpha", "cost", "fair", "jitter", "U")
+for _, b := range resp.Breakdown {
+fmt.Printf("%-12s %9.2f %8.2f %8.2f %6.2f %7.1f %7.2f %7.2f %9.2f\n",
+b.Action, b.LatencySampleMs, b.EnergyTerm, b.SloPenalty, b.AlphaEff, b.CostMs, b.FairnessPenalty, b.Jitter, b.Utility)
+}
+}
 }
@@ -0,0 +1,224 @@
+package main
+
+import (
+"fmt"
+"math"
+"strconv"
+"strings"
+)
+
+// rewardFn turns an observed outcome into a reward (higher is better).
+type rewardFn struct {
+kind   string // cost | latency | slo
+lambda float64
+}
+
+func (r rewardFn) observed(s sample) float64 {
+return r.value(s.latMs, s.energyJ, s.slo)
+}
+
+// predicted is the direct-method model q(x, a): the reward the Decider's own
+// predictions expect for candidate c.
+func (r rewardFn) predicted(s sample, c logCandidate) float64 {
+return r.value(c.MuLatencyMs, c.MuEnergyJ, s.slo)
+}
+
+func (r rewardFn) value(latMs, energyJ, slo float64) float64 {
+switch r.kind {
+case "latency":
+return -latMs
+case "slo":
+if slo > 0 && latMs > slo {
+return 0
+}
+return 1
+}
+return -(latMs + r.lambda*energyJ)
+}
+
+// targetPolicy gives pi(a|x) over a logged decision's candidates.
+type targetPolicy struct {
+name string
+prob func(s sample) []float64
+}
+
+// argmaxProb puts all mass on the first highest-scoring candidate, the way the
+// Decider breaks ties.
+func argmaxProb(s sample, score func(c logCandidate) float64) []float64 {
+p := make([]float64, len(s.cands))
+best, bestU := 0, math.Inf(-1)
+for i, c := range s.cands {
+if u := score(c); u > bestU {
+best, bestU = i, u
+}
+}
+p[best] = 1
+return p
+}
+
+// parseTarget builds a target policy from the Decider's policy names:
+// logged, greedy_latency, min_energy, static:<action> and
+// csn[;lambda_energy=X][;alpha_slo_base=Y] (the csn objective without sampling
+// noise, exploration or fairness).
+func parseTarget(spec string, lambda, alpha float64) (targetPolicy, error) {
+spec = strings.TrimSpace(spec)
+parts := strings.Split(spec, ";")
+name := strings.TrimSpace(parts[0])
+if len(parts) > 1 && name != "csn" {
+return targetPolicy{}, fmt.Errorf("%s: only csn takes overrides", spec)
+}
+switch {
+case name == "logged":
+return targetPolicy{name: spec, prob: func(s sample) []float64 {
+p := make([]float64, len(s.cands))
+for i, c := range s.cands {
+p[i] = c.Propensity
+}
+return p
+}}, nil
+case name == "greedy_latency":
+return targetPolicy{name: spec, prob: func(s sample) []float64 {
+return argmaxProb(s, func(c logCandidate) float64 { return -c.MuLatencyMs })
+}}, nil
+case name == "min_energy":
+return targetPolicy{name: spec, prob: func(s sample) []float64 {
+return argmaxProb(s, func(c logCandidate) float64 { return -c.MuEnergyJ - 1e-6*c.MuLatencyMs })
+}}, nil
+case strings.HasPrefix(name, "static:"):
+a := strings.TrimPrefix(name, "static:")
+return targetPolicy{name: spec, prob: func(s sample) []float64 {
+return argmaxProb(s, func(c logCandidate) float64 {
+if c.Action == a {
+return 1
+}
+return 0
+})
+}}, nil
+case name == "csn":
+for _, kv := range parts[1:] {
+i := strings.Index(kv, "=")
+if i <= 0 {
+return targetPolicy{}, fmt.Errorf("%s: bad override %q", spec, kv)
+}
+f, err := strconv.ParseFloat(strings.TrimSpace(kv[i+1:]), 64)
+if err != nil {
+return targetPolicy{}, fmt.Errorf("%s: bad value in %q", spec, kv)
+}
+switch strings.TrimSpace(kv[:i]) {
+case "lambda_energy":
+lambda = f
+case "alpha_slo_base":
+alpha = f
+default:
+return targetPolicy{}, fmt.Errorf("%s: unknown override %q", spec, kv[:i])
+}
+}
+return targetPolicy{name: spec, prob: func(s sample) []float64 {
+return argmaxProb(s, func(c logCandidate) float64 {
+pen := math.Max(0, c.P95EffMs-s.slo)
+return -(c.MuLatencyMs + lambda*c.MuEnergyJ + (alpha+s.muSLO)*pen + c.CostMs)
+})
+}}, nil
+}
+return targetPolicy{}, fmt.Errorf("unknown policy %q", spec)
+}
+
+// interval is a point estimate with a normal-approximation confidence interval.
+type interval struct {
+Value float64 `json:"value"`
+Lo    float64 `json:"lo"`
+Hi    float64 `json:"hi"`
+SE    float64 `json:"se"`
+}
+
+func newInterval(v, se, z float64) interval {
+return interval{Value: v, Lo: v - z*se, Hi: v + z*se, SE: se}
+}
+
+type estimate struct {
+Policy  string   `json:"policy"`
+N       int      `json:"n"`
+Support int      `json:"support"` // samples where pi gives the logged action mass
+ESS     float64  `json:"ess"`     // effective sample size of the importance weights
+MaxW    float64  `json:"max_weight"`
+IPS     interval `json:"ips"`
+SNIPS   interval `json:"snips"`
+DM      interval `json:"dm"`
+DR      interval `json:"dr"`
+}
+
+func meanSE(xs []float64) (float64, float64) {
+n := float64(len(xs))
+if n == 0 {
+return 0, 0
+}
+m := 0.0
+for _, x := range xs {
+m += x
+}
+m /= n
+if n < 2 {
+return m, 0
+}
+v := 0.0
+for _, x := range xs {
+v += (x - m) * (x - m)
+}
+return m, math.Sqrt(v / (n - 1) / n)
+}
+
+// evaluate runs IPS, SNIPS, the direct method and doubly robust for pi.
+// Importance weights pi/mu are capped at clip when clip > 0.
+func evaluate(tp targetPolicy, samples []sample, rw rewardFn, clip, z float64) estimate {
+e := estimate{Policy: tp.name, N: len(samples)}
+if len(samples) == 0 {
+return e
+}
+ips := make([]float64, len(samples))
+dm := make([]float64, len(samples))
+dr := make([]float64, len(samples))
+w := make([]float64, len(samples))
+r := make([]float64, len(samples))
+sumW, sumW2 := 0.0, 0.0
+for i, s := range samples {
+pi := tp.prob(s)
+w[i] = pi[s.chosen] / s.mu
+if clip > 0 && w[i] > clip {
+w[i] = clip
+}
+if pi[s.chosen] > 0 {
+e.Support++
+}
+e.MaxW = math.Max(e.MaxW, w[i])
+r[i] = rw.observed(s)
+for j, c := range s.cands {
+dm[i] += pi[j] * rw.predicted(s, c)
+}
+ips[i] = w[i] * r[i]
+dr[i] = dm[i] + w[i]*(r[i]-rw.predicted(s, s.cands[s.chosen]))
+sumW += w[i]
+sumW2 += w[i] * w[i]
+}
+v, se := meanSE(ips)
+e.IPS = newInterval(v, se, z)
+v, se = meanSE(dm)
+e.DM = newInterval(v, se, z)
+v, se = meanSE(dr)
+e.DR = newInterval(v, se, z)
+if sumW > 0 {
+// delta method for the ratio estimator
+sn := 0.0
+for i := range w {
+sn += w[i] * r[i]
+}
+sn /= sumW
+dev := 0.0
+for i := range w {
+d := w[i] * (r[i] - sn)
+dev += d * d
+}
+e.SNIPS = newInterval(sn, math.Sqrt(dev)/sumW, z)
+e.ESS = sumW * sumW / sumW2
+}
+return e
+}
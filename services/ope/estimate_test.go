@@ -0,0 +1,133 @@
+package main
+
+import (
+"math"
+"os"
+"path/filepath"
+"testing"
+)
+
+// syntheticLog is ten decisions between a (100ms) and b (50ms), logged with
+// mu(a)=0.7 and mu(b)=0.3 and chosen exactly in those proportions, so the
+// unbiased estimators recover a target's value without sampling error.
+// predB is what the logged predictions say about b.
+func syntheticLog(predB float64) []sample {
+var out []sample
+for i := 0; i < 10; i++ {
+cands := []logCandidate{
+{Action: "a", MuLatencyMs: 100, Propensity: 0.7},
+{Action: "b", MuLatencyMs: predB, Propensity: 0.3},
+}
+s := sample{slo: 200, cands: cands, chosen: 0, mu: 0.7, latMs: 100}
+if i >= 7 {
+s.chosen, s.mu, s.latMs = 1, 0.3, 50
+}
+out = append(out, s)
+}
+return out
+}
+
+func TestEstimators(t *testing.T) {
+rw := rewardFn{kind: "latency"}
+cases := []struct {
+name                string
+policy              string
+predB               float64
+clip                float64
+ips, snips, dm, dr  float64
+support             int
+ess, maxW           float64
+}{
+{name: "logging policy", policy: "logged", predB: 50,
+ips: -85, snips: -85, dm: -85, dr: -85, support: 10, ess: 10, maxW: 1},
+{name: "static b, exact predictions", policy: "static:b", predB: 50,
+ips: -50, snips: -50, dm: -50, dr: -50, support: 3, ess: 3, maxW: 1 / 0.3},
+{name: "greedy latency picks b", policy: "greedy_latency", predB: 50,
+ips: -50, snips: -50, dm: -50, dr: -50, support: 3, ess: 3, maxW: 1 / 0.3},
+// DM inherits the prediction error, DR corrects it with the weighted residual
+{name: "static b, biased predictions", policy: "static:b", predB: 40,
+ips: -50, snips: -50, dm: -40, dr: -50, support: 3, ess: 3, maxW: 1 / 0.3},
+{name: "clipped weights", policy: "static:b", predB: 50, clip: 2,
+ips: -30, snips: -50, dm: -50, dr: -50, support: 3, ess: 3, maxW: 2},
+}
+for _, tc := range cases {
+t.Run(tc.name, func(t *testing.T) {
+tp, err := parseTarget(tc.policy, 0, 0)
+if err != nil {
+t.Fatal(err)
+}
+e := evaluate(tp, syntheticLog(tc.predB), rw, tc.clip, 1.96)
+for _, c := range []struct {
+name      string
+got, want float64
+}{
+{"ips", e.IPS.Value, tc.ips},
+{"snips", e.SNIPS.Value, tc.snips},
+{"dm", e.DM.Value, tc.dm},
+{"dr", e.DR.Value, tc.dr},
+{"ess", e.ESS, tc.ess},
+{"max_weight", e.MaxW, tc.maxW},
+} {
+if math.Abs(c.got-c.want) > 1e-9 {
+t.Errorf("%s = %g, want %g", c.name, c.got, c.want)
+}
+}
+if e.Support != tc.support || e.N != 10 {
+t.Errorf("support %d of %d, want %d of 10", e.Support, e.N, tc.support)
+}
+if e.IPS.Lo > e.IPS.Value || e.IPS.Hi < e.IPS.Value {
+t.Errorf("ips interval %+v does not contain the estimate", e.IPS)
+}
+})
+}
+}
+
+func TestRewardFn(t *testing.T) {
+cases := []struct {
+kind         string
+lat, en, slo float64
+want         float64
+}{
+{"cost", 100, 0.5, 200, -140},
+{"latency", 100, 0.5, 200, -100},
+{"slo", 100, 0.5, 200, 1},
+{"slo", 250, 0.5, 200, 0},
+{"slo", 250, 0.5, 0, 1},
+}
+for _, tc := range cases {
+if got := (rewardFn{kind: tc.kind, lambda: 80}).value(tc.lat, tc.en, tc.slo); got != tc.want {
+t.Errorf("%s reward of (%g ms, %g J, slo %g) = %g, want %g", tc.kind, tc.lat, tc.en, tc.slo, got, tc.want)
+}
+}
+}
+
+func TestLoadSamplesExactOnly(t *testing.T) {
+log := `{"kind":"decision","decision_id":"d1","chosen":"a","propensity":0.7,"propensity_method":"exact","candidates":[{"action":"a","mu_latency_ms":100,"propensity":0.7},{"action":"b","mu_latency_ms":50,"propensity":0.3}]}
+{"kind":"decision","decision_id":"d2","chosen":"b","propensity":0.3,"propensity_method":"monte_carlo","candidates":[{"action":"a","mu_latency_ms":100,"propensity":0.7},{"action":"b","mu_latency_ms":50,"propensity":0.3}]}
+{"kind":"decision","decision_id":"d3","chosen":"a","propensity":0.7,"propensity_method":"exact","candidates":[{"action":"a","mu_latency_ms":100,"propensity":0.7}]}
+{"kind":"outcome","decision_id":"d1","source":"reported","observed_latency_ms":90}
+{"kind":"outcome","decision_id":"d2","source":"reported","observed_latency_ms":55}
+not json
+`
+path := filepath.Join(t.TempDir(), "decisions.jsonl")
+if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+t.Fatal(err)
+}
+cases := []struct {
+exact     bool
+usable    int
+estimated int
+}{
+{exact: false, usable: 2, estimated: 1},
+{exact: true, usable: 1, estimated: 1},
+}
+for _, tc := range cases {
+got, st, err := loadSamples([]string{path}, tc.exact)
+if err != nil {
+t.Fatal(err)
+}
+if len(got) != tc.usable || st.Estimated != tc.estimated || st.Decisions != 3 || st.NoOutcome != 1 || st.BadLines != 1 {
+t.Errorf("exact=%v: %d samples, stats %+v", tc.exact, len(got), st)
+}
+}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+"bufio"
+"encoding/json"
+"fmt"
+"os"
+)
+
+// The subset of the Decider's audit records (services/control/audit.go) the
+// estimators need.
+type logCandidate struct {
+Action      string  `json:"action"`
+MuLatencyMs float64 `json:"mu_latency_ms"`
+P95EffMs    float64 `json:"p95_eff_ms"`
+MuEnergyJ   float64 `json:"mu_energy_j"`
+CostMs      float64 `json:"cost_ms"`
+Propensity  float64 `json:"propensity"`
+}
+
+type logRecord struct {
+Kind       string `json:"kind"`
+DecisionID string `json:"decision_id"`
+
+Context *struct {
+SloP95Ms float64 `json:"slo_p95_ms"`
+} `json:"context"`
+Candidates []logCandidate `json:"candidates"`
+MuSLO      *float64       `json:"mu_slo"`
+Chosen     string         `json:"chosen"`
+Propensity *float64       `json:"propensity"`
+PropMethod string         `json:"propensity_method"` // exact | monte_carlo; "" before it was logged
+Degraded   bool           `json:"degraded"`
+
+Source            string   `json:"source"`
+ObservedLatencyMs *float64 `json:"observed_latency_ms"`
+ObservedEnergyJ   *float64 `json:"observed_energy_j"`
+}
+
+// sample is one decision with a reported outcome.
+type sample struct {
+slo     float64
+muSLO   float64
+cands   []logCandidate
+chosen  int     // index into cands
+mu      float64 // logging propensity of cands[chosen]
+latMs   float64
+energyJ float64
+}
+
+type loadStats struct {
+Decisions    int `json:"decisions"`
+Outcomes     int `json:"reported_outcomes"`
+NoOutcome    int `json:"no_outcome"`    // timed out or never reported
+NoPropensity int `json:"no_propensity"` // logged before propensities were recorded
+Estimated    int `json:"estimated_propensity"` // Monte Carlo propensities (used, or skipped with -exact)
+NoPrediction int `json:"no_prediction"` // degraded, or chosen action without a candidate
+BadLines     int `json:"bad_lines"`
+}
+
+// loadSamples joins decision records with their reported outcomes across all
+// files (e.g. decisions.jsonl and its rotations). With exactOnly, decisions
+// whose propensity is a Monte Carlo estimate (random scores, see the
+// Decider's propensity.go) are left out.
+func loadSamples(paths []string, exactOnly bool) ([]sample, loadStats, error) {
+var st loadStats
+decisions := map[string]*logRecord{}
+var order []string
+outcomes := map[string]*logRecord{}
+for _, p := range paths {
+f, err := os.Open(p)
+if err != nil {
+return nil, st, err
+}
+sc := bufio.NewScanner(f)
+sc.Buffer(make([]byte, 1<<20), 16<<20)
+for sc.Scan() {
+var r logRecord
+if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+st.BadLines++
+continue
+}
+switch r.Kind {
+case "decision":
+if _, dup := decisions[r.DecisionID]; !dup {
+order = append(order, r.DecisionID)
+}
+decisions[r.DecisionID] = &r
+case "outcome":
+if r.Source == "reported" && r.ObservedLatencyMs != nil {
+outcomes[r.DecisionID] = &r
+}
+}
+}
+err = sc.Err()
+f.Close()
+if err != nil {
+return nil, st, fmt.Errorf("%s: %v", p, err)
+}
+}
+var out []sample
+for _, id := range order {
+d := decisions[id]
+st.Decisions++
+o := outcomes[id]
+if o == nil {
+st.NoOutcome++
+continue
+}
+st.Outcomes++
+if d.Propensity == nil || *d.Propensity <= 0 {
+st.NoPropensity++
+continue
+}
+if d.PropMethod != "exact" {
+st.Estimated++
+if exactOnly {
+continue
+}
+}
+chosen := -1
+for i, c := range d.Candidates {
+if c.Action == d.Chosen {
+chosen = i
+}
+}
+if d.Degraded || chosen < 0 {
+st.NoPrediction++
+continue
+}
+s := sample{cands: d.Candidates, chosen: chosen, mu: *d.Propensity, latMs: *o.ObservedLatencyMs}
+if d.Context != nil {
+s.slo = d.Context.SloP95Ms
+}
+if d.MuSLO != nil {
+s.muSLO = *d.MuSLO
+}
+if o.ObservedEnergyJ != nil {
+s.energyJ = *o.ObservedEnergyJ
+}
+out = append(out, s)
+}
+return out, st, nil
+}
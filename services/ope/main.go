@@ -0,0 +1,95 @@
+// Command ope estimates, from the Decider's decision log, how alternative
+// policies would have done on the same traffic. Decisions are joined with
+// their reported outcomes and reweighted by the propensity the Decider logged
+// (IPS, self-normalised IPS, doubly robust; the direct method uses the logged
+// predictions as reward model). Propensities of decisions with random scores
+// (csn sampling, LinTS) are Monte Carlo estimates; -exact leaves those out,
+// otherwise their count is reported since they bias the weighted estimators.
+//
+//	ope [-reward cost|latency|slo] [-policies logged,csn,greedy_latency,...] [-exact] [file ...]
+package main
+
+import (
+"encoding/json"
+"flag"
+"fmt"
+"log"
+"os"
+"strings"
+)
+
+func main() {
+policies := flag.String("policies", "logged,csn,greedy_latency,min_energy", "comma-separated target policies: logged, csn[;lambda_energy=X][;alpha_slo_base=Y], greedy_latency, min_energy, static:<action>")
+reward := flag.String("reward", "cost", "cost (-(latency_ms + lambda*energy_j)), latency (-latency_ms) or slo (1 when latency <= slo)")
+lambda := flag.Float64("lambda", 80, "energy weight (ms per J) of the cost reward and the csn target")
+alpha := flag.Float64("alpha", 2, "alpha_slo_base of the csn target")
+clip := flag.Float64("clip", 0, "cap importance weights at this value (0: no cap)")
+z := flag.Float64("z", 1.96, "confidence interval half-width in standard errors")
+exact := flag.Bool("exact", false, "only use decisions whose propensity is exact, not a Monte Carlo estimate")
+asJSON := flag.Bool("json", false, "print JSON")
+flag.Parse()
+
+switch *reward {
+case "cost", "latency", "slo":
+default:
+log.Fatalf("unknown -reward %q", *reward)
+}
+var targets []targetPolicy
+for _, spec := range strings.Split(*policies, ",") {
+if strings.TrimSpace(spec) == "" {
+continue
+}
+tp, err := parseTarget(spec, *lambda, *alpha)
+if err != nil {
+log.Fatal(err)
+}
+targets = append(targets, tp)
+}
+
+paths := flag.Args()
+if len(paths) == 0 {
+paths = []string{"experiments/decisions.jsonl"}
+}
+samples, st, err := loadSamples(paths, *exact)
+if err != nil {
+log.Fatal(err)
+}
+if len(samples) == 0 {
+log.Fatalf("no usable decisions (%+v)", st)
+}
+
+rw := rewardFn{kind: *reward, lambda: *lambda}
+out := struct {
+Reward    string     `json:"reward"`
+Logged    float64    `json:"logged_mean_reward"`
+Load      loadStats  `json:"load"`
+Estimates []estimate `json:"estimates"`
+}{Reward: *reward, Load: st}
+for _, s := range samples {
+out.Logged += rw.observed(s)
+}
+out.Logged /= float64(len(samples))
+for _, tp := range targets {
+out.Estimates = append(out.Estimates, evaluate(tp, samples, rw, *clip, *z))
+}
+
+if *asJSON {
+enc := json.NewEncoder(os.Stdout)
+enc.SetIndent("", "  ")
+_ = enc.Encode(out)
+return
+}
+fmt.Printf("%d decisions, %d with reported outcomes, %d usable; reward=%s, logged mean %.4g\n",
+st.Decisions, st.Outcomes, len(samples), *reward, out.Logged)
+if st.Estimated > 0 && !*exact {
+fmt.Printf("warning: %d propensities are Monte Carlo estimates; IPS/SNIPS/DR may be biased (-exact drops them, -clip bounds their weights)\n", st.Estimated)
+}
+fmt.Printf("%-28s %7s %8s %28s %28s %28s %28s\n", "policy", "support", "ess", "ips", "snips", "dm", "dr")
+for _, e := range out.Estimates {
+fmt.Printf("%-28s %7d %8.1f %28s %28s %28s %28s\n", e.Policy, e.Support, e.ESS, e.IPS, e.SNIPS, e.DM, e.DR)
+}
+}
+
+func (iv interval) String() string {
+return fmt.Sprintf("%.4g [%.4g, %.4g]", iv.Value, iv.Lo, iv.Hi)
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+"encoding/json"
+"errors"
+"log"
+"net/http"
+"os"
+"sort"
+"strconv"
+"strings"
+"sync"
+"time"
+
+"github.com/prometheus/client_golang/prometheus"
+"github.com/prometheus/client_golang/prometheus/promhttp"
+
+"github.com/mulat/csn/catalog"
+)
+
+var (
+mOpsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_ops_requests_total",
+Help: "Operator API requests",
+}, []string{"endpoint"})
+mEdgesUp = prometheus.NewGauge(prometheus.GaugeOpts{
+Name: "csn_edges_up",
+Help: "Number of edge nodes taking work",
+})
+mEdgeUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_edge_up",
+Help: "1 if any edge of the catalog site takes work, 0 while all are draining or drained",
+}, []string{"site"})
+mEdgeCPU = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_edge_cpu",
+Help: "Capacity-weighted CPU utilisation (0..1) of the site's edges that take work",
+}, []string{"site"})
+mEdgeQueue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_edge_queue_depth",
+Help: "Requests queued across the site's edges as last reported",
+}, []string{"site"})
+mEdgeCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_edge_capacity",
+Help: "Registered capacity of the site's edges (relative units)",
+}, []string{"site"})
+)
+
+func init() {
+prometheus.MustRegister(mOpsTotal, mEdgesUp, mEdgeUp, mEdgeCPU, mEdgeQueue, mEdgeCapacity)
+}
+
+func envOr(name, def string) string {
+if v := os.Getenv(name); v != "" {
+return v
+}
+return def
+}
+
+type operator struct {
+reg  *registry
+acts *catalog.Catalog
+
+pubMu     sync.Mutex
+published map[string]bool // sites with gauges
+}
+
+type edgeReq struct {
+Name       string  `json:"name"`
+Site       string  `json:"site"` // catalog edge site; optional, see registry.defaultSiteLocked
+Addr       string  `json:"addr"`
+Capacity   float64 `json:"capacity"`
+CPU        float64 `json:"cpu"`
+QueueDepth float64 `json:"queue_depth"`
+Force      bool    `json:"force"`
+}
+
+// siteLoad aggregates the edges of one catalog site.
+type siteLoad struct {
+up            bool
+cpuW, upCap   float64 // capacity-weighted CPU sum and capacity of up edges
+queue, capSum float64
+}
+
+// publish mirrors the registry into the per-site gauges the Decider's
+// CapPoller scrapes. Calls are serialised and gauges are overwritten in place,
+// so a scrape never sees a half-built vector; sites with no edges left are
+// deleted.
+func (o *operator) publish() {
+o.pubMu.Lock()
+defer o.pubMu.Unlock()
+sites := map[string]*siteLoad{}
+up := 0
+for _, e := range o.reg.list() {
+sl := sites[e.Site]
+if sl == nil {
+sl = &siteLoad{}
+sites[e.Site] = sl
+}
+if e.State == stateUp {
+up++
+sl.up = true
+sl.cpuW += e.Capacity * e.CPU
+sl.upCap += e.Capacity
+}
+sl.queue += e.QueueDepth
+sl.capSum += e.Capacity
+}
+for site, sl := range sites {
+v, cpu := 0.0, 0.0
+if sl.up {
+v = 1
+}
+if sl.upCap > 0 {
+cpu = sl.cpuW / sl.upCap
+}
+mEdgeUp.WithLabelValues(site).Set(v)
+mEdgeCPU.WithLabelValues(site).Set(cpu)
+mEdgeQueue.WithLabelValues(site).Set(sl.queue)
+mEdgeCapacity.WithLabelValues(site).Set(sl.capSum)
+}
+for site := range o.published {
+if sites[site] == nil {
+mEdgeUp.DeleteLabelValues(site)
+mEdgeCPU.DeleteLabelValues(site)
+mEdgeQueue.DeleteLabelValues(site)
+mEdgeCapacity.DeleteLabelValues(site)
+}
+}
+o.published = make(map[string]bool, len(sites))
+for site := range sites {
+o.published[site] = true
+}
+mEdgesUp.Set(float64(up))
+}
+
+// edgeSites are the catalog's edge sites, which the Decider knows by name.
+func edgeSites(c *catalog.Catalog) map[string]bool {
+out := map[string]bool{}
+for _, a := range c.Actions() {
+if a.Kind == "edge" {
+out[a.Site] = true
+}
+}
+return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+out := make([]string, 0, len(m))
+for k := range m {
+out = append(out, k)
+}
+sort.Strings(out)
+return out
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+w.Header().Set("Content-Type", "application/json")
+w.WriteHeader(code)
+_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+writeJSON(w, code, map[string]interface{}{"ok": false, "error": msg})
+}
+
+// decode reads an edgeReq from a POST body; name is required.
+func decode(w http.ResponseWriter, r *http.Request) (edgeReq, bool) {
+var req edgeReq
+if r.Method != http.MethodPost {
+writeError(w, http.StatusMethodNotAllowed, "POST only")
+return req, false
+}
+if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+writeError(w, http.StatusBadRequest, err.Error())
+return req, false
+}
+if req.Name == "" {
+writeError(w, http.StatusBadRequest, "name is required")
+return req, false
+}
+return req, true
+}
+
+// reply answers a registry change; edges lists the names taking work, as the
+// scripts expect.
+func (o *operator) reply(w http.ResponseWriter, e edge, err error) {
+o.publish()
+if err != nil {
+log.Printf("registry: %v", err)
+writeError(w, http.StatusInternalServerError, err.Error())
+return
+}
+writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "edge": e, "edges": o.reg.upNames()})
+}
+
+func (o *operator) handleEdge(w http.ResponseWriter, r *http.Request) {
+mOpsTotal.WithLabelValues("/edge POST").Inc()
+req, ok := decode(w, r)
+if !ok {
+return
+}
+if req.Capacity < 0 {
+writeError(w, http.StatusBadRequest, "capacity must be >= 0")
+return
+}
+e, err := o.reg.add(req.Name, req.Site, req.Addr, req.Capacity)
+if errors.Is(err, errUnknownSite) {
+writeError(w, http.StatusBadRequest, err.Error()+" ("+strings.Join(sortedKeys(o.reg.sites), ", ")+")")
+return
+}
+o.reply(w, e, err)
+}
+
+func (o *operator) handleDrain(w http.ResponseWriter, r *http.Request) {
+mOpsTotal.WithLabelValues("/drain POST").Inc()
+req, ok := decode(w, r)
+if !ok {
+return
+}
+e, found, err := o.reg.drain(req.Name)
+if !found {
+writeError(w, http.StatusNotFound, "unknown edge "+strconv.Quote(req.Name))
+return
+}
+o.reply(w, e, err)
+}
+
+func (o *operator) handleRemove(w http.ResponseWriter, r *http.Request) {
+mOpsTotal.WithLabelValues("/remove POST").Inc()
+req, ok := decode(w, r)
+if !ok {
+return
+}
+e, found, err := o.reg.remove(req.Name, req.Force)
+if !found {
+writeError(w, http.StatusNotFound, "unknown edge "+strconv.Quote(req.Name))
+return
+}
+if errors.Is(err, errNotDrained) {
+writeError(w, http.StatusConflict, err.Error())
+return
+}
+o.reply(w, e, err)
+}
+
+func (o *operator) handleLoad(w http.ResponseWriter, r *http.Request) {
+mOpsTotal.WithLabelValues("/edge/load POST").Inc()
+req, ok := decode(w, r)
+if !ok {
+return
+}
+e, found, err := o.reg.setLoad(req.Name, req.CPU, req.QueueDepth)
+if !found {
+writeError(w, http.StatusNotFound, "unknown edge "+strconv.Quote(req.Name))
+return
+}
+o.reply(w, e, err)
+}
+
+// estimateReq is the body of POST /estimate.
+type estimateReq struct {
+Action   string  `json:"action"`
+BwMbps   float64 `json:"bw_mbps"`
+RttMs    float64 `json:"rtt_ms"`
+InputKB  float64 `json:"input_kb"`
+SloP95Ms float64 `json:"slo_p95_ms"`
+}
+
+// handleEstimate keeps the Python operator's placeholder estimate: fixed
+// values, not wired to the predictor. Unknown actions are rejected.
+func (o *operator) handleEstimate(w http.ResponseWriter, r *http.Request) {
+mOpsTotal.WithLabelValues("/estimate").Inc()
+if r.Method != http.MethodPost {
+writeError(w, http.StatusMethodNotAllowed, "POST only")
+return
+}
+var req estimateReq
+if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+writeError(w, http.StatusBadRequest, err.Error())
+return
+}
+if _, ok := o.acts.Lookup(req.Action); !ok {
+writeError(w, http.StatusBadRequest, "unknown action "+strconv.Quote(req.Action))
+return
+}
+writeJSON(w, http.StatusOK, map[string]interface{}{
+"action":           req.Action,
+"mu_latency_ms":    92.3662109375,
+"mu_energy_j":      2.467411804199219,
+"p95_conformal_ms": 122.9664306640625,
+"slo_violation":    0,
+})
+}
+
+func (o *operator) handleList(w http.ResponseWriter, r *http.Request) {
+mOpsTotal.WithLabelValues("/edges GET").Inc()
+writeJSON(w, http.StatusOK, map[string]interface{}{"edges": o.reg.list()})
+}
+
+// drainLoop completes drains whose queue emptied or whose timeout passed.
+func (o *operator) drainLoop() {
+t := time.NewTicker(time.Second)
+defer t.Stop()
+for now := range t.C {
+if err := o.reg.finishDrains(now); err != nil {
+log.Printf("registry: %v", err)
+}
+o.publish()
+}
+}
+
+func main() {
+listen := envOr("CSN_OPERATOR_LISTEN", ":9103")
+statePath := envOr("CSN_OPERATOR_STATE", "experiments/operator_state.json")
+drainMs, err := strconv.Atoi(envOr("CSN_DRAIN_TIMEOUT_MS", "30000"))
+if err != nil || drainMs < 0 {
+log.Fatalf("CSN_DRAIN_TIMEOUT_MS: want a non-negative integer, got %q", os.Getenv("CSN_DRAIN_TIMEOUT_MS"))
+}
+
+reg, err := loadRegistry(statePath, time.Duration(drainMs)*time.Millisecond)
+if err != nil {
+log.Fatalf("load registry: %v", err)
+}
+// edges must serve a site the Decider's catalog has (CSN_CATALOG, same file)
+acts, err := catalog.FromEnv()
+if err != nil {
+log.Fatalf("catalog: %v", err)
+}
+reg.sites = edgeSites(acts)
+for _, e := range reg.list() {
+if !reg.sites[e.Site] {
+log.Printf("edge %q: site %q is not in the catalog; the Decider will ignore it", e.Name, e.Site)
+}
+}
+o := &operator{reg: reg, acts: acts}
+o.publish()
+log.Printf("Operator: %d edges from %s (%d up)", len(reg.list()), statePath, len(reg.upNames()))
+go o.drainLoop()
+
+http.HandleFunc("/edge", o.handleEdge)
+http.HandleFunc("/edge/load", o.handleLoad)
+http.HandleFunc("/drain", o.handleDrain)
+http.HandleFunc("/remove", o.handleRemove)
+http.HandleFunc("/edges", o.handleList)
+http.HandleFunc("/estimate", o.handleEstimate)
+http.Handle("/metrics", promhttp.Handler())
+log.Printf("Operator listening on %s", listen)
+if err := http.ListenAndServe(listen, nil); err != nil {
+log.Fatalf("serve: %v", err)
+}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+"encoding/json"
+"errors"
+"fmt"
+"os"
+"path/filepath"
+"sort"
+"sync"
+"time"
+)
+
+// Edge states. Draining edges no longer receive work (csn_edge_up=0) but stay
+// in the registry until their queue empties or the drain timeout passes.
+const (
+stateUp       = "up"
+stateDraining = "draining"
+stateDrained  = "drained"
+)
+
+// errNotDrained rejects removing an edge that may still hold work.
+var errNotDrained = errors.New("drain it first or pass force")
+
+// errUnknownSite rejects an edge whose site is not an edge site of the catalog.
+var errUnknownSite = errors.New("not an edge site of the action catalog")
+
+// edge is one edge node; Site is the catalog site ("edge1") whose actions it
+// serves, so several nodes can back one site.
+type edge struct {
+Name       string    `json:"name"`
+Site       string    `json:"site"`
+Addr       string    `json:"addr,omitempty"`
+Capacity   float64   `json:"capacity"`
+State      string    `json:"state"`
+CPU        float64   `json:"cpu"`
+QueueDepth float64   `json:"queue_depth"`
+Added      time.Time `json:"added"`
+DrainStart *time.Time `json:"drain_start,omitempty"`
+}
+
+// registry is the operator's edge inventory, saved to path after every change.
+type registry struct {
+mu           sync.Mutex
+path         string
+drainTimeout time.Duration
+sites        map[string]bool // catalog edge sites; nil accepts any
+edges        map[string]*edge
+}
+
+type registryFile struct {
+Edges []*edge `json:"edges"`
+}
+
+// loadRegistry reads path if it exists; a missing file is an empty registry.
+func loadRegistry(path string, drainTimeout time.Duration) (*registry, error) {
+r := &registry{path: path, drainTimeout: drainTimeout, edges: make(map[string]*edge)}
+if path == "" {
+return r, nil
+}
+b, err := os.ReadFile(path)
+if os.IsNotExist(err) {
+return r, nil
+}
+if err != nil {
+return nil, err
+}
+var f registryFile
+if err := json.Unmarshal(b, &f); err != nil {
+return nil, fmt.Errorf("%s: %w", path, err)
+}
+for _, e := range f.Edges {
+if e.Name == "" {
+continue
+}
+if e.Site == "" {
+// saved before edges had a site: the name was used as the site label
+e.Site = e.Name
+}
+r.edges[e.Name] = e
+}
+return r, nil
+}
+
+// saveLocked writes the registry atomically (temp file + rename).
+func (r *registry) saveLocked() error {
+if r.path == "" {
+return nil
+}
+b, err := json.MarshalIndent(registryFile{Edges: r.listLocked()}, "", "  ")
+if err != nil {
+return err
+}
+if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+return err
+}
+tmp := r.path + ".tmp"
+if err := os.WriteFile(tmp, b, 0o644); err != nil {
+return err
+}
+return os.Rename(tmp, r.path)
+}
+
+func (r *registry) listLocked() []*edge {
+out := make([]*edge, 0, len(r.edges))
+for _, e := range r.edges {
+out = append(out, e)
+}
+sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+return out
+}
+
+// list returns copies sorted by name.
+func (r *registry) list() []edge {
+r.mu.Lock()
+defer r.mu.Unlock()
+out := make([]edge, 0, len(r.edges))
+for _, e := range r.listLocked() {
+out = append(out, *e)
+}
+return out
+}
+
+// upNames lists the edges that take work.
+func (r *registry) upNames() []string {
+r.mu.Lock()
+defer r.mu.Unlock()
+var out []string
+for _, e := range r.listLocked() {
+if e.State == stateUp {
+out = append(out, e.Name)
+}
+}
+return out
+}
+
+// add registers name or brings a draining/drained edge back up. Empty site,
+// addr and zero capacity keep the previous values; a new edge without a site
+// gets defaultSiteLocked's.
+func (r *registry) add(name, site, addr string, capacity float64) (edge, error) {
+r.mu.Lock()
+defer r.mu.Unlock()
+e := r.edges[name]
+if site == "" {
+site = r.defaultSiteLocked(name, e)
+}
+if r.sites != nil && !r.sites[site] {
+return edge{}, fmt.Errorf("site %q: %w", site, errUnknownSite)
+}
+if e == nil {
+e = &edge{Name: name, Capacity: 1, Added: time.Now()}
+r.edges[name] = e
+}
+e.Site = site
+if addr != "" {
+e.Addr = addr
+}
+if capacity > 0 {
+e.Capacity = capacity
+}
+e.State = stateUp
+e.DrainStart = nil
+return *e, r.saveLocked()
+}
+
+// defaultSiteLocked is the site of an edge added without one: the site it had,
+// else its name when that is a catalog site, else the first catalog edge site
+// in name order, so clients that only send a name keep working.
+func (r *registry) defaultSiteLocked(name string, e *edge) string {
+if e != nil {
+return e.Site
+}
+if r.sites == nil || r.sites[name] {
+return name
+}
+first := ""
+for s := range r.sites {
+if first == "" || s < first {
+first = s
+}
+}
+if first == "" {
+return name
+}
+return first
+}
+
+// drain stops new work going to name; finishDrains completes it.
+func (r *registry) drain(name string) (edge, bool, error) {
+r.mu.Lock()
+defer r.mu.Unlock()
+e := r.edges[name]
+if e == nil {
+return edge{}, false, nil
+}
+if e.State == stateUp {
+now := time.Now()
+e.State = stateDraining
+e.DrainStart = &now
+r.finishLocked(e, now)
+}
+return *e, true, r.saveLocked()
+}
+
+// remove deletes name. Edges that are still up or draining need force.
+func (r *registry) remove(name string, force bool) (edge, bool, error) {
+r.mu.Lock()
+defer r.mu.Unlock()
+e := r.edges[name]
+if e == nil {
+return edge{}, false, nil
+}
+if e.State != stateDrained && !force {
+return *e, true, fmt.Errorf("edge %q is %s: %w", name, e.State, errNotDrained)
+}
+delete(r.edges, name)
+return *e, true, r.saveLocked()
+}
+
+// setLoad records the load an edge reports; an empty queue ends a drain.
+func (r *registry) setLoad(name string, cpu, queue float64) (edge, bool, error) {
+r.mu.Lock()
+defer r.mu.Unlock()
+e := r.edges[name]
+if e == nil {
+return edge{}, false, nil
+}
+e.CPU, e.QueueDepth = cpu, queue
+r.finishLocked(e, time.Now())
+return *e, true, r.saveLocked()
+}
+
+func (r *registry) finishLocked(e *edge, now time.Time) bool {
+if e.State != stateDraining {
+return false
+}
+if e.QueueDepth > 0 && e.DrainStart != nil && now.Sub(*e.DrainStart) < r.drainTimeout {
+return false
+}
+e.State = stateDrained
+e.QueueDepth = 0
+return true
+}
+
+// finishDrains moves draining edges whose queue is empty or whose timeout
+// passed to drained.
+func (r *registry) finishDrains(now time.Time) error {
+r.mu.Lock()
+defer r.mu.Unlock()
+changed := false
+for _, e := range r.edges {
+if r.finishLocked(e, now) {
+changed = true
+}
+}
+if !changed {
+return nil
+}
+return r.saveLocked()
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+"context"
+"encoding/json"
+"fmt"
+"hash/fnv"
+"math"
+"net/http"
+"os"
+"sort"
+"strconv"
+"strings"
+"sync"
+"time"
+
+"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+mUpstreamRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_predict_upstream_requests_total",
+Help: "HTTP calls to model upstreams by upstream (stable|canary) and result (ok|error)",
+}, []string{"upstream", "result"})
+mUpstreamLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+Name:    "csn_predict_upstream_latency_seconds",
+Help:    "HTTP call latency per model upstream",
+Buckets: []float64{.002, .005, .01, .025, .05, .1, .25, .5},
+}, []string{"upstream"})
+mUpstreamVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_predict_upstream_version",
+Help: "1 for the model_version last reported by each upstream",
+}, []string{"upstream", "version"})
+mCanaryRouted = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_predict_routed_total",
+Help: "Predict/PredictBatch calls served by each upstream",
+}, []string{"upstream"})
+mShadowDiff = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+Name:    "csn_predict_canary_diff",
+Help:    "Absolute canary-minus-stable prediction difference from shadow scoring",
+Buckets: []float64{.01, .05, .1, .5, 1, 2, 5, 10, 25, 50, 100},
+}, []string{"metric"})
+mShadowDropped = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_predict_shadow_dropped_total",
+Help: "Shadow scorings skipped because shadowInflight were already running",
+})
+)
+
+func init() {
+prometheus.MustRegister(mUpstreamRequests, mUpstreamLatency, mUpstreamVersion, mCanaryRouted, mShadowDiff, mShadowDropped)
+}
+
+// shadowInflight bounds concurrent shadow scorings; beyond it they are dropped.
+const shadowInflight = 32
+
+func envOr(name, def string) string {
+if v := os.Getenv(name); v != "" {
+return v
+}
+return def
+}
+
+// upstream is one model server (ml/serve_predictor.py).
+type upstream struct {
+name    string
+baseURL string
+
+mu      sync.Mutex
+version string
+}
+
+func newUpstream(name, baseURL string) *upstream {
+return &upstream{name: name, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (u *upstream) observe(d time.Duration, err error) {
+result := "ok"
+if err != nil {
+result = "error"
+}
+mUpstreamRequests.WithLabelValues(u.name, result).Inc()
+mUpstreamLatency.WithLabelValues(u.name).Observe(d.Seconds())
+}
+
+// sawVersion tracks the model_version the upstream reports.
+func (u *upstream) sawVersion(v string) {
+if v == "" {
+return
+}
+u.mu.Lock()
+defer u.mu.Unlock()
+if v == u.version {
+return
+}
+if u.version != "" {
+mUpstreamVersion.DeleteLabelValues(u.name, u.version)
+}
+u.version = v
+mUpstreamVersion.WithLabelValues(u.name, v).Set(1)
+}
+
+// canaryRouter sends a percentage of tenant/app pairs, and every call of the
+// listed tenants, to the canary upstream. The split hashes tenant/app, so a
+// pair sees one model_version throughout (the Decider's prediction cache and
+// its per-action fan-out rely on that). With shadow on, each call is also
+// scored by the other upstream in the background and the difference recorded;
+// the caller only ever sees the primary's answer. A router without an
+// upstream sends everything to stable.
+type canaryRouter struct {
+up    *upstream
+slots chan struct{} // shadow scorings in flight
+
+mu      sync.Mutex
+percent float64
+tenants map[string]bool
+shadow  bool
+}
+
+// canaryFromEnv reads CSN_CANARY_UPSTREAM (empty: no canary),
+// CSN_CANARY_PERCENT (0..100, share of tenant/app pairs), CSN_CANARY_TENANTS
+// (comma list) and CSN_CANARY_SHADOW (true|false).
+func canaryFromEnv() (*canaryRouter, error) {
+r := &canaryRouter{tenants: map[string]bool{}, slots: make(chan struct{}, shadowInflight)}
+if u := strings.TrimSpace(os.Getenv("CSN_CANARY_UPSTREAM")); u != "" {
+r.up = newUpstream("canary", u)
+}
+pct, err := strconv.ParseFloat(envOr("CSN_CANARY_PERCENT", "0"), 64)
+if err != nil {
+return nil, fmt.Errorf("CSN_CANARY_PERCENT: %v", err)
+}
+shadow, err := strconv.ParseBool(envOr("CSN_CANARY_SHADOW", "false"))
+if err != nil {
+return nil, fmt.Errorf("CSN_CANARY_SHADOW: %v", err)
+}
+return r, r.set(pct, splitTenants(os.Getenv("CSN_CANARY_TENANTS")), shadow)
+}
+
+func splitTenants(s string) []string {
+var out []string
+for _, t := range strings.Split(s, ",") {
+if t = strings.TrimSpace(t); t != "" {
+out = append(out, t)
+}
+}
+return out
+}
+
+func (r *canaryRouter) set(percent float64, tenants []string, shadow bool) error {
+if math.IsNaN(percent) || percent < 0 || percent > 100 {
+return fmt.Errorf("percent %v outside [0, 100]", percent)
+}
+r.mu.Lock()
+defer r.mu.Unlock()
+r.percent, r.shadow = percent, shadow
+r.tenants = make(map[string]bool, len(tenants))
+for _, t := range tenants {
+r.tenants[t] = true
+}
+return nil
+}
+
+// routeBucket places tenant/app in [0, 100); raising the percentage only
+// moves pairs from stable to canary, never back.
+func routeBucket(tenant, app string) float64 {
+h := fnv.New64a()
+h.Write([]byte(tenant))
+h.Write([]byte{0})
+h.Write([]byte(app))
+// FNV's high bits barely move for keys like "t1".."t99"; mix before using them
+k := h.Sum64()
+k ^= k >> 33
+k *= 0xff51afd7ed558ccd
+k ^= k >> 33
+k *= 0xc4ceb9fe1a85ec53
+k ^= k >> 33
+return float64(k>>11) / (1 << 53) * 100
+}
+
+// route picks the upstream that answers and, with shadow on, the one that
+// scores in the background.
+func (r *canaryRouter) route(stable *upstream, tenant, app string) (primary, shadow *upstream) {
+primary = stable
+if r == nil || r.up == nil {
+mCanaryRouted.WithLabelValues(primary.name).Inc()
+return primary, nil
+}
+r.mu.Lock()
+toCanary := r.tenants[tenant] || routeBucket(tenant, app) < r.percent
+shadowOn := r.shadow
+r.mu.Unlock()
+if toCanary {
+primary = r.up
+}
+mCanaryRouted.WithLabelValues(primary.name).Inc()
+if !shadowOn {
+return primary, nil
+}
+if primary == stable {
+return primary, r.up
+}
+return primary, stable
+}
+
+func (r *canaryRouter) describe() string {
+if r.up == nil {
+return "no canary"
+}
+v := r.snapshot()
+return fmt.Sprintf("canary %s at %.1f%% tenants=%v shadow=%v", r.up.baseURL, v.Percent, v.Tenants, v.Shadow)
+}
+
+type canaryView struct {
+Upstream string   `json:"upstream,omitempty"`
+Version  string   `json:"version,omitempty"`
+Percent  float64  `json:"percent"`
+Tenants  []string `json:"tenants"`
+Shadow   bool     `json:"shadow"`
+}
+
+func (r *canaryRouter) snapshot() canaryView {
+r.mu.Lock()
+v := canaryView{Percent: r.percent, Shadow: r.shadow, Tenants: []string{}}
+for t := range r.tenants {
+v.Tenants = append(v.Tenants, t)
+}
+r.mu.Unlock()
+sort.Strings(v.Tenants)
+if r.up != nil {
+v.Upstream = r.up.baseURL
+r.up.mu.Lock()
+v.Version = r.up.version
+r.up.mu.Unlock()
+}
+return v
+}
+
+// startShadow runs shadowScore in the background unless shadowInflight
+// scorings are already running; a slow upstream then costs dropped samples
+// rather than goroutines.
+func (s *predictorServer) startShadow(primary, shadow *upstream, in httpBatchIn, outs []httpPredictOut) {
+select {
+case s.canary.slots <- struct{}{}:
+default:
+mShadowDropped.Inc()
+return
+}
+go func() {
+defer func() { <-s.canary.slots }()
+s.shadowScore(primary, shadow, in, outs)
+}()
+}
+
+// shadowScore scores in on shadow and records how far the canary's
+// predictions are from stable's. Errors only show up in the upstream metrics.
+func (s *predictorServer) shadowScore(primary, shadow *upstream, in httpBatchIn, outs []httpPredictOut) {
+ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+defer cancel()
+other, err := s.batchOn(ctx, shadow, in)
+if err != nil {
+return
+}
+canary, stable := outs, other
+if primary == s.stable {
+canary, stable = other, outs
+}
+for i := range canary {
+mShadowDiff.WithLabelValues("mu_latency_ms").Observe(math.Abs(canary[i].MuLatencyMs - stable[i].MuLatencyMs))
+mShadowDiff.WithLabelValues("mu_energy_j").Observe(math.Abs(canary[i].MuEnergyJ - stable[i].MuEnergyJ))
+mShadowDiff.WithLabelValues("p95_conformal_ms").Observe(math.Abs(canary[i].P95ConformalMs - stable[i].P95ConformalMs))
+}
+}
+
+// registerCanaryHandlers exposes GET /canary, POST /canary?percent=&tenants=&shadow=
+// (omitted parameters keep their value) and POST /canary/abort (back to 0%,
+// no tenants, no shadow).
+func registerCanaryHandlers(mux *http.ServeMux, r *canaryRouter) {
+mux.HandleFunc("/canary", func(w http.ResponseWriter, req *http.Request) {
+if req.Method == http.MethodPost {
+if r.up == nil {
+http.Error(w, "no canary upstream (CSN_CANARY_UPSTREAM)", http.StatusConflict)
+return
+}
+cur := r.snapshot()
+pct, tenants, shadow := cur.Percent, cur.Tenants, cur.Shadow
+q := req.URL.Query()
+var err error
+if v := q.Get("percent"); v != "" {
+if pct, err = strconv.ParseFloat(v, 64); err != nil {
+http.Error(w, "percent: "+err.Error(), http.StatusBadRequest)
+return
+}
+}
+if q.Has("tenants") {
+tenants = splitTenants(q.Get("tenants"))
+}
+if v := q.Get("shadow"); v != "" {
+if shadow, err = strconv.ParseBool(v); err != nil {
+http.Error(w, "shadow: "+err.Error(), http.StatusBadRequest)
+return
+}
+}
+if err := r.set(pct, tenants, shadow); err != nil {
+http.Error(w, err.Error(), http.StatusBadRequest)
+return
+}
+}
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(r.snapshot())
+})
+mux.HandleFunc("/canary/abort", func(w http.ResponseWriter, req *http.Request) {
+if req.Method != http.MethodPost {
+http.Error(w, "POST only", http.StatusMethodNotAllowed)
+return
+}
+_ = r.set(0, nil, false)
+w.Header().Set("Content-Type", "application/json")
+_ = json.NewEncoder(w).Encode(r.snapshot())
+})
+}
@@ -8,15 +8,23 @@ import (
 "log"
 "net"
 "net/http"
+"sync"
 "time"
 
+"github.com/mulat/csn/catalog"
 pb "github.com/mulat/csn/proto"
+"github.com/prometheus/client_golang/prometheus/promhttp"
 "google.golang.org/grpc"
+"google.golang.org/grpc/codes"
+"google.golang.org/grpc/status"
 )
 
 type httpPredictIn struct {
 Features []float64 `json:"features"`
 Action   string    `json:"action,omitempty"`
+// resolved from the action catalog so the model server does not parse names
+Kind string `json:"kind,omitempty"`
+Tier string `json:"tier,omitempty"`
 }
 type httpPredictOut struct {
 MuLatencyMs    float64 `json:"mu_latency_ms"`
@@ -24,66 +32,204 @@ VarLatency     float64 `json:"var_latency"`
 MuEnergyJ      float64 `json:"mu_energy_j"`
 VarEnergy      float64 `json:"var_energy"`
 P95ConformalMs float64 `json:"p95_conformal_ms"`
+ModelVersion   string  `json:"model_version"`
+}
+type httpBatchIn struct {
+Rows []httpPredictIn `json:"rows"`
+}
+type httpBatchOut struct {
+Predictions []httpPredictOut `json:"predictions"`
 }
 
 type predictorServer struct {
 pb.UnimplementedPredictorServer
 httpClient *http.Client
-baseURL    string
+stable     *upstream
+canary     *canaryRouter
+actions    *catalog.Catalog
 }
 
-func (s *predictorServer) Predict(ctx context.Context, req *pb.PredictRequest) (*pb.PredictReply, error) {
-// Map gRPC Context -> feature vector in agreed order
-f := []float64{
-req.Ctx.BwMbps,
-req.Ctx.RttMs,
-req.Ctx.Loss,
-req.Ctx.DeviceCpu,
-req.Ctx.EdgeCpu,
-req.Ctx.InputKb,
-req.Ctx.SloP95Ms,
-}
-inp := httpPredictIn{Features: f, Action: req.Action}
-
-body, _ := json.Marshal(inp)
-httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/predict", bytes.NewReader(body))
-httpReq.Header.Set("Content-Type", "application/json")
-
-resp, err := s.httpClient.Do(httpReq)
-if err != nil {
-// graceful fallback: return conservative dummy if HTTP fails
-log.Printf("http predictor error: %v", err)
-return &pb.PredictReply{
-MuLatencyMs:    200,
-VarLatency:     900,
-MuEnergyJ:      0.5,
-VarEnergy:      0.05,
-P95ConformalMs: 240,
-}, nil
+// row builds the HTTP input for one action; unknown actions are rejected.
+func (s *predictorServer) row(f []float64, action string) (httpPredictIn, error) {
+a, ok := s.actions.Lookup(action)
+if !ok {
+return httpPredictIn{}, status.Errorf(codes.InvalidArgument, "unknown action %q", action)
+}
+return httpPredictIn{Features: f, Action: a.Name, Kind: a.Kind, Tier: a.Tier}, nil
 }
-defer resp.Body.Close()
 
-var out httpPredictOut
-if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-log.Printf("decode error: %v", err)
-return nil, err
+// features maps gRPC Context -> feature vector in agreed order
+func features(c *pb.Context) []float64 {
+return []float64{
+c.GetBwMbps(),
+c.GetRttMs(),
+c.GetLoss(),
+c.GetDeviceCpu(),
+c.GetEdgeCpu(),
+c.GetInputKb(),
+c.GetSloP95Ms(),
+}
 }
 
+func toReply(out httpPredictOut) *pb.PredictReply {
 return &pb.PredictReply{
 MuLatencyMs:    out.MuLatencyMs,
 VarLatency:     out.VarLatency,
 MuEnergyJ:      out.MuEnergyJ,
 VarEnergy:      out.VarEnergy,
 P95ConformalMs: out.P95ConformalMs,
-}, nil
+ModelVersion:   out.ModelVersion,
+}
+}
+
+func (s *predictorServer) post(ctx context.Context, up *upstream, path string, in, out interface{}) (int, error) {
+start := time.Now()
+code, err := s.doPost(ctx, up.baseURL+path, in, out)
+up.observe(time.Since(start), err)
+return code, err
+}
+
+func (s *predictorServer) doPost(ctx context.Context, url string, in, out interface{}) (int, error) {
+body, _ := json.Marshal(in)
+httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+httpReq.Header.Set("Content-Type", "application/json")
+resp, err := s.httpClient.Do(httpReq)
+if err != nil {
+return 0, err
+}
+defer resp.Body.Close()
+if resp.StatusCode != http.StatusOK {
+return resp.StatusCode, fmt.Errorf("%s: HTTP %d", url, resp.StatusCode)
+}
+return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (s *predictorServer) Predict(ctx context.Context, req *pb.PredictRequest) (*pb.PredictReply, error) {
+inp, err := s.row(features(req.Ctx), req.Action)
+if err != nil {
+return nil, err
+}
+primary, shadow := s.canary.route(s.stable, req.Ctx.GetTenantId(), req.Ctx.GetAppId())
+out, err := s.predictOn(ctx, primary, inp)
+if err != nil {
+return nil, err
+}
+if shadow != nil {
+s.startShadow(primary, shadow, httpBatchIn{Rows: []httpPredictIn{inp}}, []httpPredictOut{out})
+}
+return toReply(out), nil
+}
+
+func (s *predictorServer) predictOn(ctx context.Context, up *upstream, inp httpPredictIn) (httpPredictOut, error) {
+var out httpPredictOut
+code, err := s.post(ctx, up, "/predict", inp, &out)
+if err != nil && code == 0 {
+// Unavailable lets the Decider's breaker see the outage and switch to its degraded mode
+log.Printf("http predictor (%s) error: %v", up.name, err)
+return out, status.Errorf(codes.Unavailable, "http predictor: %v", err)
+}
+if err != nil {
+log.Printf("predictor (%s) error: %v", up.name, err)
+return out, err
+}
+up.sawVersion(out.ModelVersion)
+return out, nil
+}
+
+// PredictBatch sends all actions as one /predict_batch request; upstreams
+// without that endpoint (404) are served by parallel /predict calls. The whole
+// batch goes to one upstream so the Decider compares actions under one model.
+func (s *predictorServer) PredictBatch(ctx context.Context, req *pb.PredictBatchRequest) (*pb.PredictBatchReply, error) {
+f := features(req.Ctx)
+in := httpBatchIn{Rows: make([]httpPredictIn, len(req.Actions))}
+for i, a := range req.Actions {
+r, err := s.row(f, a)
+if err != nil {
+return nil, err
+}
+in.Rows[i] = r
+}
+primary, shadow := s.canary.route(s.stable, req.Ctx.GetTenantId(), req.Ctx.GetAppId())
+outs, err := s.batchOn(ctx, primary, in)
+if err != nil {
+return nil, err
+}
+if shadow != nil {
+s.startShadow(primary, shadow, in, outs)
+}
+reply := &pb.PredictBatchReply{Predictions: make([]*pb.PredictReply, len(outs))}
+for i, p := range outs {
+reply.Predictions[i] = toReply(p)
+}
+return reply, nil
+}
+
+func (s *predictorServer) batchOn(ctx context.Context, up *upstream, in httpBatchIn) ([]httpPredictOut, error) {
+var out httpBatchOut
+code, err := s.post(ctx, up, "/predict_batch", in, &out)
+switch {
+case err == nil && len(out.Predictions) == len(in.Rows):
+if len(out.Predictions) > 0 {
+up.sawVersion(out.Predictions[0].ModelVersion)
+}
+return out.Predictions, nil
+case err == nil:
+return nil, fmt.Errorf("predict_batch: got %d predictions for %d actions", len(out.Predictions), len(in.Rows))
+case code == 0:
+log.Printf("http predictor (%s) error: %v", up.name, err)
+return nil, status.Errorf(codes.Unavailable, "http predictor: %v", err)
+case code != http.StatusNotFound:
+return nil, err
+}
+
+outs := make([]httpPredictOut, len(in.Rows))
+errs := make([]error, len(in.Rows))
+var wg sync.WaitGroup
+for i, r := range in.Rows {
+wg.Add(1)
+go func(i int, r httpPredictIn) {
+defer wg.Done()
+outs[i], errs[i] = s.predictOn(ctx, up, r)
+}(i, r)
+}
+wg.Wait()
+for _, err := range errs {
+if err != nil {
+return nil, err
+}
+}
+return outs, nil
 }
 
 func main() {
+// CSN_CATALOG must point at the same file as the Decider's catalog.path
+acts, err := catalog.FromEnv()
+if err != nil {
+log.Fatalf("catalog: %v", err)
+}
 cli := &http.Client{Timeout: 500 * time.Millisecond}
+canary, err := canaryFromEnv()
+if err != nil {
+log.Fatalf("canary: %v", err)
+}
 s := &predictorServer{
 httpClient: cli,
-baseURL:    "http://127.0.0.1:8000",
+stable:     newUpstream("stable", envOr("CSN_PREDICT_UPSTREAM", "http://127.0.0.1:8000")),
+canary:     canary,
+actions:    acts,
+}
+
+// metrics and the canary admin API (canary.go)
+metricsListen := envOr("CSN_PREDICT_METRICS_LISTEN", ":9107")
+go func() {
+mux := http.NewServeMux()
+mux.Handle("/metrics", promhttp.Handler())
+registerCanaryHandlers(mux, canary)
+log.Printf("Predictor metrics on %s", metricsListen)
+if err := http.ListenAndServe(metricsListen, mux); err != nil {
+log.Printf("metrics server error: %v", err)
 }
+}()
 
 lis, err := net.Listen("tcp", ":7001")
 if err != nil {
@@ -91,7 +237,7 @@ log.Fatalf("listen: %v", err)
 }
 grpcServer := grpc.NewServer()
 pb.RegisterPredictorServer(grpcServer, s)
-fmt.Println("Predictor (proxy) listening on :7001, using ONNX service at :8000")
+fmt.Printf("Predictor (proxy) listening on :7001, stable upstream %s, %s\n", s.stable.baseURL, canary.describe())
 if err := grpcServer.Serve(lis); err != nil {
 log.Fatalf("serve: %v", err)
 }
@@ -0,0 +1,108 @@
+// Command registry lists, validates, promotes and rolls back model versions
+// under models/. "registry serve" exposes the same operations over gRPC
+// (ModelRegistry) and HTTP.
+//
+//	registry [-root models] list|history
+//	registry [-root models] show|validate <version>
+//	registry [-root models] promote <version> [-reason text]
+//	registry [-root models] rollback [-reason text]
+//	registry [-root models] serve
+package main
+
+import (
+"encoding/json"
+"flag"
+"fmt"
+"log"
+"os"
+
+"github.com/mulat/csn/modelreg"
+)
+
+func usage() {
+fmt.Fprintln(os.Stderr, "usage: registry [-root dir] list|history|show <v>|validate <v>|promote <v> [-reason r]|rollback [-reason r]|serve")
+os.Exit(2)
+}
+
+func printJSON(v interface{}) {
+enc := json.NewEncoder(os.Stdout)
+enc.SetIndent("", "  ")
+_ = enc.Encode(v)
+}
+
+func main() {
+root := flag.String("root", envOr("CSN_MODELS_DIR", "models"), "models directory")
+flag.Parse()
+if flag.NArg() == 0 {
+usage()
+}
+reg := modelreg.New(*root)
+cmd, args := flag.Arg(0), flag.Args()[1:]
+
+switch cmd {
+case "list":
+vs, err := reg.List()
+if err != nil {
+log.Fatal(err)
+}
+for _, v := range vs {
+mark := " "
+if v.Current {
+mark = "*"
+}
+state := "ok"
+if !v.Valid {
+state = fmt.Sprint(v.Problems)
+}
+fmt.Printf("%s %-24s %s  %s\n", mark, v.Name, v.Modified.Format("2006-01-02 15:04:05"), state)
+}
+case "history":
+h, err := reg.History()
+if err != nil {
+log.Fatal(err)
+}
+printJSON(h)
+case "show", "validate":
+if len(args) != 1 {
+usage()
+}
+if cmd == "validate" {
+if err := reg.Validate(args[0]); err != nil {
+log.Fatal(err)
+}
+fmt.Printf("%s: ok\n", args[0])
+return
+}
+v, err := reg.Get(args[0])
+if err != nil {
+log.Fatal(err)
+}
+printJSON(v)
+case "promote", "rollback":
+fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+reason := fs.String("reason", "manual", "recorded in models/.history.jsonl")
+var version string
+if cmd == "promote" {
+if len(args) == 0 {
+usage()
+}
+version, args = args[0], args[1:]
+}
+_ = fs.Parse(args)
+var c modelreg.Change
+var err error
+if cmd == "promote" {
+c, err = reg.Promote(version, *reason)
+} else {
+c, err = reg.Rollback(*reason)
+}
+if err != nil {
+log.Fatal(err)
+}
+fmt.Printf("%s: %s -> %s\n", c.Op, c.From, c.To)
+case "serve":
+serve(reg)
+default:
+usage()
+}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+"context"
+"encoding/json"
+"errors"
+"log"
+"net"
+"net/http"
+"os"
+
+"github.com/prometheus/client_golang/prometheus"
+"github.com/prometheus/client_golang/prometheus/promhttp"
+"google.golang.org/grpc"
+"google.golang.org/grpc/codes"
+"google.golang.org/grpc/status"
+
+"github.com/mulat/csn/modelreg"
+pb "github.com/mulat/csn/proto"
+)
+
+var (
+mChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_model_changes_total",
+Help: "Model promotions and rollbacks by result (ok|error)",
+}, []string{"op", "result"})
+mCurrent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_model_current",
+Help: "1 for the version models/current points to",
+}, []string{"version"})
+)
+
+func init() {
+prometheus.MustRegister(mChanges, mCurrent)
+}
+
+func envOr(name, def string) string {
+if v := os.Getenv(name); v != "" {
+return v
+}
+return def
+}
+
+type registryServer struct {
+pb.UnimplementedModelRegistryServer
+reg *modelreg.Registry
+}
+
+func (s *registryServer) publish() {
+cur, err := s.reg.Current()
+if err != nil {
+return
+}
+mCurrent.Reset()
+if cur != "" {
+mCurrent.WithLabelValues(cur).Set(1)
+}
+}
+
+func (s *registryServer) ListModels(ctx context.Context, req *pb.ListModelsRequest) (*pb.ListModelsReply, error) {
+vs, err := s.reg.List()
+if err != nil {
+return nil, status.Error(codes.Internal, err.Error())
+}
+out := &pb.ListModelsReply{}
+for _, v := range vs {
+out.Versions = append(out.Versions, &pb.ModelVersion{Name: v.Name, Current: v.Current, Valid: v.Valid, Problems: v.Problems, ManifestJson: string(v.Manifest)})
+if v.Current {
+out.Current = v.Name
+}
+}
+return out, nil
+}
+
+// change runs a promotion or rollback and maps its error to a gRPC status.
+func (s *registryServer) change(op string, f func() (modelreg.Change, error)) (*pb.ModelChangeReply, error) {
+c, err := f()
+defer s.publish()
+if err != nil {
+mChanges.WithLabelValues(op, "error").Inc()
+code := codes.FailedPrecondition
+switch {
+case errors.Is(err, modelreg.ErrInvalidName):
+code = codes.InvalidArgument
+case os.IsNotExist(err):
+code = codes.NotFound
+case c.To != "":
+// switched, but the history write failed
+code = codes.Internal
+}
+return nil, status.Error(code, err.Error())
+}
+mChanges.WithLabelValues(op, "ok").Inc()
+log.Printf("%s: %s -> %s (%s)", c.Op, c.From, c.To, c.Reason)
+return &pb.ModelChangeReply{Previous: c.From, Current: c.To}, nil
+}
+
+func (s *registryServer) PromoteModel(ctx context.Context, req *pb.PromoteModelRequest) (*pb.ModelChangeReply, error) {
+return s.change("promote", func() (modelreg.Change, error) { return s.reg.Promote(req.GetVersion(), req.GetReason()) })
+}
+
+func (s *registryServer) RollbackModel(ctx context.Context, req *pb.RollbackModelRequest) (*pb.ModelChangeReply, error) {
+return s.change("rollback", func() (modelreg.Change, error) { return s.reg.Rollback(req.GetReason()) })
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+w.Header().Set("Content-Type", "application/json")
+w.WriteHeader(code)
+_ = json.NewEncoder(w).Encode(v)
+}
+
+// httpStatus maps the gRPC status of a failed call onto HTTP.
+func httpStatus(err error) int {
+switch status.Code(err) {
+case codes.InvalidArgument:
+return http.StatusBadRequest
+case codes.NotFound:
+return http.StatusNotFound
+case codes.FailedPrecondition:
+return http.StatusConflict
+}
+return http.StatusInternalServerError
+}
+
+// registerHTTP mirrors the gRPC API: GET /models, GET /models/history,
+// POST /models/promote?version=&reason=, POST /models/rollback?reason=.
+func (s *registryServer) registerHTTP(mux *http.ServeMux) {
+mux.HandleFunc("/models", func(w http.ResponseWriter, r *http.Request) {
+vs, err := s.reg.List()
+if err != nil {
+writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+return
+}
+cur, _ := s.reg.Current()
+writeJSON(w, http.StatusOK, map[string]interface{}{"current": cur, "versions": vs})
+})
+mux.HandleFunc("/models/history", func(w http.ResponseWriter, r *http.Request) {
+h, err := s.reg.History()
+if err != nil {
+writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+return
+}
+writeJSON(w, http.StatusOK, map[string]interface{}{"history": h})
+})
+post := func(path string, f func(r *http.Request) (*pb.ModelChangeReply, error)) {
+mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+if r.Method != http.MethodPost {
+http.Error(w, "POST only", http.StatusMethodNotAllowed)
+return
+}
+rep, err := f(r)
+if err != nil {
+writeJSON(w, httpStatus(err), map[string]string{"error": status.Convert(err).Message()})
+return
+}
+writeJSON(w, http.StatusOK, map[string]string{"previous": rep.Previous, "current": rep.Current})
+})
+}
+post("/models/promote", func(r *http.Request) (*pb.ModelChangeReply, error) {
+q := r.URL.Query()
+return s.PromoteModel(r.Context(), &pb.PromoteModelRequest{Version: q.Get("version"), Reason: q.Get("reason")})
+})
+post("/models/rollback", func(r *http.Request) (*pb.ModelChangeReply, error) {
+return s.RollbackModel(r.Context(), &pb.RollbackModelRequest{Reason: r.URL.Query().Get("reason")})
+})
+mux.Handle("/metrics", promhttp.Handler())
+}
+
+func serve(reg *modelreg.Registry) {
+grpcAddr := envOr("CSN_REGISTRY_LISTEN", ":7003")
+httpAddr := envOr("CSN_REGISTRY_HTTP", ":9106")
+s := &registryServer{reg: reg}
+s.publish()
+
+mux := http.NewServeMux()
+s.registerHTTP(mux)
+go func() {
+log.Printf("Registry HTTP on %s", httpAddr)
+if err := http.ListenAndServe(httpAddr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+log.Printf("http server error: %v", err)
+}
+}()
+
+lis, err := net.Listen("tcp", grpcAddr)
+if err != nil {
+log.Fatalf("listen: %v", err)
+}
+g := grpc.NewServer()
+pb.RegisterModelRegistryServer(g, s)
+log.Printf("Registry gRPC on %s (root %s)", grpcAddr, reg.Root)
+if err := g.Serve(lis); err != nil {
+log.Fatalf("serve: %v", err)
+}
+}
+
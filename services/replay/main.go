@@ -0,0 +1,227 @@
+// Command replay sends the requests recorded in a Decider decision log back to
+// a Decider, in order, one at a time. Each request carries its recorded time
+// (gRPC metadata csn-ts) and reported outcomes are replayed against the new
+// decision IDs, so a Decider in deterministic mode (deterministic.enabled,
+// same seed and config) returns the same decisions on every replay.
+//
+//	replay [-addr 127.0.0.1:7002] [-o replay.jsonl] [-compare previous.jsonl] decisions.jsonl ...
+//
+// Only admitted decisions are logged; requests denied by quota are not
+// replayed, so runs with throttled tenants do not reproduce exactly. Capacity
+// factors are not recorded either: a deterministic Decider does not poll the
+// Operator and scores edge actions at the base factor, so decisions from a
+// live run that had the capacity poller on can differ from their replay.
+package main
+
+import (
+"bufio"
+"context"
+"encoding/json"
+"flag"
+"fmt"
+"io"
+"log"
+"os"
+"time"
+
+"google.golang.org/grpc"
+"google.golang.org/grpc/metadata"
+
+pb "github.com/mulat/csn/proto"
+)
+
+// record is the part of a decision-log line a replay needs.
+type record struct {
+Kind       string `json:"kind"`
+DecisionID string `json:"decision_id"`
+TS         string `json:"ts"`
+
+Context *struct {
+TenantID   string  `json:"tenant_id"`
+AppID      string  `json:"app_id"`
+BwMbps     float64 `json:"bw_mbps"`
+RttMs      float64 `json:"rtt_ms"`
+Loss       float64 `json:"loss"`
+DeviceCPU  float64 `json:"device_cpu"`
+BatterySoc float64 `json:"battery_soc"`
+EdgeCPU    float64 `json:"edge_cpu"`
+InputKb    float64 `json:"input_kb"`
+SloP95Ms   float64 `json:"slo_p95_ms"`
+} `json:"context"`
+FeasibleActions []string `json:"feasible_actions"`
+Chosen          string   `json:"chosen"`
+
+Source            string   `json:"source"`
+ObservedLatencyMs *float64 `json:"observed_latency_ms"`
+ObservedEnergyJ   *float64 `json:"observed_energy_j"`
+}
+
+// result is one replayed decision, written as JSONL.
+type result struct {
+Seq            int     `json:"seq"`
+TS             string  `json:"ts"`
+RecordedID     string  `json:"recorded_id"`
+RecordedChosen string  `json:"recorded_chosen"`
+DecisionID     string  `json:"decision_id"`
+Chosen         string  `json:"chosen"`
+Propensity     float64 `json:"propensity"`
+Reason         string  `json:"reason"`
+}
+
+func main() {
+addr := flag.String("addr", "127.0.0.1:7002", "Decider gRPC address")
+outPath := flag.String("o", "", "write replayed decisions as JSONL here (default: stdout)")
+compare := flag.String("compare", "", "JSONL from an earlier replay; report the first decision that differs")
+flag.Parse()
+if flag.NArg() == 0 {
+fmt.Fprintln(os.Stderr, "usage: replay [-addr host:port] [-o out.jsonl] [-compare prev.jsonl] decisions.jsonl ...")
+os.Exit(2)
+}
+
+conn, err := grpc.Dial(*addr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(2*time.Second))
+if err != nil {
+log.Fatalf("connect decider: %v", err)
+}
+defer conn.Close()
+dec := pb.NewDeciderClient(conn)
+
+var out io.Writer = os.Stdout
+if *outPath != "" {
+f, err := os.Create(*outPath)
+if err != nil {
+log.Fatal(err)
+}
+defer f.Close()
+out = f
+}
+w := bufio.NewWriter(out)
+defer w.Flush()
+enc := json.NewEncoder(w)
+
+ids := map[string]string{} // recorded decision ID -> replayed one
+var results []result
+same, outcomes := 0, 0
+for _, path := range flag.Args() {
+err := eachRecord(path, func(r *record) error {
+ctx, cancel := context.WithTimeout(metadata.AppendToOutgoingContext(context.Background(), "csn-ts", r.TS), 5*time.Second)
+defer cancel()
+switch r.Kind {
+case "decision":
+if r.Context == nil {
+return nil
+}
+c := r.Context
+reply, err := dec.Decide(ctx, &pb.DecideRequest{
+Ctx: &pb.Context{
+TenantId: c.TenantID, AppId: c.AppID, BwMbps: c.BwMbps, RttMs: c.RttMs, Loss: c.Loss,
+DeviceCpu: c.DeviceCPU, BatterySoc: c.BatterySoc, EdgeCpu: c.EdgeCPU, InputKb: c.InputKb, SloP95Ms: c.SloP95Ms,
+},
+FeasibleActions: r.FeasibleActions,
+})
+if err != nil {
+return fmt.Errorf("decide %s: %v", r.DecisionID, err)
+}
+ids[r.DecisionID] = reply.DecisionId
+res := result{
+Seq: len(results), TS: r.TS, RecordedID: r.DecisionID, RecordedChosen: r.Chosen,
+DecisionID: reply.DecisionId, Chosen: reply.ChosenAction, Propensity: reply.Propensity, Reason: reply.Reason,
+}
+if res.Chosen == res.RecordedChosen {
+same++
+}
+results = append(results, res)
+return enc.Encode(res)
+case "outcome":
+id, ok := ids[r.DecisionID]
+if !ok || r.Source != "reported" || r.ObservedLatencyMs == nil {
+// timeouts resolve on the replayed Decider's own clock
+return nil
+}
+en := 0.0
+if r.ObservedEnergyJ != nil {
+en = *r.ObservedEnergyJ
+}
+if _, err := dec.ReportOutcome(ctx, &pb.OutcomeRequest{DecisionId: id, ObservedLatencyMs: *r.ObservedLatencyMs, ObservedEnergyJ: en}); err != nil {
+return fmt.Errorf("outcome %s: %v", r.DecisionID, err)
+}
+outcomes++
+}
+return nil
+})
+if err != nil {
+log.Fatalf("%s: %v", path, err)
+}
+}
+log.Printf("replayed %d decisions and %d outcomes; %d chose the recorded action", len(results), outcomes, same)
+
+if *compare != "" {
+prev, err := loadResults(*compare)
+if err != nil {
+log.Fatal(err)
+}
+if i, why := firstDiff(prev, results); i >= 0 {
+w.Flush()
+log.Fatalf("differs from %s at seq %d: %s", *compare, i, why)
+}
+log.Printf("identical to %s", *compare)
+}
+}
+
+func eachRecord(path string, fn func(*record) error) error {
+f, err := os.Open(path)
+if err != nil {
+return err
+}
+defer f.Close()
+sc := bufio.NewScanner(f)
+sc.Buffer(make([]byte, 1<<20), 16<<20)
+line := 0
+for sc.Scan() {
+line++
+var r record
+if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+return fmt.Errorf("line %d: %v", line, err)
+}
+if err := fn(&r); err != nil {
+return err
+}
+}
+return sc.Err()
+}
+
+func loadResults(path string) ([]result, error) {
+f, err := os.Open(path)
+if err != nil {
+return nil, err
+}
+defer f.Close()
+var out []result
+dec := json.NewDecoder(f)
+for {
+var r result
+if err := dec.Decode(&r); err == io.EOF {
+return out, nil
+} else if err != nil {
+return nil, fmt.Errorf("%s: %v", path, err)
+}
+out = append(out, r)
+}
+}
+
+// firstDiff compares two replays decision by decision.
+func firstDiff(a, b []result) (int, string) {
+for i := 0; i < len(a) && i < len(b); i++ {
+switch {
+case a[i].Chosen != b[i].Chosen:
+return i, fmt.Sprintf("chose %s, now %s", a[i].Chosen, b[i].Chosen)
+case a[i].DecisionID != b[i].DecisionID:
+return i, fmt.Sprintf("decision ID %s, now %s", a[i].DecisionID, b[i].DecisionID)
+case a[i].Propensity != b[i].Propensity:
+return i, fmt.Sprintf("propensity %v, now %v", a[i].Propensity, b[i].Propensity)
+}
+}
+if len(a) != len(b) {
+return min(len(a), len(b)), fmt.Sprintf("%d decisions, now %d", len(a), len(b))
+}
+return -1, ""
+}
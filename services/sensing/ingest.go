@@ -0,0 +1,279 @@
+package main
+
+import (
+"bufio"
+"bytes"
+"encoding/csv"
+"encoding/json"
+"errors"
+"fmt"
+"io"
+"math"
+"os"
+"path/filepath"
+"strconv"
+"strings"
+"sync"
+"time"
+
+"github.com/mulat/csn/catalog"
+)
+
+// telemetryHeader is the experiments/telemetry.csv schema plus the decision ID
+// and the predictor's estimate for the chosen action (empty when unknown).
+var telemetryHeader = []string{"ts", "tenant", "app", "bw_mbps", "rtt_ms", "loss", "device_cpu", "edge_cpu", "input_kb", "slo_p95_ms", "action", "obs_latency_ms", "obs_energy_j",
+"decision_id", "lat_mu", "lat_var", "en_mu", "p95_conf"}
+
+// sensePayload matches what the Decider's telemetry queue sends. DecisionID,
+// TS and the observed latency/energy are optional; producers that know the
+// outcome fill them in.
+type sensePayload struct {
+DecisionID string `json:"decision_id,omitempty"`
+TS      *int64   `json:"ts,omitempty"`
+Tenant  string   `json:"tenant"`
+App     string   `json:"app"`
+Bw      float64  `json:"bw"`
+Rtt     float64  `json:"rtt"`
+Loss    float64  `json:"loss"`
+DevCPU  float64  `json:"dev_cpu"`
+Soc     float64  `json:"soc"`
+EdgeCPU float64  `json:"edge_cpu"`
+InputKB float64  `json:"input_kb"`
+SloMS   float64  `json:"slo_ms"`
+Action  string   `json:"action"`
+LatMu   *float64 `json:"lat_mu,omitempty"`
+LatVar  *float64 `json:"lat_var,omitempty"`
+EnMu    *float64 `json:"en_mu,omitempty"`
+P95Conf *float64 `json:"p95_conf,omitempty"`
+ObsLatMs *float64 `json:"obs_latency_ms,omitempty"`
+ObsEnJ   *float64 `json:"obs_energy_j,omitempty"`
+}
+
+// rejectError carries the short reason used as the csn_sense_rejected_total label.
+type rejectError struct {
+reason string
+msg    string
+}
+
+func (e *rejectError) Error() string { return e.msg }
+
+func reject(reason, format string, args ...interface{}) error {
+return &rejectError{reason: reason, msg: fmt.Sprintf(format, args...)}
+}
+
+func rejectReason(err error) string {
+var re *rejectError
+if errors.As(err, &re) {
+return re.reason
+}
+return "invalid"
+}
+
+func inRange(name string, v, lo, hi float64) error {
+if math.IsNaN(v) || math.IsInf(v, 0) || v < lo || v > hi {
+return reject("range", "%s=%v outside [%v, %v]", name, v, lo, hi)
+}
+return nil
+}
+
+// validate checks one record; actions must be in the catalog.
+func validate(p *sensePayload, actions *catalog.Catalog) error {
+if p.Tenant == "" || p.App == "" || p.Action == "" {
+return reject("missing", "tenant, app and action are required")
+}
+if _, ok := actions.Lookup(p.Action); !ok {
+return reject("action", "unknown action %q", p.Action)
+}
+checks := []struct {
+name      string
+v, lo, hi float64
+}{
+{"bw", p.Bw, 0, math.MaxFloat64},
+{"rtt", p.Rtt, 0, math.MaxFloat64},
+{"loss", p.Loss, 0, 1},
+{"dev_cpu", p.DevCPU, 0, 1},
+{"soc", p.Soc, 0, 1},
+{"edge_cpu", p.EdgeCPU, 0, 1},
+{"input_kb", p.InputKB, 0, math.MaxFloat64},
+{"slo_ms", p.SloMS, math.SmallestNonzeroFloat64, math.MaxFloat64},
+}
+for _, c := range checks {
+if err := inRange(c.name, c.v, c.lo, c.hi); err != nil {
+return err
+}
+}
+for name, v := range map[string]*float64{"obs_latency_ms": p.ObsLatMs, "obs_energy_j": p.ObsEnJ,
+"lat_mu": p.LatMu, "lat_var": p.LatVar, "en_mu": p.EnMu, "p95_conf": p.P95Conf} {
+if v == nil {
+continue
+}
+if err := inRange(name, *v, 0, math.MaxFloat64); err != nil {
+return err
+}
+}
+return nil
+}
+
+// decodeRecords accepts a single object, a JSON array, or {"records": [...]}.
+// Unknown fields are rejected so schema drift shows up as errors.
+func decodeRecords(r io.Reader) ([]json.RawMessage, bool, error) {
+body, err := io.ReadAll(r)
+if err != nil {
+return nil, false, err
+}
+body = bytes.TrimSpace(body)
+if len(body) == 0 {
+return nil, false, errors.New("empty body")
+}
+if body[0] == '[' {
+var rows []json.RawMessage
+if err := json.Unmarshal(body, &rows); err != nil {
+return nil, true, err
+}
+return rows, true, nil
+}
+var wrapped struct {
+Records []json.RawMessage `json:"records"`
+}
+if err := json.Unmarshal(body, &wrapped); err == nil && wrapped.Records != nil {
+return wrapped.Records, true, nil
+}
+return []json.RawMessage{body}, false, nil
+}
+
+func parseRecord(raw json.RawMessage) (sensePayload, error) {
+var p sensePayload
+dec := json.NewDecoder(bytes.NewReader(raw))
+dec.DisallowUnknownFields()
+if err := dec.Decode(&p); err != nil {
+return p, reject("decode", "%v", err)
+}
+return p, nil
+}
+
+// telemetryWriter appends rows to the telemetry CSV.
+type telemetryWriter struct {
+mu   sync.Mutex
+path string
+f    *os.File
+}
+
+// openTelemetry opens path for appending, writing the header into a new file and
+// refusing an existing file whose header differs.
+func openTelemetry(path string) (*telemetryWriter, error) {
+if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+return nil, err
+}
+f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+if err != nil {
+return nil, err
+}
+st, err := f.Stat()
+if err != nil {
+f.Close()
+return nil, err
+}
+tw := &telemetryWriter{path: path, f: f}
+if st.Size() == 0 {
+if err := tw.write([][]string{telemetryHeader}); err != nil {
+f.Close()
+return nil, err
+}
+return tw, nil
+}
+first, err := bufio.NewReader(io.NewSectionReader(f, 0, st.Size())).ReadString('\n')
+if err != nil && err != io.EOF {
+f.Close()
+return nil, err
+}
+if got := strings.TrimSpace(first); got != strings.Join(telemetryHeader, ",") {
+f.Close()
+return nil, fmt.Errorf("%s: header %q does not match the telemetry schema", path, got)
+}
+return tw, nil
+}
+
+func fmtFloat(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+func fmtOpt(v *float64) string {
+if v == nil {
+return ""
+}
+return fmtFloat(*v)
+}
+
+// row renders p in telemetryHeader order; ts defaults to now (unix seconds).
+func row(p *sensePayload, now time.Time) []string {
+ts := now.Unix()
+if p.TS != nil {
+ts = *p.TS
+}
+return []string{
+strconv.FormatInt(ts, 10), p.Tenant, p.App,
+fmtFloat(p.Bw), fmtFloat(p.Rtt), fmtFloat(p.Loss), fmtFloat(p.DevCPU), fmtFloat(p.EdgeCPU),
+fmtFloat(p.InputKB), fmtFloat(p.SloMS), p.Action, fmtOpt(p.ObsLatMs), fmtOpt(p.ObsEnJ),
+p.DecisionID, fmtOpt(p.LatMu), fmtOpt(p.LatVar), fmtOpt(p.EnMu), fmtOpt(p.P95Conf),
+}
+}
+
+// write renders rows into one buffer and appends it with a single write, so a
+// batch is not interleaved with concurrent requests.
+func (t *telemetryWriter) write(rows [][]string) error {
+var buf bytes.Buffer
+cw := csv.NewWriter(&buf)
+if err := cw.WriteAll(rows); err != nil {
+return err
+}
+t.mu.Lock()
+defer t.mu.Unlock()
+_, err := t.f.Write(buf.Bytes())
+return err
+}
+
+func (t *telemetryWriter) Close() error {
+t.mu.Lock()
+defer t.mu.Unlock()
+return t.f.Close()
+}
+
+// recentIDs remembers the last n decision IDs so a batch the Decider resends
+// after a lost reply is not appended twice.
+type recentIDs struct {
+mu   sync.Mutex
+ring []string
+idx  int
+set  map[string]struct{}
+}
+
+func newRecentIDs(n int) *recentIDs {
+return &recentIDs{ring: make([]string, n), set: make(map[string]struct{}, n)}
+}
+
+// has reports whether id was ingested recently; empty IDs never are.
+func (r *recentIDs) has(id string) bool {
+if id == "" {
+return false
+}
+r.mu.Lock()
+defer r.mu.Unlock()
+_, ok := r.set[id]
+return ok
+}
+
+// add remembers id once its record has been written.
+func (r *recentIDs) add(id string) {
+if id == "" {
+return
+}
+r.mu.Lock()
+defer r.mu.Unlock()
+if _, ok := r.set[id]; ok {
+return
+}
+if old := r.ring[r.idx]; old != "" {
+delete(r.set, old)
+}
+r.ring[r.idx] = id
+r.set[id] = struct{}{}
+r.idx = (r.idx + 1) % len(r.ring)
+}
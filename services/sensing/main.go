@@ -0,0 +1,216 @@
+package main
+
+import (
+"encoding/json"
+"log"
+"net/http"
+"os"
+"strconv"
+"time"
+
+"github.com/mulat/csn/catalog"
+"github.com/prometheus/client_golang/prometheus"
+"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+mRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_sense_requests_total",
+Help: "Ingest requests by shape (single|batch)",
+}, []string{"kind"})
+mAccepted = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_sense_records_accepted_total",
+Help: "Records appended to the telemetry CSV",
+})
+mRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+Name: "csn_sense_records_rejected_total",
+Help: "Records rejected by validation, by reason",
+}, []string{"reason"})
+mBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+Name:    "csn_sense_batch_size",
+Help:    "Records per ingest request",
+Buckets: []float64{1, 2, 5, 10, 25, 50, 100, 250, 500},
+})
+mDuplicates = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_sense_records_duplicate_total",
+Help: "Records skipped because their decision_id was already ingested",
+})
+mWriteErrors = prometheus.NewCounter(prometheus.CounterOpts{
+Name: "csn_sense_write_errors_total",
+Help: "Batches that could not be appended to the telemetry CSV",
+})
+mRttMean = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_sense_rtt_ms_mean",
+Help: "Rolling mean RTT per tenant/app (pairs beyond CSN_SENSE_MAX_PAIRS as other/other)",
+}, []string{"tenant", "app"})
+mRttP95 = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_sense_rtt_ms_p95",
+Help: "Rolling p95 RTT per tenant/app (pairs beyond CSN_SENSE_MAX_PAIRS as other/other)",
+}, []string{"tenant", "app"})
+mBwMean = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_sense_bw_mbps_mean",
+Help: "Rolling mean bandwidth per tenant/app (pairs beyond CSN_SENSE_MAX_PAIRS as other/other)",
+}, []string{"tenant", "app"})
+mLossMean = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+Name: "csn_sense_loss_mean",
+Help: "Rolling mean loss rate per tenant/app (pairs beyond CSN_SENSE_MAX_PAIRS as other/other)",
+}, []string{"tenant", "app"})
+)
+
+func init() {
+prometheus.MustRegister(mRequests, mAccepted, mRejected, mDuplicates, mBatchSize, mWriteErrors, mRttMean, mRttP95, mBwMean, mLossMean)
+}
+
+func envOr(name, def string) string {
+if v := os.Getenv(name); v != "" {
+return v
+}
+return def
+}
+
+// maxBody bounds one ingest request; dedupIDs is how many decision IDs are
+// remembered for dropping resent records.
+const (
+maxBody  = 4 << 20
+dedupIDs = 100000
+)
+
+type sensingServer struct {
+actions *catalog.Catalog
+out     *telemetryWriter
+stats   *netStats
+seen    *recentIDs
+}
+
+type recordError struct {
+Index int    `json:"index"`
+Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+w.Header().Set("Content-Type", "application/json")
+w.WriteHeader(code)
+_ = json.NewEncoder(w).Encode(v)
+}
+
+// handleIngest validates every record, skips decision IDs it has already
+// ingested, appends the rest in one write and reports the rejected ones by
+// index. It answers 400 only when every record was rejected.
+func (s *sensingServer) handleIngest(w http.ResponseWriter, r *http.Request) {
+if r.Method != http.MethodPost {
+writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"ok": false, "error": "POST only"})
+return
+}
+raws, batch, err := decodeRecords(http.MaxBytesReader(w, r.Body, maxBody))
+kind := "single"
+if batch {
+kind = "batch"
+}
+mRequests.WithLabelValues(kind).Inc()
+if err != nil {
+mRejected.WithLabelValues("decode").Inc()
+writeJSON(w, http.StatusBadRequest, map[string]interface{}{"ok": false, "error": err.Error()})
+return
+}
+mBatchSize.Observe(float64(len(raws)))
+
+now := time.Now()
+rows := make([][]string, 0, len(raws))
+valid := make([]sensePayload, 0, len(raws))
+errs := []recordError{}
+dups := 0
+batchIDs := map[string]bool{}
+for i, raw := range raws {
+p, err := parseRecord(raw)
+if err == nil {
+err = validate(&p, s.actions)
+}
+if err != nil {
+mRejected.WithLabelValues(rejectReason(err)).Inc()
+errs = append(errs, recordError{Index: i, Error: err.Error()})
+continue
+}
+if s.seen.has(p.DecisionID) || batchIDs[p.DecisionID] {
+mDuplicates.Inc()
+dups++
+continue
+}
+if p.DecisionID != "" {
+batchIDs[p.DecisionID] = true
+}
+rows = append(rows, row(&p, now))
+valid = append(valid, p)
+}
+if len(rows) > 0 {
+if err := s.out.write(rows); err != nil {
+mWriteErrors.Inc()
+log.Printf("telemetry write: %v", err)
+writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"ok": false, "error": err.Error()})
+return
+}
+mAccepted.Add(float64(len(rows)))
+for i := range valid {
+s.seen.add(valid[i].DecisionID)
+sum := s.stats.observe(&valid[i])
+mRttMean.WithLabelValues(sum.Tenant, sum.App).Set(sum.RttMean)
+mRttP95.WithLabelValues(sum.Tenant, sum.App).Set(sum.RttP95)
+mBwMean.WithLabelValues(sum.Tenant, sum.App).Set(sum.BwMean)
+mLossMean.WithLabelValues(sum.Tenant, sum.App).Set(sum.LossMean)
+}
+}
+code := http.StatusOK
+if len(rows) == 0 && dups == 0 {
+code = http.StatusBadRequest
+}
+writeJSON(w, code, map[string]interface{}{"ok": code == http.StatusOK, "accepted": len(rows), "duplicates": dups, "rejected": errs})
+}
+
+func (s *sensingServer) handleStats(w http.ResponseWriter, r *http.Request) {
+writeJSON(w, http.StatusOK, map[string]interface{}{"window": s.stats.size, "stats": s.stats.snapshot()})
+}
+
+func main() {
+listen := envOr("CSN_SENSE_LISTEN", ":9105")
+metricsListen := envOr("CSN_SENSE_METRICS_LISTEN", ":9104")
+// live rows go next to, not into, the training/drift reference experiments/telemetry.csv
+path := envOr("CSN_FEATURES_CSV", "experiments/telemetry_live.csv")
+window, err := strconv.Atoi(envOr("CSN_SENSE_WINDOW", "100"))
+if err != nil || window < 1 {
+log.Fatalf("CSN_SENSE_WINDOW: want a positive integer, got %q", os.Getenv("CSN_SENSE_WINDOW"))
+}
+// bounds the per-tenant/app windows and gauge series; later pairs are "other"
+maxPairs, err := strconv.Atoi(envOr("CSN_SENSE_MAX_PAIRS", "1000"))
+if err != nil || maxPairs < 1 {
+log.Fatalf("CSN_SENSE_MAX_PAIRS: want a positive integer, got %q", os.Getenv("CSN_SENSE_MAX_PAIRS"))
+}
+// CSN_CATALOG must point at the same file as the Decider's catalog.path
+acts, err := catalog.FromEnv()
+if err != nil {
+log.Fatalf("catalog: %v", err)
+}
+out, err := openTelemetry(path)
+if err != nil {
+log.Fatalf("telemetry: %v", err)
+}
+defer out.Close()
+s := &sensingServer{actions: acts, out: out, stats: newNetStats(window, maxPairs), seen: newRecentIDs(dedupIDs)}
+
+// metrics + stats on the port Prometheus scrapes
+go func() {
+mux := http.NewServeMux()
+mux.Handle("/metrics", promhttp.Handler())
+mux.HandleFunc("/stats", s.handleStats)
+log.Printf("Sensing metrics on %s", metricsListen)
+if err := http.ListenAndServe(metricsListen, mux); err != nil {
+log.Printf("metrics server error: %v", err)
+}
+}()
+
+mux := http.NewServeMux()
+mux.HandleFunc("/ingest", s.handleIngest)
+mux.HandleFunc("/stats", s.handleStats)
+log.Printf("Sensing ingest on %s, appending to %s", listen, path)
+if err := http.ListenAndServe(listen, mux); err != nil {
+log.Fatalf("serve: %v", err)
+}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+"math"
+"sort"
+"sync"
+)
+
+// netWindow keeps the last n network samples of one tenant/app.
+type netWindow struct {
+bw, rtt, loss []float64
+idx, n        int
+total         int64
+}
+
+func newNetWindow(size int) *netWindow {
+return &netWindow{bw: make([]float64, size), rtt: make([]float64, size), loss: make([]float64, size)}
+}
+
+func (w *netWindow) add(bw, rtt, loss float64) {
+w.bw[w.idx], w.rtt[w.idx], w.loss[w.idx] = bw, rtt, loss
+w.idx = (w.idx + 1) % len(w.bw)
+if w.n < len(w.bw) {
+w.n++
+}
+w.total++
+}
+
+func mean(xs []float64) float64 {
+if len(xs) == 0 {
+return 0
+}
+s := 0.0
+for _, x := range xs {
+s += x
+}
+return s / float64(len(xs))
+}
+
+func stddev(xs []float64) float64 {
+if len(xs) < 2 {
+return 0
+}
+m := mean(xs)
+s := 0.0
+for _, x := range xs {
+s += (x - m) * (x - m)
+}
+return math.Sqrt(s / float64(len(xs)-1))
+}
+
+// quantile uses nearest rank on a sorted copy.
+func quantile(xs []float64, q float64) float64 {
+if len(xs) == 0 {
+return 0
+}
+c := append([]float64(nil), xs...)
+sort.Float64s(c)
+i := int(math.Ceil(q*float64(len(c)))) - 1
+if i < 0 {
+i = 0
+}
+return c[i]
+}
+
+// netSummary is the rolling view served on /stats and exported as gauges.
+type netSummary struct {
+Tenant    string  `json:"tenant"`
+App       string  `json:"app"`
+Samples   int     `json:"samples"`
+Total     int64   `json:"total"`
+BwMean    float64 `json:"bw_mbps_mean"`
+BwP5      float64 `json:"bw_mbps_p5"`
+RttMean   float64 `json:"rtt_ms_mean"`
+RttStd    float64 `json:"rtt_ms_std"`
+RttP95    float64 `json:"rtt_ms_p95"`
+LossMean  float64 `json:"loss_mean"`
+}
+
+func (w *netWindow) summary() netSummary {
+bw, rtt, loss := w.bw[:w.n], w.rtt[:w.n], w.loss[:w.n]
+return netSummary{
+Samples:  w.n,
+Total:    w.total,
+BwMean:   mean(bw),
+BwP5:     quantile(bw, 0.05),
+RttMean:  mean(rtt),
+RttStd:   stddev(rtt),
+RttP95:   quantile(rtt, 0.95),
+LossMean: mean(loss),
+}
+}
+
+type netKey struct{ tenant, app string }
+
+// otherPair is where samples of tenant/app pairs beyond the cap go: both come
+// from clients, so they cannot all become windows and gauge labels.
+var otherPair = netKey{"other", "other"}
+
+// netStats holds one rolling window per tenant/app, for at most maxPairs
+// pairs in order of arrival; later pairs share the "other" window.
+type netStats struct {
+mu       sync.Mutex
+size     int
+maxPairs int
+win      map[netKey]*netWindow
+}
+
+func newNetStats(size, maxPairs int) *netStats {
+return &netStats{size: size, maxPairs: maxPairs, win: make(map[netKey]*netWindow)}
+}
+
+// observe adds a sample and returns the updated summary.
+func (s *netStats) observe(p *sensePayload) netSummary {
+s.mu.Lock()
+defer s.mu.Unlock()
+k := netKey{p.Tenant, p.App}
+w := s.win[k]
+if w == nil && len(s.win) >= s.maxPairs {
+k = otherPair
+w = s.win[k]
+}
+if w == nil {
+w = newNetWindow(s.size)
+s.win[k] = w
+}
+w.add(p.Bw, p.Rtt, p.Loss)
+sum := w.summary()
+sum.Tenant, sum.App = k.tenant, k.app
+return sum
+}
+
+func (s *netStats) snapshot() []netSummary {
+s.mu.Lock()
+defer s.mu.Unlock()
+out := make([]netSummary, 0, len(s.win))
+for k, w := range s.win {
+sum := w.summary()
+sum.Tenant, sum.App = k.tenant, k.app
+out = append(out, sum)
+}
+sort.Slice(out, func(i, j int) bool {
+if out[i].Tenant != out[j].Tenant {
+return out[i].Tenant < out[j].Tenant
+}
+return out[i].App < out[j].App
+})
+return out
+}